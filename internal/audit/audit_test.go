@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf)
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+
+	logger.Log(Entry{
+		Operation: OperationUpdate,
+		Name:      "test-rule",
+		Domain:    "example.com",
+		Subject:   "alice",
+		Before:    map[string]interface{}{"port": 3000},
+		After:     map[string]interface{}{"port": 8080},
+	})
+	logger.Log(Entry{Operation: OperationDelete, Name: "other-rule"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("failed to parse line: %v", err)
+	}
+	for key, want := range map[string]interface{}{
+		"timestamp": "2025-01-02T03:04:05Z",
+		"operation": "update",
+		"name":      "test-rule",
+		"domain":    "example.com",
+		"subject":   "alice",
+	} {
+		if first[key] != want {
+			t.Errorf("expected %s %v, got %v", key, want, first[key])
+		}
+	}
+	if before, _ := first["before"].(map[string]interface{}); before["port"] != float64(3000) {
+		t.Errorf("expected before spec, got %v", first["before"])
+	}
+
+	var second Entry
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("failed to parse line: %v", err)
+	}
+	if second.Subject != AnonymousSubject {
+		t.Errorf("expected anonymous subject, got %q", second.Subject)
+	}
+	if strings.Contains(lines[1], `"after"`) {
+		t.Error("expected after to be omitted when empty")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		logger, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		logger.Log(Entry{Operation: OperationCreate, Name: "test-rule"})
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("expected entries to be appended (2 lines), got %d", lines)
+	}
+
+	if _, err := Open(filepath.Join(t.TempDir(), "missing", "audit.log")); err == nil {
+		t.Error("expected error for unwritable path")
+	}
+}
+
+func TestSubjectFromContext(t *testing.T) {
+	if got := SubjectFromContext(context.Background()); got != AnonymousSubject {
+		t.Errorf("expected %q without subject, got %q", AnonymousSubject, got)
+	}
+	if got := SubjectFromContext(WithSubject(context.Background(), "alice")); got != "alice" {
+		t.Errorf("expected alice, got %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+)
+
+var testSecret = []byte("test-secret")
+
+func encodeSegment(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode token segment: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]interface{}) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	input := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestHMACVerifier(t *testing.T) {
+	now := time.Now()
+	verifier := NewHMACVerifier(testSecret)
+
+	tests := []struct {
+		name      string
+		token     string
+		wantError string
+	}{
+		{
+			name:  "valid token",
+			token: signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "exp": now.Add(time.Hour).Unix()}),
+		},
+		{
+			name:      "wrong secret",
+			token:     signHS256(t, []byte("other-secret"), map[string]interface{}{"sub": "alice"}),
+			wantError: "invalid token signature",
+		},
+		{
+			name:      "expired",
+			token:     signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "exp": now.Add(-time.Hour).Unix()}),
+			wantError: "expired",
+		},
+		{
+			name:      "not valid yet",
+			token:     signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "nbf": now.Add(time.Hour).Unix()}),
+			wantError: "not valid yet",
+		},
+		{
+			name:      "no subject",
+			token:     signHS256(t, testSecret, map[string]interface{}{"exp": now.Add(time.Hour).Unix()}),
+			wantError: "no subject",
+		},
+		{
+			name:      "unsigned",
+			token:     encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, map[string]interface{}{"sub": "alice"}) + ".",
+			wantError: "unsupported signing algorithm",
+		},
+		{
+			name:      "malformed",
+			token:     "not-a-token",
+			wantError: "malformed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if claims.Subject != "alice" {
+					t.Errorf("expected subject alice, got %q", claims.Subject)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	fetches := 0
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	defer jwks.Close()
+
+	verifier := NewJWKSVerifier(jwks.URL, jwks.Client())
+	claims := map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+
+	got, err := verifier.Verify(context.Background(), signRS256(t, key, "key-1", claims))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.Subject != "alice" {
+		t.Errorf("expected subject alice, got %q", got.Subject)
+	}
+
+	// An unknown key ID is rejected without refetching immediately
+	if _, err := verifier.Verify(context.Background(), signRS256(t, key, "key-2", claims)); err == nil || !strings.Contains(err.Error(), "unknown signing key") {
+		t.Errorf("expected unknown signing key error, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("expected 1 JWKS fetch, got %d", fetches)
+	}
+
+	// An HMAC token must not be verified with the RSA key material
+	if _, err := verifier.Verify(context.Background(), signHS256(t, key.N.Bytes(), claims)); err == nil {
+		t.Error("expected HS256 token to be rejected by JWKS verifier")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	verifier := NewHMACVerifier(testSecret)
+	validToken := signHS256(t, testSecret, map[string]interface{}{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()})
+
+	var gotSubject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSubject = audit.SubjectFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		verifier       *Verifier
+		publicReads    bool
+		method         string
+		authorization  string
+		expectedStatus int
+		expectedSub    string
+	}{
+		{
+			name:           "auth disabled",
+			method:         http.MethodPost,
+			expectedStatus: http.StatusOK,
+			expectedSub:    audit.AnonymousSubject,
+		},
+		{
+			name:           "missing token",
+			verifier:       verifier,
+			method:         http.MethodPost,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid token",
+			verifier:       verifier,
+			method:         http.MethodDelete,
+			authorization:  "Bearer " + signHS256(t, []byte("wrong"), map[string]interface{}{"sub": "alice"}),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "valid token",
+			verifier:       verifier,
+			method:         http.MethodPut,
+			authorization:  "Bearer " + validToken,
+			expectedStatus: http.StatusOK,
+			expectedSub:    "alice",
+		},
+		{
+			name:           "reads require auth by default",
+			verifier:       verifier,
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "public reads",
+			verifier:       verifier,
+			publicReads:    true,
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedSub:    audit.AnonymousSubject,
+		},
+		{
+			name:           "public reads still protect writes",
+			verifier:       verifier,
+			publicReads:    true,
+			method:         http.MethodPatch,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSubject = ""
+			req := httptest.NewRequest(tt.method, "/api/proxyrules", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			Middleware(tt.verifier, tt.publicReads)(next).ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected WWW-Authenticate header on 401")
+			}
+			if gotSubject != tt.expectedSub {
+				t.Errorf("expected subject %q, got %q", tt.expectedSub, gotSubject)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// jwksFetchTimeout bounds a single JWKS download
+	jwksFetchTimeout = 10 * time.Second
+	// jwksMinRefreshInterval limits refetches triggered by unknown key IDs
+	jwksMinRefreshInterval = time.Minute
+)
+
+// NewJWKSVerifier verifies RS256/RS384/RS512 tokens against the keys published
+// at url. Keys are fetched on first use and refetched when a token names an
+// unknown key ID, at most once per minute.
+func NewJWKSVerifier(url string, client *http.Client) *Verifier {
+	if client == nil {
+		client = &http.Client{Timeout: jwksFetchTimeout}
+	}
+	keys := &jwksCache{url: url, client: client, now: time.Now}
+	return &Verifier{
+		key: keys.key,
+		now: time.Now,
+	}
+}
+
+// jwksCache holds the RSA keys of a JWKS document by key ID
+type jwksCache struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (c *jwksCache) key(ctx context.Context, h header) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[h.Kid]; ok {
+		return key, nil
+	}
+
+	// Keys may have rotated; refetch, but don't let bad tokens hammer the issuer
+	if c.keys != nil && c.now().Sub(c.fetchedAt) < jwksMinRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", h.Kid)
+	}
+	keys, err := c.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	c.fetchedAt = c.now()
+
+	if key, ok := c.keys[h.Kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", h.Kid)
+}
+
+// fetch downloads and parses the JWKS document, skipping non-RSA keys
+func (c *jwksCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JWKS key %q: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far exp and nbf may be off before a token is rejected
+const clockSkew = 30 * time.Second
+
+// Claims are the verified claims of a bearer token
+type Claims struct {
+	// Subject is the "sub" claim identifying the caller
+	Subject string
+	// Raw holds all claims as decoded from the token
+	Raw map[string]interface{}
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// keyFunc returns the verification key for a token header: a []byte secret
+// for HMAC algorithms or an *rsa.PublicKey for RSA algorithms
+type keyFunc func(ctx context.Context, h header) (interface{}, error)
+
+// Verifier checks the signature and validity period of JWTs
+type Verifier struct {
+	key keyFunc
+	now func() time.Time
+}
+
+// NewHMACVerifier verifies HS256/HS384/HS512 tokens signed with a shared secret
+func NewHMACVerifier(secret []byte) *Verifier {
+	return &Verifier{
+		key: func(ctx context.Context, h header) (interface{}, error) {
+			return secret, nil
+		},
+		now: time.Now,
+	}
+}
+
+// Verify parses token and returns its claims if the signature is valid and
+// the token is within its validity period
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %w", err)
+	}
+
+	key, err := v.key(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(h.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	now := v.now()
+	if exp, ok := raw["exp"].(float64); ok && now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return nil, errors.New("token has expired")
+	}
+	if nbf, ok := raw["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.New("token is not valid yet")
+	}
+
+	subject, _ := raw["sub"].(string)
+	if subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+
+	return &Claims{Subject: subject, Raw: raw}, nil
+}
+
+// verifySignature checks signature over input. The key type must match the
+// algorithm family, so an RSA public key can never be used as an HMAC secret.
+func verifySignature(alg string, key interface{}, input string, signature []byte) error {
+	newHash, cryptoHash, err := hashForAlg(alg)
+	if err != nil {
+		return err
+	}
+
+	switch k := key.(type) {
+	case []byte:
+		if !strings.HasPrefix(alg, "HS") {
+			return fmt.Errorf("unexpected signing algorithm %s", alg)
+		}
+		mac := hmac.New(newHash, k)
+		mac.Write([]byte(input))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("unexpected signing algorithm %s", alg)
+		}
+		digest := newHash()
+		digest.Write([]byte(input))
+		if err := rsa.VerifyPKCS1v15(k, cryptoHash, digest.Sum(nil), signature); err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+}
+
+// hashForAlg returns the hash used by a JWS algorithm
+func hashForAlg(alg string) (func() hash.Hash, crypto.Hash, error) {
+	switch alg {
+	case "HS256", "RS256":
+		return sha256.New, crypto.SHA256, nil
+	case "HS384", "RS384":
+		return sha512.New384, crypto.SHA384, nil
+	case "HS512", "RS512":
+		return sha512.New, crypto.SHA512, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+}
+
+// decodeSegment decodes a base64url JSON token segment into v
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,107 @@
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Extractor determines the address of the client behind a request. Forwarding
+// headers are only believed when the connection comes from a trusted proxy,
+// since any other peer could set them to impersonate someone else.
+type Extractor struct {
+	trusted []netip.Prefix
+}
+
+// NewExtractor creates an Extractor that trusts X-Forwarded-For and X-Real-IP
+// from peers within trusted. With no trusted ranges the peer address is always
+// the client.
+func NewExtractor(trusted []netip.Prefix) *Extractor {
+	return &Extractor{trusted: trusted}
+}
+
+// ClientIP returns the address of the client that sent r. Behind trusted
+// proxies, X-Forwarded-For is read from the right, skipping the proxies
+// themselves, so entries the client prepended are ignored; X-Real-IP is used if
+// there is no X-Forwarded-For. Otherwise it is the host part of r.RemoteAddr.
+func (e *Extractor) ClientIP(r *http.Request) string {
+	peer := remoteHost(r.RemoteAddr)
+	if !e.isTrusted(peer) {
+		return peer
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := ""
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// An unparseable hop cannot be attributed; stop at the last good one
+				break
+			}
+			client = addr.Unmap().String()
+			if !e.isTrusted(client) {
+				return client
+			}
+		}
+		if client != "" {
+			return client
+		}
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+
+	return peer
+}
+
+// isTrusted reports whether host is an address within the trusted ranges
+func (e *Extractor) isTrusted(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range e.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware stores the client address of every request in its context, so
+// that everything keyed on the client agrees on who it is
+func (e *Extractor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithClientIP(r.Context(), e.ClientIP(r))))
+	})
+}
+
+// remoteHost strips the port from a RemoteAddr, keeping it whole if it has none
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap().String()
+	}
+	return host
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a context carrying the client address of a request
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// FromContext returns the client address stored by Middleware, or "" if there is none
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
@@ -0,0 +1,115 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestExtractorClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "no trusted proxies ignores headers",
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "untrusted peer ignores headers",
+			trusted:    trusted,
+			remoteAddr: "198.51.100.9:51234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-IP": "203.0.113.8"},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "trusted peer with forwarded for",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "spoofed entries left of the last untrusted hop are ignored",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.66, 203.0.113.7, 10.0.0.2"},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "only trusted hops",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"},
+			want:       "10.0.0.3",
+		},
+		{
+			name:       "garbage hop",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Forwarded-For": "not-an-ip"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "real ip without forwarded for",
+			trusted:    trusted,
+			remoteAddr: "10.0.0.1:51234",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.8"},
+			want:       "203.0.113.8",
+		},
+		{
+			name:       "ipv6 trusted peer",
+			trusted:    trusted,
+			remoteAddr: "[fd00::1]:51234",
+			headers:    map[string]string{"X-Forwarded-For": "2001:db8::7"},
+			want:       "2001:db8::7",
+		},
+		{
+			name:       "ipv4 mapped peer",
+			trusted:    trusted,
+			remoteAddr: "[::ffff:10.0.0.1]:51234",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			want:       "203.0.113.7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			if got := NewExtractor(tt.trusted).ClientIP(req); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractorMiddleware(t *testing.T) {
+	extractor := NewExtractor([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	var got string
+	handler := extractor.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+	req.RemoteAddr = "10.0.0.1:51234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "203.0.113.7" {
+		t.Errorf("expected the client address in the request context, got %q", got)
+	}
+}
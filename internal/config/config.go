@@ -0,0 +1,472 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPort is the port the API server listens on when PORT is not set
+	DefaultPort = "8080"
+	// DefaultShutdownTimeout is how long in-flight requests may drain on shutdown
+	DefaultShutdownTimeout = 15 * time.Second
+	// DefaultRequestTimeout bounds how long a request may take before it is answered with 503
+	DefaultRequestTimeout = 30 * time.Second
+	// DefaultMaxConcurrentProbes is the server-wide limit on simultaneous destination probes
+	DefaultMaxConcurrentProbes = 64
+	// DefaultAuditLog writes audit entries to standard output
+	DefaultAuditLog = "stdout"
+	// DefaultMaxBodyBytes is the default limit on request body size (1MB)
+	DefaultMaxBodyBytes = 1 << 20
+	// DefaultK8sRetryMaxAttempts is how often a Kubernetes API call is tried before a transient error is returned
+	DefaultK8sRetryMaxAttempts = 3
+	// DefaultMaxDestinations is the default limit on the number of destinations per rule
+	DefaultMaxDestinations = 64
+	// DefaultK8sRetryBaseDelay is the first backoff delay between Kubernetes API call attempts
+	DefaultK8sRetryBaseDelay = 100 * time.Millisecond
+)
+
+// Config holds the runtime configuration of the backend
+type Config struct {
+	// Port is the port the API server listens on
+	Port string
+	// BasePath prefixes every route, e.g. /mortar when an ingress forwards a
+	// subpath unchanged; empty serves the routes at the root
+	BasePath string
+	// TLSCertFile and TLSKeyFile make the server terminate TLS itself; both or neither must be set
+	TLSCertFile string
+	TLSKeyFile  string
+	// AllowDuplicateDomains turns the duplicate-domain check into a warning instead of a conflict
+	AllowDuplicateDomains bool
+	// MaxListResponseBytes caps the serialized size of list responses; zero disables the cap
+	MaxListResponseBytes int64
+	// MaxBodyBytes limits the size of request bodies
+	MaxBodyBytes int64
+	// ShutdownTimeout is how long in-flight requests may drain before connections are force-closed
+	ShutdownTimeout time.Duration
+	// RequestTimeout bounds how long a request may take; zero disables the bound
+	RequestTimeout time.Duration
+	// MaxConcurrentProbes limits simultaneous destination probe connections across all requests
+	MaxConcurrentProbes int
+	// MaxDestinations limits the number of entries in spec.destinations of a rule
+	MaxDestinations int
+	// AllowedDestinationCIDRs restricts IP destinations to these ranges; empty allows any address
+	AllowedDestinationCIDRs []netip.Prefix
+	// RequireTLSPort rejects TLS rules without spec.port instead of only warning about them
+	RequireTLSPort bool
+	// DetectDestinationLoops rejects rules whose destination is the domain of another rule
+	DetectDestinationLoops bool
+	// WarnSharedDestinations warns when a rule's destination is already used by another rule
+	WarnSharedDestinations bool
+	// DomainLockConfigMap names the ConfigMap that reserves domains across all writers; empty disables it
+	DomainLockConfigMap string
+	// MaxRules limits the number of rules per namespace; zero disables the quota
+	MaxRules int
+	// DefaultDestinationPort is written to spec.port of created rules that omit it; zero leaves it unset
+	DefaultDestinationPort int
+	// AuditLog is where mutation audit entries are written: "stdout" or a file path
+	AuditLog string
+	// TrustedProxyCIDRs are the peers whose X-Forwarded-For and X-Real-IP headers name the client
+	TrustedProxyCIDRs []netip.Prefix
+	// JWTSecret enables bearer-token authentication with HMAC-signed tokens
+	JWTSecret string
+	// JWKSURL enables bearer-token authentication with RSA-signed tokens whose keys are published at this URL
+	JWKSURL string
+	// AuthPublicReads lets read-only requests through without a token when authentication is enabled
+	AuthPublicReads bool
+	// K8sRetryMaxAttempts is how often a Kubernetes API call failing with a transient error is tried in total
+	K8sRetryMaxAttempts int
+	// K8sRetryBaseDelay is the wait before the first retry, doubling with each further one
+	K8sRetryBaseDelay time.Duration
+	// CacheReads serves rule reads from a watch-backed cache instead of the API server
+	CacheReads bool
+	// MaxCacheStaleness is how long the read cache may be out of sync before reads fail with 503; zero disables the bound
+	MaxCacheStaleness time.Duration
+	// LogLevel is the least severe level logged; debug also logs parsed specs and duplicate domain checks
+	LogLevel slog.Level
+	// ReadOnly rejects every write with 503, e.g. while the cluster is migrated
+	ReadOnly bool
+	// NotFoundSuggestions is how many similarly named rules a 404 on GET by name lists; zero disables suggestions
+	NotFoundSuggestions int
+}
+
+// AuthEnabled reports whether API requests must carry a bearer token
+func (c Config) AuthEnabled() bool {
+	return c.JWTSecret != "" || c.JWKSURL != ""
+}
+
+// AuthMode names how API requests are authenticated: "none", "jwt-secret" or "jwks"
+func (c Config) AuthMode() string {
+	switch {
+	case c.JWTSecret != "":
+		return "jwt-secret"
+	case c.JWKSURL != "":
+		return "jwks"
+	default:
+		return "none"
+	}
+}
+
+// LogValue lets the effective configuration be logged as one group. The JWT
+// secret is redacted; everything else is safe to appear in logs.
+func (c Config) LogValue() slog.Value {
+	jwtSecret := ""
+	if c.JWTSecret != "" {
+		jwtSecret = "[redacted]"
+	}
+	joinPrefixes := func(prefixes []netip.Prefix) string {
+		entries := make([]string, len(prefixes))
+		for i, prefix := range prefixes {
+			entries[i] = prefix.String()
+		}
+		return strings.Join(entries, ",")
+	}
+
+	return slog.GroupValue(
+		slog.String("listenAddress", ":"+c.Port),
+		slog.String("basePath", c.BasePath),
+		slog.Bool("tls", c.TLSCertFile != ""),
+		slog.String("tlsCertFile", c.TLSCertFile),
+		slog.String("authMode", c.AuthMode()),
+		slog.String("jwtSecret", jwtSecret),
+		slog.String("jwksUrl", c.JWKSURL),
+		slog.Bool("authPublicReads", c.AuthPublicReads),
+		slog.Bool("readOnly", c.ReadOnly),
+		slog.Int64("maxBodyBytes", c.MaxBodyBytes),
+		slog.Int64("maxListResponseBytes", c.MaxListResponseBytes),
+		slog.Duration("requestTimeout", c.RequestTimeout),
+		slog.Duration("shutdownTimeout", c.ShutdownTimeout),
+		slog.Bool("allowDuplicateDomains", c.AllowDuplicateDomains),
+		slog.Bool("detectDestinationLoops", c.DetectDestinationLoops),
+		slog.Bool("warnSharedDestinations", c.WarnSharedDestinations),
+		slog.String("domainLockConfigMap", c.DomainLockConfigMap),
+		slog.Int("maxRules", c.MaxRules),
+		slog.Int("maxDestinations", c.MaxDestinations),
+		slog.String("allowedDestinationCidrs", joinPrefixes(c.AllowedDestinationCIDRs)),
+		slog.String("trustedProxyCidrs", joinPrefixes(c.TrustedProxyCIDRs)),
+		slog.Bool("requireTlsPort", c.RequireTLSPort),
+		slog.Int("defaultDestinationPort", c.DefaultDestinationPort),
+		slog.Int("maxConcurrentProbes", c.MaxConcurrentProbes),
+		slog.Int("notFoundSuggestions", c.NotFoundSuggestions),
+		slog.Bool("cacheReads", c.CacheReads),
+		slog.Duration("maxCacheStaleness", c.MaxCacheStaleness),
+		slog.Int("k8sRetryMaxAttempts", c.K8sRetryMaxAttempts),
+		slog.Duration("k8sRetryBaseDelay", c.K8sRetryBaseDelay),
+		slog.String("auditLog", c.AuditLog),
+		slog.String("logLevel", c.LogLevel.String()),
+	)
+}
+
+// Default returns the configuration used when no environment variables are set
+func Default() Config {
+	return Config{
+		Port:                DefaultPort,
+		MaxBodyBytes:        DefaultMaxBodyBytes,
+		ShutdownTimeout:     DefaultShutdownTimeout,
+		RequestTimeout:      DefaultRequestTimeout,
+		MaxConcurrentProbes: DefaultMaxConcurrentProbes,
+		MaxDestinations:     DefaultMaxDestinations,
+		AuditLog:            DefaultAuditLog,
+		K8sRetryMaxAttempts: DefaultK8sRetryMaxAttempts,
+		K8sRetryBaseDelay:   DefaultK8sRetryBaseDelay,
+	}
+}
+
+// Load reads the configuration from environment variables, falling back to defaults
+func Load() (Config, error) {
+	cfg := Default()
+
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Port = port
+	}
+
+	basePath, err := parseBasePath(os.Getenv("BASE_PATH"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.BasePath = basePath
+
+	cfg.TLSCertFile = os.Getenv("TLS_CERT_FILE")
+	cfg.TLSKeyFile = os.Getenv("TLS_KEY_FILE")
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		return cfg, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	allowDuplicates, err := getEnvBool("ALLOW_DUPLICATE_DOMAINS", cfg.AllowDuplicateDomains)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.AllowDuplicateDomains = allowDuplicates
+
+	maxListBytes, err := getEnvInt64("MAX_LIST_RESPONSE_BYTES", cfg.MaxListResponseBytes)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MaxListResponseBytes = maxListBytes
+
+	maxBodyBytes, err := getEnvInt64("MAX_BODY_BYTES", cfg.MaxBodyBytes)
+	if err != nil {
+		return cfg, err
+	}
+	if maxBodyBytes < 1 {
+		return cfg, fmt.Errorf("invalid value for MAX_BODY_BYTES: must be positive")
+	}
+	cfg.MaxBodyBytes = maxBodyBytes
+
+	shutdownTimeout, err := getEnvDuration("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ShutdownTimeout = shutdownTimeout
+
+	if value := os.Getenv("REQUEST_TIMEOUT"); value == "0" {
+		cfg.RequestTimeout = 0
+	} else {
+		requestTimeout, err := getEnvDuration("REQUEST_TIMEOUT", cfg.RequestTimeout)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.RequestTimeout = requestTimeout
+	}
+
+	maxProbes, err := getEnvInt64("MAX_CONCURRENT_PROBES", int64(cfg.MaxConcurrentProbes))
+	if err != nil {
+		return cfg, err
+	}
+	if maxProbes < 1 {
+		return cfg, fmt.Errorf("invalid value for MAX_CONCURRENT_PROBES: must be at least 1")
+	}
+	cfg.MaxConcurrentProbes = int(maxProbes)
+
+	maxDestinations, err := getEnvInt64("MAX_DESTINATIONS", int64(cfg.MaxDestinations))
+	if err != nil {
+		return cfg, err
+	}
+	if maxDestinations < 1 {
+		return cfg, fmt.Errorf("invalid value for MAX_DESTINATIONS: must be at least 1")
+	}
+	cfg.MaxDestinations = int(maxDestinations)
+
+	allowedCIDRs, err := getEnvPrefixes("ALLOWED_DEST_CIDRS")
+	if err != nil {
+		return cfg, err
+	}
+	cfg.AllowedDestinationCIDRs = allowedCIDRs
+
+	requireTLSPort, err := getEnvBool("REQUIRE_TLS_PORT", cfg.RequireTLSPort)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.RequireTLSPort = requireTLSPort
+
+	detectLoops, err := getEnvBool("DETECT_DESTINATION_LOOPS", cfg.DetectDestinationLoops)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.DetectDestinationLoops = detectLoops
+
+	warnShared, err := getEnvBool("WARN_SHARED_DESTINATIONS", cfg.WarnSharedDestinations)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.WarnSharedDestinations = warnShared
+
+	cfg.DomainLockConfigMap = os.Getenv("DOMAIN_LOCK_CONFIGMAP")
+
+	maxRules, err := getEnvInt64("MAX_RULES", int64(cfg.MaxRules))
+	if err != nil {
+		return cfg, err
+	}
+	if maxRules < 0 {
+		return cfg, fmt.Errorf("invalid value for MAX_RULES: must not be negative")
+	}
+	cfg.MaxRules = int(maxRules)
+
+	defaultPort, err := getEnvInt64("DEFAULT_DEST_PORT", int64(cfg.DefaultDestinationPort))
+	if err != nil {
+		return cfg, err
+	}
+	if defaultPort < 0 || defaultPort > 65535 {
+		return cfg, fmt.Errorf("invalid value for DEFAULT_DEST_PORT: must be between 1 and 65535, or 0 to disable it")
+	}
+	cfg.DefaultDestinationPort = int(defaultPort)
+
+	if auditLog := os.Getenv("AUDIT_LOG"); auditLog != "" {
+		cfg.AuditLog = auditLog
+	}
+
+	trustedProxies, err := getEnvPrefixes("TRUSTED_PROXY_CIDRS")
+	if err != nil {
+		return cfg, err
+	}
+	cfg.TrustedProxyCIDRs = trustedProxies
+
+	cfg.JWTSecret = os.Getenv("JWT_SECRET")
+	cfg.JWKSURL = os.Getenv("JWKS_URL")
+	if cfg.JWTSecret != "" && cfg.JWKSURL != "" {
+		return cfg, fmt.Errorf("JWT_SECRET and JWKS_URL are mutually exclusive")
+	}
+
+	publicReads, err := getEnvBool("AUTH_PUBLIC_READS", cfg.AuthPublicReads)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.AuthPublicReads = publicReads
+
+	suggestions, err := getEnvInt64("NOT_FOUND_SUGGESTIONS", int64(cfg.NotFoundSuggestions))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.NotFoundSuggestions = int(suggestions)
+
+	retryAttempts, err := getEnvInt64("K8S_RETRY_MAX_ATTEMPTS", int64(cfg.K8sRetryMaxAttempts))
+	if err != nil {
+		return cfg, err
+	}
+	if retryAttempts < 1 {
+		return cfg, fmt.Errorf("invalid value for K8S_RETRY_MAX_ATTEMPTS: must be at least 1")
+	}
+	cfg.K8sRetryMaxAttempts = int(retryAttempts)
+
+	retryDelay, err := getEnvDuration("K8S_RETRY_BASE_DELAY", cfg.K8sRetryBaseDelay)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.K8sRetryBaseDelay = retryDelay
+
+	cacheReads, err := getEnvBool("CACHE_READS", cfg.CacheReads)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.CacheReads = cacheReads
+
+	if value := os.Getenv("MAX_CACHE_STALENESS"); value != "" && value != "0" {
+		staleness, err := getEnvDuration("MAX_CACHE_STALENESS", cfg.MaxCacheStaleness)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.MaxCacheStaleness = staleness
+	}
+
+	logLevel, err := getEnvLogLevel("LOG_LEVEL", cfg.LogLevel)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.LogLevel = logLevel
+
+	readOnly, err := getEnvBool("READ_ONLY", cfg.ReadOnly)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.ReadOnly = readOnly
+
+	return cfg, nil
+}
+
+// parseBasePath returns BASE_PATH with a leading slash and without a trailing
+// one, or "" for the root
+func parseBasePath(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "/" {
+		return "", nil
+	}
+	if !strings.HasPrefix(value, "/") {
+		return "", fmt.Errorf("invalid value for BASE_PATH: must start with /")
+	}
+	if strings.ContainsAny(value, "?#") {
+		return "", fmt.Errorf("invalid value for BASE_PATH: must be a path without query or fragment")
+	}
+	value = strings.TrimSuffix(value, "/")
+	if strings.Contains(value, "//") {
+		return "", fmt.Errorf("invalid value for BASE_PATH: must not contain empty segments")
+	}
+	return value, nil
+}
+
+// getEnvBool parses a boolean environment variable, returning def if it is unset
+func getEnvBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("invalid value for %s: %q is not a boolean", key, value)
+	}
+	return parsed, nil
+}
+
+// getEnvLogLevel parses a log level (debug, info, warn or error) environment
+// variable, returning def if it is unset
+func getEnvLogLevel(key string, def slog.Level) (slog.Level, error) {
+	switch value := os.Getenv(key); strings.ToLower(value) {
+	case "":
+		return def, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return def, fmt.Errorf("invalid value for %s: %q is not debug, info, warn or error", key, value)
+	}
+}
+
+// getEnvInt64 parses a non-negative integer environment variable, returning def if it is unset
+func getEnvInt64(key string, def int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		return def, fmt.Errorf("invalid value for %s: %q is not a non-negative integer", key, value)
+	}
+	return parsed, nil
+}
+
+// getEnvPrefixes parses a comma-separated list of CIDRs (e.g. "10.0.0.0/8,fd00::/8"), returning nil if it is unset
+func getEnvPrefixes(key string) ([]netip.Prefix, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q is not a CIDR", key, entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// getEnvDuration parses a positive duration environment variable (e.g. "30s"), returning def if it is unset
+func getEnvDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return def, fmt.Errorf("invalid value for %s: %q is not a positive duration", key, value)
+	}
+	return parsed, nil
+}
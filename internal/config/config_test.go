@@ -0,0 +1,395 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		want      Config
+		wantError bool
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{},
+			want: Default(),
+		},
+		{
+			name: "custom port",
+			env:  map[string]string{"PORT": "9090"},
+			want: withDefaults(func(c *Config) { c.Port = "9090" }),
+		},
+		{
+			name: "base path",
+			env:  map[string]string{"BASE_PATH": "/mortar/"},
+			want: withDefaults(func(c *Config) { c.BasePath = "/mortar" }),
+		},
+		{
+			name: "nested base path",
+			env:  map[string]string{"BASE_PATH": "/tools/mortar"},
+			want: withDefaults(func(c *Config) { c.BasePath = "/tools/mortar" }),
+		},
+		{
+			name: "root base path",
+			env:  map[string]string{"BASE_PATH": "/"},
+			want: Default(),
+		},
+		{
+			name:      "relative base path",
+			env:       map[string]string{"BASE_PATH": "mortar"},
+			wantError: true,
+		},
+		{
+			name:      "base path with empty segment",
+			env:       map[string]string{"BASE_PATH": "/tools//mortar"},
+			wantError: true,
+		},
+		{
+			name:      "base path with query",
+			env:       map[string]string{"BASE_PATH": "/mortar?x=1"},
+			wantError: true,
+		},
+		{
+			name: "allow duplicate domains",
+			env:  map[string]string{"ALLOW_DUPLICATE_DOMAINS": "true"},
+			want: withDefaults(func(c *Config) { c.AllowDuplicateDomains = true }),
+		},
+		{
+			name:      "invalid boolean",
+			env:       map[string]string{"ALLOW_DUPLICATE_DOMAINS": "maybe"},
+			wantError: true,
+		},
+		{
+			name: "max list response bytes",
+			env:  map[string]string{"MAX_LIST_RESPONSE_BYTES": "1048576"},
+			want: withDefaults(func(c *Config) { c.MaxListResponseBytes = 1048576 }),
+		},
+		{
+			name:      "negative max list response bytes",
+			env:       map[string]string{"MAX_LIST_RESPONSE_BYTES": "-1"},
+			wantError: true,
+		},
+		{
+			name: "tls",
+			env:  map[string]string{"TLS_CERT_FILE": "/tls/tls.crt", "TLS_KEY_FILE": "/tls/tls.key"},
+			want: withDefaults(func(c *Config) {
+				c.TLSCertFile = "/tls/tls.crt"
+				c.TLSKeyFile = "/tls/tls.key"
+			}),
+		},
+		{
+			name:      "tls cert without key",
+			env:       map[string]string{"TLS_CERT_FILE": "/tls/tls.crt"},
+			wantError: true,
+		},
+		{
+			name:      "tls key without cert",
+			env:       map[string]string{"TLS_KEY_FILE": "/tls/tls.key"},
+			wantError: true,
+		},
+		{
+			name: "shutdown timeout",
+			env:  map[string]string{"SHUTDOWN_TIMEOUT": "45s"},
+			want: withDefaults(func(c *Config) { c.ShutdownTimeout = 45 * time.Second }),
+		},
+		{
+			name:      "invalid shutdown timeout",
+			env:       map[string]string{"SHUTDOWN_TIMEOUT": "15"},
+			wantError: true,
+		},
+		{
+			name: "request timeout",
+			env:  map[string]string{"REQUEST_TIMEOUT": "1m"},
+			want: withDefaults(func(c *Config) { c.RequestTimeout = time.Minute }),
+		},
+		{
+			name: "request timeout disabled",
+			env:  map[string]string{"REQUEST_TIMEOUT": "0"},
+			want: withDefaults(func(c *Config) { c.RequestTimeout = 0 }),
+		},
+		{
+			name:      "negative request timeout",
+			env:       map[string]string{"REQUEST_TIMEOUT": "-5s"},
+			wantError: true,
+		},
+		{
+			name: "max concurrent probes",
+			env:  map[string]string{"MAX_CONCURRENT_PROBES": "8"},
+			want: withDefaults(func(c *Config) { c.MaxConcurrentProbes = 8 }),
+		},
+		{
+			name:      "zero max concurrent probes",
+			env:       map[string]string{"MAX_CONCURRENT_PROBES": "0"},
+			wantError: true,
+		},
+		{
+			name: "max body bytes",
+			env:  map[string]string{"MAX_BODY_BYTES": "4194304"},
+			want: withDefaults(func(c *Config) { c.MaxBodyBytes = 4 << 20 }),
+		},
+		{
+			name:      "zero max body bytes",
+			env:       map[string]string{"MAX_BODY_BYTES": "0"},
+			wantError: true,
+		},
+		{
+			name:      "negative max body bytes",
+			env:       map[string]string{"MAX_BODY_BYTES": "-1"},
+			wantError: true,
+		},
+		{
+			name: "max destinations",
+			env:  map[string]string{"MAX_DESTINATIONS": "8"},
+			want: withDefaults(func(c *Config) { c.MaxDestinations = 8 }),
+		},
+		{
+			name:      "zero max destinations",
+			env:       map[string]string{"MAX_DESTINATIONS": "0"},
+			wantError: true,
+		},
+		{
+			name: "allowed destination cidrs",
+			env:  map[string]string{"ALLOWED_DEST_CIDRS": "10.0.0.0/8, 192.168.1.7/16,fd00::/8"},
+			want: withDefaults(func(c *Config) {
+				c.AllowedDestinationCIDRs = []netip.Prefix{
+					netip.MustParsePrefix("10.0.0.0/8"),
+					netip.MustParsePrefix("192.168.0.0/16"),
+					netip.MustParsePrefix("fd00::/8"),
+				}
+			}),
+		},
+		{
+			name:      "invalid allowed destination cidr",
+			env:       map[string]string{"ALLOWED_DEST_CIDRS": "10.0.0.0/8,10.0.0.1"},
+			wantError: true,
+		},
+		{
+			name: "trusted proxy cidrs",
+			env:  map[string]string{"TRUSTED_PROXY_CIDRS": "10.0.0.0/8,fd00::/8"},
+			want: withDefaults(func(c *Config) {
+				c.TrustedProxyCIDRs = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")}
+			}),
+		},
+		{
+			name:      "invalid trusted proxy cidr",
+			env:       map[string]string{"TRUSTED_PROXY_CIDRS": "loadbalancer"},
+			wantError: true,
+		},
+		{
+			name: "require tls port",
+			env:  map[string]string{"REQUIRE_TLS_PORT": "true"},
+			want: withDefaults(func(c *Config) { c.RequireTLSPort = true }),
+		},
+		{
+			name:      "invalid require tls port",
+			env:       map[string]string{"REQUIRE_TLS_PORT": "strict"},
+			wantError: true,
+		},
+		{
+			name: "detect destination loops",
+			env:  map[string]string{"DETECT_DESTINATION_LOOPS": "true"},
+			want: withDefaults(func(c *Config) { c.DetectDestinationLoops = true }),
+		},
+		{
+			name:      "invalid detect destination loops",
+			env:       map[string]string{"DETECT_DESTINATION_LOOPS": "sometimes"},
+			wantError: true,
+		},
+		{
+			name: "warn shared destinations",
+			env:  map[string]string{"WARN_SHARED_DESTINATIONS": "true"},
+			want: withDefaults(func(c *Config) { c.WarnSharedDestinations = true }),
+		},
+		{
+			name:      "invalid warn shared destinations",
+			env:       map[string]string{"WARN_SHARED_DESTINATIONS": "sometimes"},
+			wantError: true,
+		},
+		{
+			name: "domain lock configmap",
+			env:  map[string]string{"DOMAIN_LOCK_CONFIGMAP": "mortar-domain-index"},
+			want: withDefaults(func(c *Config) { c.DomainLockConfigMap = "mortar-domain-index" }),
+		},
+		{
+			name: "max rules",
+			env:  map[string]string{"MAX_RULES": "500"},
+			want: withDefaults(func(c *Config) { c.MaxRules = 500 }),
+		},
+		{
+			name:      "negative max rules",
+			env:       map[string]string{"MAX_RULES": "-1"},
+			wantError: true,
+		},
+		{
+			name: "default destination port",
+			env:  map[string]string{"DEFAULT_DEST_PORT": "8080"},
+			want: withDefaults(func(c *Config) { c.DefaultDestinationPort = 8080 }),
+		},
+		{
+			name:      "default destination port out of range",
+			env:       map[string]string{"DEFAULT_DEST_PORT": "65536"},
+			wantError: true,
+		},
+		{
+			name:      "negative default destination port",
+			env:       map[string]string{"DEFAULT_DEST_PORT": "-80"},
+			wantError: true,
+		},
+		{
+			name: "audit log file",
+			env:  map[string]string{"AUDIT_LOG": "/var/log/mortar/audit.log"},
+			want: withDefaults(func(c *Config) { c.AuditLog = "/var/log/mortar/audit.log" }),
+		},
+		{
+			name: "jwt secret with public reads",
+			env:  map[string]string{"JWT_SECRET": "s3cret", "AUTH_PUBLIC_READS": "true"},
+			want: withDefaults(func(c *Config) {
+				c.JWTSecret = "s3cret"
+				c.AuthPublicReads = true
+			}),
+		},
+		{
+			name: "jwks url",
+			env:  map[string]string{"JWKS_URL": "https://idp.example.com/.well-known/jwks.json"},
+			want: withDefaults(func(c *Config) { c.JWKSURL = "https://idp.example.com/.well-known/jwks.json" }),
+		},
+		{
+			name: "not found suggestions",
+			env:  map[string]string{"NOT_FOUND_SUGGESTIONS": "5"},
+			want: withDefaults(func(c *Config) { c.NotFoundSuggestions = 5 }),
+		},
+		{
+			name: "kubernetes retries",
+			env:  map[string]string{"K8S_RETRY_MAX_ATTEMPTS": "5", "K8S_RETRY_BASE_DELAY": "250ms"},
+			want: withDefaults(func(c *Config) {
+				c.K8sRetryMaxAttempts = 5
+				c.K8sRetryBaseDelay = 250 * time.Millisecond
+			}),
+		},
+		{
+			name:      "zero kubernetes retry attempts",
+			env:       map[string]string{"K8S_RETRY_MAX_ATTEMPTS": "0"},
+			wantError: true,
+		},
+		{
+			name: "cache reads",
+			env:  map[string]string{"CACHE_READS": "true"},
+			want: withDefaults(func(c *Config) { c.CacheReads = true }),
+		},
+		{
+			name:      "invalid cache reads",
+			env:       map[string]string{"CACHE_READS": "sometimes"},
+			wantError: true,
+		},
+		{
+			name: "max cache staleness",
+			env:  map[string]string{"MAX_CACHE_STALENESS": "2m"},
+			want: withDefaults(func(c *Config) { c.MaxCacheStaleness = 2 * time.Minute }),
+		},
+		{
+			name: "max cache staleness disabled",
+			env:  map[string]string{"MAX_CACHE_STALENESS": "0"},
+			want: Default(),
+		},
+		{
+			name: "debug log level",
+			env:  map[string]string{"LOG_LEVEL": "DEBUG"},
+			want: withDefaults(func(c *Config) { c.LogLevel = slog.LevelDebug }),
+		},
+		{
+			name: "warn log level",
+			env:  map[string]string{"LOG_LEVEL": "warn"},
+			want: withDefaults(func(c *Config) { c.LogLevel = slog.LevelWarn }),
+		},
+		{
+			name:      "invalid log level",
+			env:       map[string]string{"LOG_LEVEL": "verbose"},
+			wantError: true,
+		},
+		{
+			name: "read only",
+			env:  map[string]string{"READ_ONLY": "true"},
+			want: withDefaults(func(c *Config) { c.ReadOnly = true }),
+		},
+		{
+			name:      "invalid read only",
+			env:       map[string]string{"READ_ONLY": "yes please"},
+			wantError: true,
+		},
+		{
+			name:      "jwt secret and jwks url",
+			env:       map[string]string{"JWT_SECRET": "s3cret", "JWKS_URL": "https://idp.example.com/.well-known/jwks.json"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"PORT", "BASE_PATH", "TLS_CERT_FILE", "TLS_KEY_FILE", "ALLOW_DUPLICATE_DOMAINS", "MAX_LIST_RESPONSE_BYTES", "MAX_BODY_BYTES", "SHUTDOWN_TIMEOUT", "REQUEST_TIMEOUT", "MAX_CONCURRENT_PROBES", "MAX_DESTINATIONS", "ALLOWED_DEST_CIDRS", "REQUIRE_TLS_PORT", "DETECT_DESTINATION_LOOPS", "WARN_SHARED_DESTINATIONS", "DOMAIN_LOCK_CONFIGMAP", "MAX_RULES", "DEFAULT_DEST_PORT", "AUDIT_LOG", "TRUSTED_PROXY_CIDRS", "JWT_SECRET", "JWKS_URL", "AUTH_PUBLIC_READS", "NOT_FOUND_SUGGESTIONS", "K8S_RETRY_MAX_ATTEMPTS", "K8S_RETRY_BASE_DELAY", "CACHE_READS", "MAX_CACHE_STALENESS", "READ_ONLY", "LOG_LEVEL"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := Load()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("Load() error = %v, wantError %v", err, tt.wantError)
+			}
+			if !tt.wantError && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// withDefaults returns the default configuration with modify applied
+func withDefaults(modify func(*Config)) Config {
+	cfg := Default()
+	modify(&cfg)
+	return cfg
+}
+
+func TestConfigLogValue(t *testing.T) {
+	cfg := Default()
+	cfg.JWTSecret = "s3cret-signing-key"
+	cfg.ReadOnly = true
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("Effective configuration", "config", cfg)
+	logged := buf.String()
+
+	if strings.Contains(logged, cfg.JWTSecret) {
+		t.Errorf("expected the JWT secret to be redacted, got %q", logged)
+	}
+	for _, want := range []string{"config.listenAddress=:8080", "config.authMode=jwt-secret", "config.jwtSecret=[redacted]", "config.readOnly=true", "config.maxBodyBytes=1048576"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("expected %q in %q", want, logged)
+		}
+	}
+}
+
+func TestConfigAuthMode(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{cfg: Config{}, want: "none"},
+		{cfg: Config{JWTSecret: "secret"}, want: "jwt-secret"},
+		{cfg: Config{JWKSURL: "https://auth.example.com/jwks.json"}, want: "jwks"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.AuthMode(); got != tt.want {
+			t.Errorf("AuthMode() = %q, want %q", got, tt.want)
+		}
+	}
+}
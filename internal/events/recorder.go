@@ -0,0 +1,136 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// EventTypeNormal is used for events describing successful operations
+	EventTypeNormal = "Normal"
+	// EventTypeWarning is used for events describing rejected or failed operations
+	EventTypeWarning = "Warning"
+
+	// Reasons used for ProxyRule events
+	ReasonCreated          = "Created"
+	ReasonUpdated          = "Updated"
+	ReasonDeleted          = "Deleted"
+	ReasonValidationFailed = "ValidationFailed"
+
+	// sourceComponent is reported as the source of all events written by the backend
+	sourceComponent = "mortar-backend"
+
+	// eventTimeout bounds the write of one event
+	eventTimeout = 5 * time.Second
+	// maxPendingEvents bounds the events being written at once; further events are dropped
+	maxPendingEvents = 64
+)
+
+// Recorder records Kubernetes events about an object
+// Implementations must not fail the caller; errors are only logged
+type Recorder interface {
+	Event(obj *unstructured.Unstructured, eventType, reason, message string)
+}
+
+// DynamicRecorder writes core/v1 Events to the cluster using a dynamic client
+type DynamicRecorder struct {
+	dynamicClient dynamic.Interface
+	pending       chan struct{}
+	wg            sync.WaitGroup
+}
+
+// NewDynamicRecorder creates a Recorder that writes events with the given dynamic client
+func NewDynamicRecorder(client dynamic.Interface) *DynamicRecorder {
+	return &DynamicRecorder{
+		dynamicClient: client,
+		pending:       make(chan struct{}, maxPendingEvents),
+	}
+}
+
+func (r *DynamicRecorder) getEventGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    "",
+		Version:  "v1",
+		Resource: "events",
+	}
+}
+
+// Event creates an Event referencing obj in the background, so a slow events
+// API does not delay the request that caused it. While maxPendingEvents are
+// still being written, further events are dropped. Failures are logged and
+// otherwise ignored.
+func (r *DynamicRecorder) Event(obj *unstructured.Unstructured, eventType, reason, message string) {
+	event := newEvent(obj, eventType, reason, message, time.Now())
+	name := obj.GetName()
+
+	select {
+	case r.pending <- struct{}{}:
+	default:
+		slog.Warn("Dropping event, too many events are being recorded", "type", eventType, "reason", reason, "name", name)
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.pending }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), eventTimeout)
+		defer cancel()
+
+		_, err := r.dynamicClient.Resource(r.getEventGVR()).Namespace(event.GetNamespace()).Create(ctx, event, metav1.CreateOptions{})
+		if err != nil {
+			slog.Warn("Error recording event", "type", eventType, "reason", reason, "name", name, "error", err)
+		}
+	}()
+}
+
+// Wait blocks until the events recorded so far have been written or have failed
+func (r *DynamicRecorder) Wait() {
+	r.wg.Wait()
+}
+
+// newEvent builds an unstructured core/v1 Event that references obj
+func newEvent(obj *unstructured.Unstructured, eventType, reason, message string, now time.Time) *unstructured.Unstructured {
+	name := obj.GetName()
+	if name == "" {
+		name = "proxyrule"
+	}
+	timestamp := now.UTC().Format(time.RFC3339)
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Event",
+			"metadata": map[string]interface{}{
+				// Same naming scheme as client-go's event recorder
+				"name":      fmt.Sprintf("%s.%x", name, now.UnixNano()),
+				"namespace": obj.GetNamespace(),
+			},
+			"involvedObject": map[string]interface{}{
+				"apiVersion": obj.GetAPIVersion(),
+				"kind":       obj.GetKind(),
+				"name":       obj.GetName(),
+				"namespace":  obj.GetNamespace(),
+				"uid":        string(obj.GetUID()),
+			},
+			"type":           eventType,
+			"reason":         reason,
+			"message":        message,
+			"count":          int64(1),
+			"firstTimestamp": timestamp,
+			"lastTimestamp":  timestamp,
+			"source": map[string]interface{}{
+				"component": sourceComponent,
+			},
+		},
+	}
+}
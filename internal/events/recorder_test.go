@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// blockingClient holds every Create until release is closed, like an events
+// API that stopped answering
+type blockingClient struct {
+	dynamic.Interface
+	release chan struct{}
+}
+
+func (c *blockingClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &blockingResource{NamespaceableResourceInterface: c.Interface.Resource(gvr), release: c.release}
+}
+
+type blockingResource struct {
+	dynamic.NamespaceableResourceInterface
+	release chan struct{}
+}
+
+func (r *blockingResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return &blockingNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), release: r.release}
+}
+
+type blockingNamespacedResource struct {
+	dynamic.ResourceInterface
+	release chan struct{}
+}
+
+func (r *blockingNamespacedResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	select {
+	case <-r.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return r.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func TestDynamicRecorderDoesNotBlock(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	client := &blockingClient{Interface: fakeClient, release: make(chan struct{})}
+	recorder := NewDynamicRecorder(client)
+
+	start := time.Now()
+	for i := 0; i < maxPendingEvents+5; i++ {
+		rule := testutil.NewProxyRule(fmt.Sprintf("rule-%d", i), "example.com", "10.0.0.50", 3000)
+		rule.SetNamespace("proxy-rules")
+		recorder.Event(rule, EventTypeNormal, ReasonCreated, "created")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Event to return while the events API is blocked, took %v", elapsed)
+	}
+
+	close(client.release)
+	recorder.Wait()
+
+	list, err := fakeClient.Resource(recorder.getEventGVR()).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(list.Items) != maxPendingEvents {
+		t.Errorf("expected %d events with the rest dropped, got %d", maxPendingEvents, len(list.Items))
+	}
+}
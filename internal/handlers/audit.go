@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/clientip"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// WithAuditLogger records every successful mutation to logger
+func WithAuditLogger(logger audit.Logger) Option {
+	return func(h *ProxyRulesHandler) {
+		h.auditLogger = logger
+	}
+}
+
+// auditMutation writes an audit entry for a successful mutation. before is the
+// rule as it was prior to the change and after the stored result; either may be
+// nil (creates have no before, deletes no after).
+func (h *ProxyRulesHandler) auditMutation(r *http.Request, operation, name string, before, after *unstructured.Unstructured) {
+	entry := audit.Entry{
+		Operation: operation,
+		Name:      name,
+		Subject:   audit.SubjectFromContext(r.Context()),
+		ClientIP:  clientip.FromContext(r.Context()),
+		Before:    auditSpec(before),
+		After:     auditSpec(after),
+	}
+
+	// Report the domain the rule has now, or had before it was deleted
+	for _, obj := range []*unstructured.Unstructured{after, before} {
+		if obj == nil {
+			continue
+		}
+		if domains := getRuleDomains(obj); len(domains) > 0 {
+			entry.Domain = domains[0]
+			break
+		}
+	}
+
+	h.auditLogger.Log(entry)
+}
+
+// auditSpec returns the spec of obj, or nil if there is none
+func auditSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	if obj == nil {
+		return nil
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	return spec
+}
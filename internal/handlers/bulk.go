@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// confirmDeleteHeader must be set to "true" for destructive bulk operations
+	confirmDeleteHeader = "X-Confirm-Delete"
+)
+
+// BulkDeleteResult is the outcome of deleting one rule in a bulk delete
+type BulkDeleteResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // deleted, notFound, failed, or wouldDelete on dry-run
+	Error  string `json:"error,omitempty"`
+	// DeletedIngresses lists the ingresses removed along with the rule on cascade
+	DeletedIngresses []string `json:"deletedIngresses,omitempty"`
+}
+
+// BulkDeleteResponse is the body returned by a bulk delete
+type BulkDeleteResponse struct {
+	DryRun  bool               `json:"dryRun"`
+	Results []BulkDeleteResult `json:"results"`
+}
+
+// parseLabelSelector returns the labelSelector query parameter after checking its syntax
+func parseLabelSelector(r *http.Request) (string, error) {
+	selector := r.URL.Query().Get("labelSelector")
+	if _, err := labels.Parse(selector); err != nil {
+		return "", err
+	}
+	return selector, nil
+}
+
+// requireDeleteConfirmation rejects the request with 428 unless the confirmation header is set
+func requireDeleteConfirmation(w http.ResponseWriter, r *http.Request) bool {
+	if r.Header.Get(confirmDeleteHeader) != "true" {
+		http.Error(w, fmt.Sprintf("Bulk delete requires the %s: true header", confirmDeleteHeader), http.StatusPreconditionRequired)
+		return false
+	}
+	return true
+}
+
+// DeleteProxyRules deletes every rule matching the required labelSelector query
+// parameter. With ?dryRun=All each delete is only sent as a dry run and the
+// matching rules are listed; otherwise the confirmation header is required.
+// Responds 207 if some deletions failed.
+func (h *ProxyRulesHandler) DeleteProxyRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	selector, err := parseLabelSelector(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid labelSelector: %v", err), http.StatusBadRequest)
+		return
+	}
+	if selector == "" {
+		http.Error(w, "labelSelector is required for bulk delete", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dryRun value: %v", err), http.StatusBadRequest)
+		return
+	}
+	if dryRun == nil && !requireDeleteConfirmation(w, r) {
+		return
+	}
+
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r))
+	list, err := resource.List(r.Context(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := BulkDeleteResponse{
+		DryRun:  dryRun != nil,
+		Results: []BulkDeleteResult{},
+	}
+	status := http.StatusOK
+
+	for i := range list.Items {
+		result := h.bulkDeleteRule(r, &list.Items[i], false, dryRun, "bulk delete by "+selector)
+		if result.Status == "failed" {
+			status = http.StatusMultiStatus
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	writeJSON(w, status, response)
+}
+
+// BatchDeleteProxyRules serves POST /api/proxyrules:delete, deleting the rules
+// listed in the body's names, or those matching its labelSelector. Every rule
+// is attempted: missing and failed ones are reported in the results, with 207,
+// instead of aborting the batch. With ?cascade=true the ingresses generated
+// for each deleted rule are removed too. As for DELETE /api/proxyrules,
+// ?dryRun=All only reports what would be deleted and otherwise the
+// confirmation header is required.
+func (h *ProxyRulesHandler) BatchDeleteProxyRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	cascade, err := boolQueryParameter(r, "cascade")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dryRun value: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	request, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
+		return
+	}
+	names, selector, err := parseBatchDeleteRequest(request)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if dryRun == nil && !requireDeleteConfirmation(w, r) {
+		return
+	}
+
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r))
+	response := BulkDeleteResponse{
+		DryRun:  dryRun != nil,
+		Results: []BulkDeleteResult{},
+	}
+	status := http.StatusOK
+
+	var targets []*unstructured.Unstructured
+	reason := "bulk delete by name"
+	if selector != "" {
+		list, err := resource.List(r.Context(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for i := range list.Items {
+			targets = append(targets, &list.Items[i])
+		}
+		reason = "bulk delete by " + selector
+	} else {
+		for _, name := range names {
+			existing, err := resource.Get(r.Context(), name, metav1.GetOptions{})
+			if err != nil {
+				result := BulkDeleteResult{Name: name, Status: "failed", Error: err.Error()}
+				if apierrors.IsNotFound(err) {
+					result = BulkDeleteResult{Name: name, Status: "notFound"}
+				}
+				response.Results = append(response.Results, result)
+				status = http.StatusMultiStatus
+				continue
+			}
+			targets = append(targets, existing)
+		}
+	}
+
+	for _, target := range targets {
+		result := h.bulkDeleteRule(r, target, cascade, dryRun, reason)
+		if result.Status == "notFound" || result.Status == "failed" || result.Error != "" {
+			status = http.StatusMultiStatus
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	writeJSON(w, status, response)
+}
+
+// parseBatchDeleteRequest returns the names, without duplicates, or the label
+// selector of a batch delete body; exactly one of them must be given
+func parseBatchDeleteRequest(request map[string]interface{}) ([]string, string, error) {
+	rawNames, hasNames := request["names"]
+	rawSelector, hasSelector := request["labelSelector"]
+	if hasNames == hasSelector {
+		return nil, "", fmt.Errorf("exactly one of names and labelSelector is required")
+	}
+
+	if hasSelector {
+		selector, _ := rawSelector.(string)
+		if strings.TrimSpace(selector) == "" {
+			return nil, "", fmt.Errorf("labelSelector must be a non-empty string")
+		}
+		if _, err := labels.Parse(selector); err != nil {
+			return nil, "", fmt.Errorf("Invalid labelSelector: %v", err)
+		}
+		return nil, selector, nil
+	}
+
+	list, ok := rawNames.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, "", fmt.Errorf("names must be a non-empty list of rule names")
+	}
+	names := make([]string, 0, len(list))
+	seen := make(map[string]bool, len(list))
+	for i, raw := range list {
+		name, _ := raw.(string)
+		if name == "" {
+			return nil, "", fmt.Errorf("names[%d] must be a non-empty string", i)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, "", nil
+}
+
+// bulkDeleteRule deletes one rule of a bulk delete and does the bookkeeping of
+// a single delete; reason is added to its event. With cascade, ingress
+// failures are reported in Error while the rule still counts as deleted. A
+// dry run is passed to the API server and reported as wouldDelete.
+func (h *ProxyRulesHandler) bulkDeleteRule(r *http.Request, rule *unstructured.Unstructured, cascade bool, dryRun []string, reason string) BulkDeleteResult {
+	namespace := requestNamespace(r)
+	name := rule.GetName()
+	if err := h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).Delete(r.Context(), name, metav1.DeleteOptions{DryRun: dryRun}); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Deleted by someone else since it was listed
+			return BulkDeleteResult{Name: name, Status: "notFound"}
+		}
+		return BulkDeleteResult{Name: name, Status: "failed", Error: err.Error()}
+	}
+
+	if dryRun != nil {
+		result := BulkDeleteResult{Name: name, Status: "wouldDelete"}
+		if cascade {
+			result.DeletedIngresses = h.deleteRuleIngresses(r.Context(), namespace, name, dryRun).DeletedIngresses
+		}
+		return result
+	}
+
+	h.domains.invalidate(namespace)
+	h.deletions.record(namespace)
+	h.releaseDomains(r.Context(), namespace, name)
+	h.recorder.Event(h.newObjectReference(namespace, name), events.EventTypeNormal, events.ReasonDeleted, fmt.Sprintf("Proxy rule %s deleted (%s)", name, reason))
+	h.auditMutation(r, audit.OperationDelete, name, rule, nil)
+
+	result := BulkDeleteResult{Name: name, Status: "deleted"}
+	if cascade {
+		// The rule is gone either way; finish its ingresses even if the client left
+		cascaded := h.deleteRuleIngresses(context.Background(), namespace, name, nil)
+		result.DeletedIngresses = cascaded.DeletedIngresses
+		result.Error = strings.Join(cascaded.Errors, "; ")
+	}
+	return result
+}
+
+// boolQueryParameter parses the optional boolean query parameter key, which is false if absent
+func boolQueryParameter(r *http.Request, key string) (bool, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s value '%s': must be true or false", key, value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ReadCache is a local copy of the proxy rules that reads can be served from
+type ReadCache interface {
+	// Staleness returns how far behind the API server the cache may be: zero
+	// while its watch is connected, otherwise the time since it was lost
+	Staleness() time.Duration
+
+	// HasSynced reports whether the initial list has been loaded
+	HasSynced() bool
+
+	// List returns copies of the cached rules in namespace matching selector
+	List(namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error)
+
+	// Get returns a copy of the cached rule, or false if it is not cached
+	Get(namespace, name string) (*unstructured.Unstructured, bool)
+}
+
+// WithReadCache serves reads from cache
+func WithReadCache(cache ReadCache) Option {
+	return func(h *ProxyRulesHandler) {
+		h.readCache = cache
+	}
+}
+
+// WithMaxCacheStaleness makes reads fail with 503 instead of serving a cache
+// that has been out of sync for longer than max; zero disables the bound
+func WithMaxCacheStaleness(max time.Duration) Option {
+	return func(h *ProxyRulesHandler) {
+		h.maxCacheStaleness = max
+	}
+}
+
+// CacheStaleness returns the staleness of the read cache, and false if reads are not cached
+func (h *ProxyRulesHandler) CacheStaleness() (time.Duration, bool) {
+	if h.readCache == nil {
+		return 0, false
+	}
+	return h.readCache.Staleness(), true
+}
+
+// checkCacheFresh responds 503 and returns false if reads are served from a
+// cache that is staler than the configured bound
+func (h *ProxyRulesHandler) checkCacheFresh(w http.ResponseWriter) bool {
+	staleness, cached := h.CacheStaleness()
+	if !cached || h.maxCacheStaleness <= 0 || staleness <= h.maxCacheStaleness {
+		return true
+	}
+
+	http.Error(w, fmt.Sprintf("Read cache is %v out of date (maximum %v); try again later", staleness.Round(time.Second), h.maxCacheStaleness), http.StatusServiceUnavailable)
+	return false
+}
+
+// listRules lists rules from the read cache once it has synced, and from the
+// API server otherwise
+func (h *ProxyRulesHandler) listRules(ctx context.Context, namespace, labelSelector string) (*unstructured.UnstructuredList, error) {
+	if h.readCache == nil || !h.readCache.HasSynced() {
+		return h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labelSelector,
+		})
+	}
+
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := h.readCache.List(namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match the API server, which returns items ordered by name
+	sort.Slice(cached, func(i, j int) bool { return cached[i].GetName() < cached[j].GetName() })
+
+	list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, len(cached))}
+	list.SetAPIVersion("bausteln.io/v1")
+	list.SetKind("ProxyruleList")
+	for _, item := range cached {
+		list.Items = append(list.Items, *item)
+	}
+	return list, nil
+}
+
+// getRule returns a rule from the read cache, falling back to the API server
+// when caching is off or the rule is not cached yet
+func (h *ProxyRulesHandler) getRule(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	if h.readCache != nil && h.readCache.HasSynced() {
+		if rule, ok := h.readCache.Get(namespace, name); ok {
+			return rule, nil
+		}
+	}
+	return h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// setETag exposes the object's resourceVersion as a strong ETag
+func setETag(w http.ResponseWriter, obj *unstructured.Unstructured) {
+	if rv := obj.GetResourceVersion(); rv != "" {
+		w.Header().Set("ETag", `"`+rv+`"`)
+	}
+}
+
+// ifMatchResourceVersion returns the resourceVersion requested through the
+// If-Match header, or "" when the client did not ask for a precondition
+func ifMatchResourceVersion(r *http.Request) string {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return ""
+	}
+	value = strings.TrimPrefix(value, "W/")
+	return strings.Trim(value, `"`)
+}
+
+// weakETag derives a weak ETag from a serialized response body. It is weak
+// because equal bodies are only promised to be equivalent, not byte-identical
+// once the response is compressed or re-encoded.
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ifNoneMatch reports whether the If-None-Match header of r matches etag,
+// using the weak comparison RFC 9110 prescribes for GET
+func ifNoneMatch(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// deletionClock remembers when rules were last deleted through this handler,
+// per namespace. A deleted rule leaves no timestamp behind, so without it a
+// list that only lost rules would look unmodified.
+type deletionClock struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	times map[string]time.Time
+}
+
+func newDeletionClock() *deletionClock {
+	return &deletionClock{now: time.Now, times: make(map[string]time.Time)}
+}
+
+// record notes that a rule in namespace was just deleted
+func (c *deletionClock) record(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times[namespace] = c.now()
+}
+
+// last returns when a rule in namespace was last deleted, or the zero time
+func (c *deletionClock) last(namespace string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.times[namespace]
+}
+
+// ruleLastModified returns when a rule was last written: the latest time in
+// its managedFields, which the API server stamps on every create and update,
+// or its creationTimestamp if it has none
+func ruleLastModified(obj *unstructured.Unstructured) time.Time {
+	modified := obj.GetCreationTimestamp().Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(modified) {
+			modified = entry.Time.Time
+		}
+	}
+	return modified
+}
+
+// notModifiedSince reports whether the If-Modified-Since header of r is at or
+// after lastModified. It is ignored when If-None-Match is sent, which takes
+// precedence, and when lastModified is unknown.
+func notModifiedSince(r *http.Request, lastModified time.Time) bool {
+	if lastModified.IsZero() || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have whole seconds
+	return !lastModified.Truncate(time.Second).After(since)
+}
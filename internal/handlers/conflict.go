@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	// ConflictReasonDuplicateName means a rule with the requested name already exists
+	ConflictReasonDuplicateName = "DuplicateName"
+	// ConflictReasonDuplicateDomain means another rule already routes the requested domain
+	ConflictReasonDuplicateDomain = "DuplicateDomain"
+)
+
+// writeDuplicateName responds 409 for a rule name that is already taken
+func writeDuplicateName(w http.ResponseWriter, name string) {
+	writeJSON(w, http.StatusConflict, ErrorResponse{Error: ErrorDetail{
+		Code:             ConflictReasonDuplicateName,
+		Message:          fmt.Sprintf("Proxy rule with name '%s' already exists", name),
+		ConflictingRule:  name,
+		ConflictingValue: name,
+	}})
+}
+
+// writeDomainCheckError responds to an error from checkDuplicateDomain: 409 for
+// a duplicate domain, 500 if the check itself failed
+func writeDomainCheckError(w http.ResponseWriter, err error) {
+	var dupErr *duplicateDomainError
+	if !errors.As(err, &dupErr) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	conflictingValue := dupErr.ExistingDomain
+	if conflictingValue == "" {
+		conflictingValue = dupErr.Domain
+	}
+	writeJSON(w, http.StatusConflict, ErrorResponse{Error: ErrorDetail{
+		Code:             ConflictReasonDuplicateDomain,
+		Message:          dupErr.Error(),
+		ConflictingRule:  dupErr.RuleName,
+		ConflictingValue: conflictingValue,
+	}})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CountResponse is the body returned by the count endpoint
+type CountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// CountProxyRules returns the number of proxy rules without shipping the objects.
+// It asks for a single item and uses the API server's remainingItemCount when
+// available, falling back to paging through the rules otherwise.
+func (h *ProxyRulesHandler) CountProxyRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r))
+
+	list, err := resource.List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	count := int64(len(list.Items))
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		count += *remaining
+	} else if list.GetContinue() != "" {
+		opts := metav1.ListOptions{Limit: exportPageSize, Continue: list.GetContinue()}
+		for {
+			page, err := resource.List(ctx, opts)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+				return
+			}
+			count += int64(len(page.Items))
+
+			if page.GetContinue() == "" {
+				break
+			}
+			opts.Continue = page.GetContinue()
+		}
+	}
+
+	writeJSON(w, http.StatusOK, CountResponse{Count: count})
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CRDNotInstalledMessage is the error returned while the proxy rule CRD is missing
+const CRDNotInstalledMessage = "ProxyRule CRD (proxyrules.bausteln.io/v1) not installed in cluster"
+
+// IsCRDNotInstalled reports whether err means the API server does not serve the
+// requested resource type at all, as opposed to a missing object of that type.
+// The API server answers requests for unknown resources with a bare 404 page
+// rather than a Status object, which the client reports as an unexpected response.
+func IsCRDNotInstalled(err error) bool {
+	if meta.IsNoMatchError(err) {
+		return true
+	}
+	return apierrors.IsNotFound(err) && apierrors.HasStatusCause(err, metav1.CauseTypeUnexpectedServerResponse)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DomainCheckResponse is the body of POST /api/domains/check
+type DomainCheckResponse struct {
+	// Domain is the checked domain in the canonical form it would be stored in
+	Domain    string `json:"domain"`
+	Available bool   `json:"available"`
+	// ConflictingRule and ConflictingDomain name the rule that already routes
+	// the domain, exactly or through a wildcard, and its overlapping domain
+	ConflictingRule   string `json:"conflictingRule,omitempty"`
+	ConflictingDomain string `json:"conflictingDomain,omitempty"`
+}
+
+// CheckDomain reports whether the body's domain is valid and not yet routed by
+// any rule, using the same overlap rules as the duplicate domain check of a
+// create. Nothing is reserved: the domain may still be taken before the rule
+// is created, which the create then rejects as usual. Invalid domains are
+// rejected with 422.
+func (h *ProxyRulesHandler) CheckDomain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	request, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
+		return
+	}
+	domain, _ := request["domain"].(string)
+	if strings.TrimSpace(domain) == "" {
+		http.Error(w, "domain is required", http.StatusBadRequest)
+		return
+	}
+
+	// Check the domain as a create would store it
+	domain = canonicalDomain(domain)
+	if validationErrs := validation.ValidateDomain(domain); len(validationErrs) > 0 {
+		for i := range validationErrs {
+			validationErrs[i].Field = "domain"
+		}
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+
+	namespace := requestNamespace(r)
+	list := func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		return h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	}
+	rules, _, err := h.domains.rules(r.Context(), namespace, list)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := DomainCheckResponse{Domain: domain, Available: true}
+
+	// Report the first conflict in name order, as the duplicate check would
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if conflict := findDomainConflict(name, rules[name], []string{domain}); conflict != nil {
+			response.Available = false
+			response.ConflictingRule = conflict.RuleName
+			response.ConflictingDomain = conflict.ExistingDomain
+			break
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// domainIndexTTL bounds how long a domain index is reused before it is rebuilt
+// from a fresh List. Writes through this handler invalidate it immediately; the
+// TTL only limits how long changes made elsewhere (kubectl, other replicas) go
+// unnoticed.
+const domainIndexTTL = 2 * time.Second
+
+// domainIndex caches the domains of every rule per namespace so that duplicate
+// domain checks do not each List all rules. Concurrent lookups share a single
+// List, and domains that passed a check are claimed until their write finishes,
+// so two concurrent writes through this handler cannot both take the same
+// domain. Writes through other replicas are only covered by the domain lock
+// (see WithDomainLock), and writes directly against the API server only by a
+// server-side uniqueness check.
+type domainIndex struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	now        func() time.Time
+	namespaces map[string]*namespaceDomains
+	nextClaim  uint64
+}
+
+// namespaceDomains is the index for a single namespace
+type namespaceDomains struct {
+	rules      map[string][]string // rule name -> domains, as of builtAt
+	builtAt    time.Time
+	generation uint64 // incremented on every invalidation
+	building   *domainIndexBuild
+	claims     map[uint64]domainClaim
+}
+
+// domainIndexBuild is a List in flight that concurrent lookups wait for
+type domainIndexBuild struct {
+	done       chan struct{}
+	cancel     context.CancelFunc
+	waiters    int // lookups still waiting; the List is cancelled when the last one gives up
+	generation uint64
+	rules      map[string][]string
+	err        error
+}
+
+// domainClaim holds domains that passed a duplicate check for a write in progress
+type domainClaim struct {
+	rule    string
+	domains []string
+}
+
+func newDomainIndex() *domainIndex {
+	return &domainIndex{
+		ttl:        domainIndexTTL,
+		now:        time.Now,
+		namespaces: make(map[string]*namespaceDomains),
+	}
+}
+
+func (x *domainIndex) namespace(namespace string) *namespaceDomains {
+	ns, ok := x.namespaces[namespace]
+	if !ok {
+		ns = &namespaceDomains{claims: make(map[uint64]domainClaim)}
+		x.namespaces[namespace] = ns
+	}
+	return ns
+}
+
+// rules returns the domains of every rule in namespace, calling list to rebuild
+// the index if it has expired. Concurrent callers share one call to list. The
+// returned generation is passed to claim to detect writes that finished since.
+func (x *domainIndex) rules(ctx context.Context, namespace string, list func(context.Context) (*unstructured.UnstructuredList, error)) (map[string][]string, uint64, error) {
+	x.mu.Lock()
+	ns := x.namespace(namespace)
+	if ns.rules != nil && x.now().Sub(ns.builtAt) < x.ttl {
+		rules, generation := ns.rules, ns.generation
+		x.mu.Unlock()
+		return rules, generation, nil
+	}
+
+	build := ns.building
+	if build == nil {
+		// The List is shared by several requests, so it must not be cancelled with
+		// whichever one started it, only once none of them is waiting any more
+		listCtx, cancel := context.WithCancel(context.Background())
+		build = &domainIndexBuild{done: make(chan struct{}), cancel: cancel, generation: ns.generation}
+		ns.building = build
+		go x.build(listCtx, namespace, build, list)
+	}
+	build.waiters++
+	x.mu.Unlock()
+
+	select {
+	case <-build.done:
+		return build.rules, build.generation, build.err
+	case <-ctx.Done():
+		x.abandon(namespace, build)
+		return nil, 0, ctx.Err()
+	}
+}
+
+// abandon stops waiting for build and cancels its List if no other lookup
+// still waits for it
+func (x *domainIndex) abandon(namespace string, build *domainIndexBuild) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	build.waiters--
+	if build.waiters > 0 {
+		return
+	}
+	build.cancel()
+	if ns := x.namespace(namespace); ns.building == build {
+		ns.building = nil
+	}
+}
+
+// build lists the rules of namespace and stores the result, unless the index
+// was invalidated while the List was in flight
+func (x *domainIndex) build(ctx context.Context, namespace string, build *domainIndexBuild, list func(context.Context) (*unstructured.UnstructuredList, error)) {
+	defer build.cancel()
+	items, err := list(ctx)
+	if err == nil {
+		build.rules = make(map[string][]string, len(items.Items))
+		for _, item := range items.Items {
+			build.rules[item.GetName()] = getRuleDomains(&item)
+		}
+	}
+	build.err = err
+
+	x.mu.Lock()
+	ns := x.namespace(namespace)
+	if ns.building == build {
+		ns.building = nil
+	}
+	if err == nil && ns.generation == build.generation {
+		ns.rules = build.rules
+		ns.builtAt = x.now()
+	}
+	x.mu.Unlock()
+	close(build.done)
+}
+
+// claim checks domains against the pending claims in namespace (ignoring those
+// of excludeName) and, if none conflict, claims them until release is called.
+// It returns false without claiming if a write finished after generation, as
+// the rules the caller checked may then be missing that write.
+func (x *domainIndex) claim(namespace string, generation uint64, rule, excludeName string, domains []string) (uint64, bool, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ns := x.namespace(namespace)
+	if ns.generation != generation {
+		return 0, false, nil
+	}
+	for _, claim := range ns.claims {
+		if excludeName != "" && claim.rule == excludeName {
+			continue
+		}
+		if err := findDomainConflict(claim.rule, claim.domains, domains); err != nil {
+			return 0, true, err
+		}
+	}
+
+	x.nextClaim++
+	ns.claims[x.nextClaim] = domainClaim{rule: rule, domains: domains}
+	return x.nextClaim, true, nil
+}
+
+// release drops a claim once its write has finished. If the write changed the
+// rule, the namespace index is invalidated first so the claimed domains stay
+// visible until a List that includes the write replaces them.
+func (x *domainIndex) release(namespace string, id uint64, written bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	ns := x.namespace(namespace)
+	if written {
+		ns.invalidate()
+	}
+	delete(ns.claims, id)
+}
+
+// invalidate forces the next lookup in namespace to List the rules again
+func (x *domainIndex) invalidate(namespace string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.namespace(namespace).invalidate()
+}
+
+func (ns *namespaceDomains) invalidate() {
+	ns.rules = nil
+	ns.generation++
+	// A List already in flight may miss the write; later lookups start a new one
+	ns.building = nil
+}
+
+// findDomainConflict returns the first of domains that overlaps one of the
+// existing domains of rule
+func findDomainConflict(rule string, existing, domains []string) *duplicateDomainError {
+	for _, existingDomain := range existing {
+		for _, domain := range domains {
+			if domainsConflict(existingDomain, domain) {
+				return &duplicateDomainError{Domain: domain, ExistingDomain: existingDomain, RuleName: rule}
+			}
+		}
+	}
+	return nil
+}
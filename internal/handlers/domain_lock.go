@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// maxDomainLockAttempts bounds how often a lock update is retried after
+	// losing an optimistic-concurrency race against another writer
+	maxDomainLockAttempts = 5
+	// pendingReservationTTL is how long a reservation for a write that never
+	// completed (e.g. the replica crashed) blocks its domains
+	pendingReservationTTL = time.Minute
+)
+
+// configMapGVR is the GroupVersionResource of core/v1 ConfigMaps
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// WithDomainLock enforces unique domains across all writers through a ConfigMap
+// of this name in each rule namespace, mapping every domain to the rule that
+// owns it. A domain is reserved with an optimistic-concurrency update of the
+// ConfigMap before the rule is written, so of two concurrent writers, on any
+// replica, only one can take it. An empty name disables the lock.
+func WithDomainLock(configMapName string) Option {
+	return func(h *ProxyRulesHandler) {
+		h.domainLock = configMapName
+	}
+}
+
+// domainLockEntry is the owner of a domain in the lock. Reservations for writes
+// in progress are pending until the write succeeds.
+type domainLockEntry struct {
+	rule         string
+	pendingSince time.Time
+}
+
+// parseDomainLockEntry decodes "rule" or, while pending, "rule/unix-seconds"
+func parseDomainLockEntry(value string) domainLockEntry {
+	rule, since, pending := strings.Cut(value, "/")
+	entry := domainLockEntry{rule: rule}
+	if seconds, err := strconv.ParseInt(since, 10, 64); pending && err == nil {
+		entry.pendingSince = time.Unix(seconds, 0)
+	}
+	return entry
+}
+
+func (e domainLockEntry) String() string {
+	if e.pendingSince.IsZero() {
+		return e.rule
+	}
+	return fmt.Sprintf("%s/%d", e.rule, e.pendingSince.Unix())
+}
+
+// domainLockKey maps a domain to a ConfigMap key, which may not contain '*';
+// '_' never appears in a valid domain
+func domainLockKey(domain string) string {
+	return strings.Replace(canonicalDomain(domain), "*", "_", 1)
+}
+
+// domainFromLockKey reverses domainLockKey
+func domainFromLockKey(key string) string {
+	return strings.Replace(key, "_", "*", 1)
+}
+
+// errDomainLockUnchanged tells updateDomainLock that there is nothing to write
+var errDomainLockUnchanged = errors.New("domain lock unchanged")
+
+// reserveDomains reserves domains for rule in the lock of namespace, failing
+// with a duplicateDomainError if another rule owns an overlapping domain.
+// Domains of excludeName, the rule being replaced, do not count as taken. The
+// returned release confirms the reservation once the write succeeded, dropping
+// domains the rule no longer has, or restores the previous owners otherwise.
+func (h *ProxyRulesHandler) reserveDomains(ctx context.Context, namespace, rule, excludeName string, domains []string) (release func(written bool), err error) {
+	var previous map[string]string // key -> value before the reservation ("" if free)
+	now := time.Now()
+
+	err = h.updateDomainLock(ctx, namespace, func(data map[string]string) error {
+		previous = make(map[string]string, len(domains))
+		for _, domain := range domains {
+			for key, value := range data {
+				owner := parseDomainLockEntry(value)
+				if owner.rule == rule || owner.rule == excludeName || !domainsConflict(domainFromLockKey(key), domain) {
+					continue
+				}
+				if !h.domainLockEntryStale(ctx, namespace, owner, domainFromLockKey(key), now) {
+					return &duplicateDomainError{Domain: domain, ExistingDomain: domainFromLockKey(key), RuleName: owner.rule}
+				}
+				slog.Debug("Dropping stale domain reservation", "namespace", namespace, "domain", domainFromLockKey(key), "rule", owner.rule)
+				previous[key] = value
+				delete(data, key)
+			}
+		}
+		for _, domain := range domains {
+			key := domainLockKey(domain)
+			if _, recorded := previous[key]; !recorded {
+				previous[key] = data[key]
+			}
+			data[key] = domainLockEntry{rule: rule, pendingSince: now}.String()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(written bool) {
+		// The request may be gone by now, but the lock must still be settled
+		ctx := context.WithoutCancel(ctx)
+		err := h.updateDomainLock(ctx, namespace, func(data map[string]string) error {
+			for key, value := range data {
+				owner := parseDomainLockEntry(value)
+				if owner.rule != rule {
+					continue
+				}
+				_, reserved := previous[key]
+				switch {
+				case written && reserved:
+					data[key] = owner.rule
+				case written:
+					// A domain the rule had before this write
+					delete(data, key)
+				case reserved && previous[key] == "":
+					delete(data, key)
+				case reserved:
+					data[key] = previous[key]
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Warn("Failed to settle domain reservation", "namespace", namespace, "rule", rule, "written", written, "error", err)
+		}
+	}, nil
+}
+
+// releaseDomains drops every domain of a deleted rule from the lock
+func (h *ProxyRulesHandler) releaseDomains(ctx context.Context, namespace, rule string) {
+	if h.domainLock == "" {
+		return
+	}
+	err := h.updateDomainLock(context.WithoutCancel(ctx), namespace, func(data map[string]string) error {
+		changed := false
+		for key, value := range data {
+			if parseDomainLockEntry(value).rule == rule {
+				delete(data, key)
+				changed = true
+			}
+		}
+		if !changed {
+			return errDomainLockUnchanged
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("Failed to release domains of deleted rule", "namespace", namespace, "rule", rule, "error", err)
+	}
+}
+
+// domainLockEntryStale reports whether owner no longer holds domain: a pending
+// reservation whose write never finished, or a rule deleted or changed without
+// going through this backend
+func (h *ProxyRulesHandler) domainLockEntryStale(ctx context.Context, namespace string, owner domainLockEntry, domain string, now time.Time) bool {
+	if !owner.pendingSince.IsZero() {
+		return now.Sub(owner.pendingSince) > pendingReservationTTL
+	}
+	obj, err := h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).Get(ctx, owner.rule, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true
+	}
+	if err != nil {
+		// Unknown, so keep the reservation
+		return false
+	}
+	for _, ruleDomain := range getRuleDomains(obj) {
+		if canonicalDomain(ruleDomain) == domain {
+			return false
+		}
+	}
+	return true
+}
+
+// updateDomainLock applies mutate to the data of the lock ConfigMap in
+// namespace and writes it back, creating the ConfigMap from the stored rules if
+// it does not exist yet. Lost races against other writers are retried with
+// fresh data; errors from mutate are returned as they are.
+func (h *ProxyRulesHandler) updateDomainLock(ctx context.Context, namespace string, mutate func(data map[string]string) error) error {
+	resource := h.dynamicClient.Resource(configMapGVR).Namespace(namespace)
+
+	for attempt := 0; attempt < maxDomainLockAttempts; attempt++ {
+		cm, err := resource.Get(ctx, h.domainLock, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if err != nil && !create {
+			return fmt.Errorf("error reading domain lock: %w", err)
+		}
+
+		var data map[string]string
+		if create {
+			cm = &unstructured.Unstructured{}
+			cm.SetAPIVersion("v1")
+			cm.SetKind("ConfigMap")
+			cm.SetName(h.domainLock)
+			cm.SetNamespace(namespace)
+			if data, err = h.seedDomainLock(ctx, namespace); err != nil {
+				return fmt.Errorf("error seeding domain lock: %w", err)
+			}
+		} else {
+			data, _, _ = unstructured.NestedStringMap(cm.Object, "data")
+			if data == nil {
+				data = map[string]string{}
+			}
+		}
+
+		if err := mutate(data); err != nil {
+			if errors.Is(err, errDomainLockUnchanged) {
+				return nil
+			}
+			return err
+		}
+		if err := unstructured.SetNestedStringMap(cm.Object, data, "data"); err != nil {
+			return err
+		}
+
+		if create {
+			_, err = resource.Create(ctx, cm, metav1.CreateOptions{})
+		} else {
+			_, err = resource.Update(ctx, cm, metav1.UpdateOptions{})
+		}
+		if err == nil {
+			return nil
+		}
+		if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("error writing domain lock: %w", err)
+		}
+		// Another writer changed the lock since it was read; start over
+	}
+	return fmt.Errorf("domain lock %s kept changing, please retry", h.domainLock)
+}
+
+// seedDomainLock returns lock data for the rules stored in namespace, so rules
+// written before the lock existed are protected too
+func (h *ProxyRulesHandler) seedDomainLock(ctx context.Context, namespace string) (map[string]string, error) {
+	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]string{}
+	for i := range list.Items {
+		for _, domain := range getRuleDomains(&list.Items[i]) {
+			// Of rules that already share a domain, the first in name order keeps it
+			if _, taken := data[domainLockKey(domain)]; !taken {
+				data[domainLockKey(domain)] = list.Items[i].GetName()
+			}
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parseDryRun reads the ?dryRun= query parameter, which like kubectl's
+// --dry-run=server only accepts All. The result is passed as the DryRun option
+// of the write, so the API server runs it through admission without persisting.
+func parseDryRun(r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get("dryRun")
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.EqualFold(value, metav1.DryRunAll) {
+		return nil, fmt.Errorf("must be %s", metav1.DryRunAll)
+	}
+	return []string{metav1.DryRunAll}, nil
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// exportPageSize is the number of rules fetched per List call during export
+	exportPageSize = 100
+	// csvListDelimiter joins multiple values (domains, destinations, labels) in one CSV cell
+	csvListDelimiter = ";"
+)
+
+// csvHeader is the header row of the CSV export
+var csvHeader = []string{"name", "domain", "destinations", "port", "tls", "labels"}
+
+// ExportProxyRules streams all proxy rules as a multi-document YAML stream that
+// can be applied again with kubectl, or as
+// CSV with ?format=csv. Rules are fetched page by page and written as they arrive,
+// so memory use is bounded by the page size rather than the total number of rules.
+func (h *ProxyRulesHandler) ExportProxyRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var writeHeader func() error
+	var writeItem func(item unstructured.Unstructured) error
+	var flush func()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "yaml":
+		writeHeader = func() error {
+			w.Header().Set("Content-Type", "application/yaml")
+			w.Header().Set("Content-Disposition", `attachment; filename="proxyrules.yaml"`)
+			w.WriteHeader(http.StatusOK)
+			return nil
+		}
+		writeItem = func(item unstructured.Unstructured) error {
+			return writeYAMLDocument(w, sanitizeForExport(&item).Object)
+		}
+		flush = func() {}
+	case "csv":
+		csvWriter := csv.NewWriter(w)
+		writeHeader = func() error {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", `attachment; filename="proxyrules.csv"`)
+			w.WriteHeader(http.StatusOK)
+			return csvWriter.Write(csvHeader)
+		}
+		writeItem = func(item unstructured.Unstructured) error {
+			return csvWriter.Write(csvRecord(&item))
+		}
+		flush = csvWriter.Flush
+	default:
+		http.Error(w, fmt.Sprintf("Unsupported export format '%s': must be yaml or csv", format), http.StatusBadRequest)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	ctx := r.Context()
+	opts := metav1.ListOptions{Limit: exportPageSize}
+	wroteHeader := false
+
+	for {
+		list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).List(ctx, opts)
+		if err != nil {
+			if !wroteHeader {
+				http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+				return
+			}
+			// The status line is already sent, so the stream can only be cut short
+			slog.Error("Error fetching proxyrules during export", "error", err)
+			return
+		}
+
+		if !wroteHeader {
+			wroteHeader = true
+			if err := writeHeader(); err != nil {
+				slog.Error("Error writing export header", "error", err)
+				return
+			}
+		}
+
+		for _, item := range list.Items {
+			if err := writeItem(item); err != nil {
+				slog.Error("Error writing proxyrule during export", "name", item.GetName(), "error", err)
+				return
+			}
+		}
+
+		flush()
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if list.GetContinue() == "" {
+			return
+		}
+		opts.Continue = list.GetContinue()
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// exportedMetadata are the metadata fields kept in YAML exports; everything
+// else (uid, resourceVersion, managedFields, creationTimestamp, ...) is set
+// by the API server and would make the export fail to apply elsewhere
+var exportedMetadata = []string{"name", "namespace", "labels", "annotations", "finalizers"}
+
+// lastAppliedAnnotation is kubectl's copy of the previously applied object
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// sanitizeForExport returns a copy of rule without server-populated fields or
+// status, so that it can be applied again as is
+func sanitizeForExport(rule *unstructured.Unstructured) *unstructured.Unstructured {
+	sanitized := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	sanitized.SetAPIVersion(rule.GetAPIVersion())
+	if sanitized.GetAPIVersion() == "" {
+		sanitized.SetAPIVersion("bausteln.io/v1")
+	}
+	sanitized.SetKind(rule.GetKind())
+	if sanitized.GetKind() == "" {
+		sanitized.SetKind("Proxyrule")
+	}
+
+	metadata := map[string]interface{}{}
+	if existing, ok := rule.Object["metadata"].(map[string]interface{}); ok {
+		for _, field := range exportedMetadata {
+			if value, found := existing[field]; found {
+				metadata[field] = runtime.DeepCopyJSONValue(value)
+			}
+		}
+	}
+	sanitized.Object["metadata"] = metadata
+
+	if annotations := sanitized.GetAnnotations(); annotations != nil {
+		delete(annotations, lastAppliedAnnotation)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		sanitized.SetAnnotations(annotations)
+	}
+
+	if spec, found := rule.Object["spec"]; found {
+		sanitized.Object["spec"] = runtime.DeepCopyJSONValue(spec)
+	}
+	return sanitized
+}
+
+// writeYAMLDocument writes obj as a single "---" prefixed YAML document
+func writeYAMLDocument(w http.ResponseWriter, obj map[string]interface{}) error {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("---\n")); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// csvRecord flattens a rule into a CSV row matching csvHeader, using the
+// resolved port and tls so defaults show up in the spreadsheet
+func csvRecord(rule *unstructured.Unstructured) []string {
+	resolved := resolveProxyRule(rule)
+
+	port := ""
+	if value, found, _ := unstructured.NestedFieldNoCopy(resolved.Object, "spec", "port"); found {
+		port = fmt.Sprint(value)
+	}
+	tls := ""
+	if value, found, _ := unstructured.NestedFieldNoCopy(resolved.Object, "spec", "tls"); found {
+		tls = fmt.Sprint(value)
+	}
+
+	labels := make([]string, 0, len(rule.GetLabels()))
+	for key, value := range rule.GetLabels() {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	return []string{
+		rule.GetName(),
+		strings.Join(getRuleDomains(resolved), csvListDelimiter),
+		strings.Join(getRuleDestinations(resolved), csvListDelimiter),
+		port,
+		tls,
+		strings.Join(labels, csvListDelimiter),
+	}
+}
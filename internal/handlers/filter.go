@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// getRuleDestinations returns all destinations of a rule (destination and destinations)
+func getRuleDestinations(obj *unstructured.Unstructured) []string {
+	var destinations []string
+
+	if destination, found, err := unstructured.NestedString(obj.Object, "spec", "destination"); err == nil && found && destination != "" {
+		destinations = append(destinations, destination)
+	}
+	if list, found, err := unstructured.NestedStringSlice(obj.Object, "spec", "destinations"); err == nil && found {
+		for _, destination := range list {
+			if destination != "" {
+				destinations = append(destinations, destination)
+			}
+		}
+	}
+
+	return destinations
+}
+
+// ruleEnabled reports whether a rule routes traffic; spec.enabled defaults to true
+func ruleEnabled(obj *unstructured.Unstructured) bool {
+	enabled, found, err := unstructured.NestedBool(obj.Object, "spec", "enabled")
+	return err != nil || !found || enabled
+}
+
+// filterByEnabled keeps only the rules whose enabled state equals enabled
+func filterByEnabled(items []unstructured.Unstructured, enabled bool) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for _, item := range items {
+		if ruleEnabled(&item) == enabled {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// destinationsEqual reports whether two destinations refer to the same address,
+// comparing IP addresses by value (so 2001:db8::1 matches 2001:0db8:0:0::1) and
+// DNS names case-insensitively
+func destinationsEqual(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA != nil || ipB != nil {
+		return ipA != nil && ipB != nil && ipA.Equal(ipB)
+	}
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// filterByDestination keeps only the rules with a destination equal to destination
+func filterByDestination(items []unstructured.Unstructured, destination string) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for _, item := range items {
+		for _, candidate := range getRuleDestinations(&item) {
+			if destinationsEqual(candidate, destination) {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// fieldFilter reports whether a rule matches one spec.<field>= query
+type fieldFilter func(rule *unstructured.Unstructured) bool
+
+// specFieldFilters are the spec fields that can be queried by exact value,
+// each parsing the query value into a filter. Port and tls are compared in
+// their resolved form, so rules relying on the defaults match them too.
+var specFieldFilters = map[string]func(value string) (fieldFilter, error){
+	"spec.port": func(value string) (fieldFilter, error) {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return func(rule *unstructured.Unstructured) bool { return probePort(rule) == port }, nil
+	},
+	"spec.tls": func(value string) (fieldFilter, error) {
+		tls, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("must be true or false")
+		}
+		return func(rule *unstructured.Unstructured) bool {
+			resolved, _, _ := unstructured.NestedBool(resolveProxyRule(rule).Object, "spec", "tls")
+			return resolved == tls
+		}, nil
+	},
+	"spec.destination": func(value string) (fieldFilter, error) {
+		return func(rule *unstructured.Unstructured) bool {
+			destination, _, _ := unstructured.NestedString(rule.Object, "spec", "destination")
+			return destination != "" && destinationsEqual(destination, value)
+		}, nil
+	},
+}
+
+// parseFieldFilters returns a filter for each spec.<field>= query parameter,
+// failing on fields that cannot be queried and on values of the wrong type
+func parseFieldFilters(r *http.Request) ([]fieldFilter, error) {
+	query := r.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		if strings.HasPrefix(key, "spec.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []fieldFilter
+	for _, key := range keys {
+		parse, ok := specFieldFilters[key]
+		if !ok {
+			return nil, fmt.Errorf("field %s cannot be queried; supported fields are spec.destination, spec.port and spec.tls", key)
+		}
+		for _, value := range query[key] {
+			filter, err := parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s value '%s' %v", key, value, err)
+			}
+			filters = append(filters, filter)
+		}
+	}
+	return filters, nil
+}
+
+// filterByFields keeps only the rules matching all filters
+func filterByFields(items []unstructured.Unstructured, filters []fieldFilter) []unstructured.Unstructured {
+	filtered := []unstructured.Unstructured{}
+	for _, item := range items {
+		matches := true
+		for _, filter := range filters {
+			if !filter(&item) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// HealthSummary is the aggregate validation status of all proxy rules in the namespace
+type HealthSummary struct {
+	Total           int `json:"total"`
+	Valid           int `json:"valid"`
+	Invalid         int `json:"invalid"`
+	DomainConflicts int `json:"domainConflicts"`
+	Orphaned        int `json:"orphaned"`
+}
+
+// GetHealthSummary re-validates every proxy rule and reports how many are valid,
+// how many pairs of rules share a domain and how many have no generated ingress
+func (h *ProxyRulesHandler) GetHealthSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	ingresses, err := h.dynamicClient.Resource(ingressGVR).Namespace(requestNamespace(r)).List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching ingresses: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summarizeProxyRules(list.Items, ingresses.Items, h.validationOptions))
+}
+
+// summarizeProxyRules computes the health summary for rules and their generated
+// ingresses; rules are checked against opts as on create
+func summarizeProxyRules(rules, ingresses []unstructured.Unstructured, opts validation.Options) HealthSummary {
+	summary := HealthSummary{Total: len(rules)}
+
+	for i := range rules {
+		if errs := validation.ValidateProxyRuleCreate(&rules[i], opts); len(errs) > 0 {
+			summary.Invalid++
+		} else {
+			summary.Valid++
+		}
+
+		hasIngress := false
+		for _, ingress := range ingresses {
+			if isIngressForRule(ingress, rules[i].GetNamespace(), rules[i].GetName()) {
+				hasIngress = true
+				break
+			}
+		}
+		if !hasIngress {
+			summary.Orphaned++
+		}
+	}
+
+	// Count each pair of rules whose domains collide
+	for i := range rules {
+		domains := getRuleDomains(&rules[i])
+		for j := i + 1; j < len(rules); j++ {
+			if anyDomainsConflict(domains, getRuleDomains(&rules[j])) {
+				summary.DomainConflicts++
+			}
+		}
+	}
+
+	return summary
+}
+
+// anyDomainsConflict reports whether any domain in a conflicts with any domain in b
+func anyDomainsConflict(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if domainsConflict(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// HistoryAnnotation holds a rule's change history as a JSON array of HistoryEntry
+	HistoryAnnotation = "bausteln.io/history"
+	// UpdatedAtAnnotation holds the RFC 3339 time of a rule's last update through this backend
+	UpdatedAtAnnotation = "bausteln.io/updated-at"
+	// maxHistoryEntries is how many changes the history keeps
+	maxHistoryEntries = 20
+	// maxHistoryBytes bounds the encoded history, well below the 256KiB that
+	// Kubernetes allows for all annotations of an object together
+	maxHistoryBytes = 16 << 10
+)
+
+// HistoryEntry is one change to a rule made through this backend
+type HistoryEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Operation string    `json:"operation"`
+	// Subject is the authenticated caller, if authentication is enabled
+	Subject string `json:"subject,omitempty"`
+	// Changes lists the changed fields, e.g. spec.destination or metadata.labels
+	Changes []string `json:"changes"`
+}
+
+// HistoryResponse is the body returned for a rule's history
+type HistoryResponse struct {
+	Name    string         `json:"name"`
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// GetProxyRuleHistory returns the changes recorded in a rule's history
+// annotation, oldest first
+func (h *ProxyRulesHandler) GetProxyRuleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}/history
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}/history", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	obj, err := h.getRule(r.Context(), requestNamespace(r), name)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), status)
+		return
+	}
+
+	entries, err := ruleHistory(obj)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error decoding %s annotation: %v", HistoryAnnotation, err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, HistoryResponse{Name: name, Entries: entries})
+}
+
+// ruleHistory decodes the history annotation of obj
+func ruleHistory(obj *unstructured.Unstructured) ([]HistoryEntry, error) {
+	entries := []HistoryEntry{}
+	data, ok := obj.GetAnnotations()[HistoryAnnotation]
+	if !ok {
+		return entries, nil
+	}
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// recordHistory sets the history annotation of updated to that of the stored
+// rule plus an entry for the fields changed between the two, and stamps the
+// updated-at annotation. The stored history always wins, so a request cannot
+// rewrite it. No history entry is added if no field changed.
+func recordHistory(stored, updated *unstructured.Unstructured, operation, subject string) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	entries, err := ruleHistory(stored)
+	if err != nil {
+		// Someone edited the annotation by hand; start over rather than block the update
+		slog.Warn("Discarding unreadable rule history", "rule", stored.GetName(), "error", err)
+		entries = nil
+	}
+
+	if changes := changedFields(stored, updated); len(changes) > 0 {
+		entries = append(entries, HistoryEntry{
+			Timestamp: now,
+			Operation: operation,
+			Subject:   subject,
+			Changes:   changes,
+		})
+	}
+
+	annotations := updated.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	delete(annotations, HistoryAnnotation)
+	if data := encodeHistory(entries); data != "" {
+		annotations[HistoryAnnotation] = data
+	}
+	annotations[UpdatedAtAnnotation] = now.Format(time.RFC3339)
+	updated.SetAnnotations(annotations)
+}
+
+// encodeHistory encodes entries, dropping the oldest until at most
+// maxHistoryEntries remain and the result fits into maxHistoryBytes
+func encodeHistory(entries []HistoryEntry) string {
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+	for len(entries) > 0 {
+		data, err := json.Marshal(entries)
+		if err == nil && len(data) <= maxHistoryBytes {
+			return string(data)
+		}
+		entries = entries[1:]
+	}
+	return ""
+}
+
+// changedFields lists the spec fields, labels and annotations that differ
+// between before and after, sorted
+func changedFields(before, after *unstructured.Unstructured) []string {
+	changes := []string{}
+
+	beforeSpec, _, _ := unstructured.NestedMap(before.Object, "spec")
+	afterSpec, _, _ := unstructured.NestedMap(after.Object, "spec")
+	keys := map[string]bool{}
+	for key := range beforeSpec {
+		keys[key] = true
+	}
+	for key := range afterSpec {
+		keys[key] = true
+	}
+	for key := range keys {
+		// Compare encoded values, as numbers decode as int64 or float64 depending on the source
+		beforeValue, _ := json.Marshal(beforeSpec[key])
+		afterValue, _ := json.Marshal(afterSpec[key])
+		if string(beforeValue) != string(afterValue) {
+			changes = append(changes, "spec."+key)
+		}
+	}
+	sort.Strings(changes)
+
+	if !reflect.DeepEqual(nonEmpty(before.GetLabels()), nonEmpty(after.GetLabels())) {
+		changes = append(changes, "metadata.labels")
+	}
+	if !reflect.DeepEqual(userAnnotations(before), userAnnotations(after)) {
+		changes = append(changes, "metadata.annotations")
+	}
+	return changes
+}
+
+// userAnnotations returns the annotations of obj other than those the
+// backend and kubectl maintain themselves
+func userAnnotations(obj *unstructured.Unstructured) map[string]string {
+	annotations := map[string]string{}
+	for key, value := range obj.GetAnnotations() {
+		if key != HistoryAnnotation && key != UpdatedAtAnnotation && key != lastAppliedAnnotation {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// nonEmpty treats a nil map like an empty one
+func nonEmpty(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// importModeCreate fails the import if a rule already exists (the default)
+	importModeCreate = "create"
+	// importModeUpsert replaces the spec, labels and annotations of existing rules
+	importModeUpsert = "upsert"
+)
+
+// ImportResult is the outcome of importing one rule
+type ImportResult struct {
+	Name string `json:"name"`
+	// Status is created, updated or error; skipped means the rule was valid but
+	// nothing was written because the import was rejected
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportResponse is the body returned by an import
+type ImportResponse struct {
+	Mode    string         `json:"mode"`
+	Results []ImportResult `json:"results"`
+}
+
+// importedRule is a rule from an import body on its way to the cluster
+type importedRule struct {
+	obj      *unstructured.Unstructured
+	existing *unstructured.Unstructured // set when an upsert replaces a stored rule
+	warning  string                     // a duplicate domain accepted with ALLOW_DUPLICATE_DOMAINS
+	result   ImportResult
+}
+
+// ImportProxyRules creates the rules in a multi-document YAML stream or JSON
+// array, e.g. an export. Every rule is validated and checked for duplicate
+// names and domains, against each other and the stored rules, before anything
+// is written: if one fails, none are written and the response is 422 (invalid
+// rules or destination loops) or 409 (conflicts). With ?mode=upsert, rules that already exist are
+// updated instead of conflicting. Responds 207 if some writes failed.
+func (h *ProxyRulesHandler) ImportProxyRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = importModeCreate
+	case importModeCreate, importModeUpsert:
+	default:
+		http.Error(w, fmt.Sprintf("Invalid mode '%s': must be %s or %s", mode, importModeCreate, importModeUpsert), http.StatusBadRequest)
+		return
+	}
+
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	docs, err := validation.DecodeRequestDocuments(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(docs) == 0 {
+		http.Error(w, "Import contains no rules", http.StatusBadRequest)
+		return
+	}
+
+	namespace := requestNamespace(r)
+	rules := make([]*importedRule, len(docs))
+	invalid := false
+	for i, doc := range docs {
+		rules[i] = h.prepareImportedRule(w, doc, namespace)
+		if rules[i].result.Status == "error" {
+			invalid = true
+		}
+	}
+	if looped, err := h.checkImportLoops(r.Context(), namespace, rules); err != nil {
+		http.Error(w, fmt.Sprintf("Error checking for destination loops: %v", err), http.StatusInternalServerError)
+		return
+	} else if looped {
+		invalid = true
+	}
+
+	response := ImportResponse{Mode: mode, Results: make([]ImportResult, 0, len(rules))}
+	if invalid {
+		writeJSON(w, http.StatusUnprocessableEntity, rejectImport(response, rules))
+		return
+	}
+
+	if conflicts, err := h.checkImportConflicts(r, mode, rules); err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	} else if conflicts {
+		writeJSON(w, http.StatusConflict, rejectImport(response, rules))
+		return
+	}
+	created := 0
+	for _, rule := range rules {
+		if rule.existing == nil {
+			created++
+		}
+	}
+	if !h.checkRuleQuota(w, r, namespace, created) {
+		return
+	}
+	for _, rule := range rules {
+		if rule.warning != "" {
+			slog.Warn(rule.warning)
+			addWarning(w, rule.warning)
+		}
+	}
+
+	status := http.StatusOK
+	for _, rule := range rules {
+		h.writeImportedRule(w, r, rule)
+		if rule.result.Status == "error" {
+			status = http.StatusMultiStatus
+		}
+		response.Results = append(response.Results, rule.result)
+	}
+	h.domains.invalidate(namespace)
+
+	writeJSON(w, status, response)
+}
+
+// prepareImportedRule turns a decoded document into a rule for namespace,
+// recording why it cannot be imported in its result
+func (h *ProxyRulesHandler) prepareImportedRule(w http.ResponseWriter, doc map[string]interface{}, namespace string) *importedRule {
+	// Exports carry no server-populated fields, but other backups might
+	obj := sanitizeForExport(&unstructured.Unstructured{Object: doc})
+	rule := &importedRule{obj: obj, result: ImportResult{Name: obj.GetName()}}
+
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(namespace)
+	} else if obj.GetNamespace() != namespace {
+		rule.fail(fmt.Sprintf("metadata.namespace '%s' does not match the request namespace '%s'", obj.GetNamespace(), namespace))
+		return rule
+	}
+
+	// Store domains and destinations in canonical form
+	validation.NormalizeProxyRule(obj)
+	h.applyDefaultDestinationPort(w, obj)
+	logParsedRule("import", obj)
+
+	if validationErrs := validation.ValidateProxyRuleCreate(obj, h.validationOptions); len(validationErrs) > 0 {
+		rule.fail(validationErrs.Error())
+	}
+	return rule
+}
+
+// checkImportLoops records destinations of imported rules that are routed by
+// the domain of a stored rule or of a rule earlier in the import, when loop
+// detection is enabled, and reports whether there were any
+func (h *ProxyRulesHandler) checkImportLoops(ctx context.Context, namespace string, rules []*importedRule) (bool, error) {
+	if !h.detectDestinationLoops {
+		return false, nil
+	}
+	stored, err := h.storedRuleDomains(ctx, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	routed := make(map[string][]string, len(stored)+len(rules))
+	for name, domains := range stored {
+		routed[name] = domains
+	}
+	looped := false
+	for _, rule := range rules {
+		if rule.result.Status == "error" {
+			continue
+		}
+		if loopErrs := destinationLoops(rule.obj, routed, ""); len(loopErrs) > 0 {
+			rule.fail(loopErrs.Error())
+			looped = true
+		}
+		// An upsert replaces the stored domains of the rule
+		routed[rule.obj.GetName()] = getRuleDomains(rule.obj)
+	}
+	return looped, nil
+}
+
+// checkImportConflicts records duplicate names and domains among the imported
+// rules and against the stored ones, and reports whether there were any
+func (h *ProxyRulesHandler) checkImportConflicts(r *http.Request, mode string, rules []*importedRule) (bool, error) {
+	namespace := requestNamespace(r)
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(namespace)
+	list := func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		return resource.List(ctx, metav1.ListOptions{})
+	}
+	stored, _, err := h.domains.rules(r.Context(), namespace, list)
+	if err != nil {
+		return false, err
+	}
+
+	conflicts := false
+	imported := make(map[string]*importedRule, len(rules))
+	for _, rule := range rules {
+		name := rule.obj.GetName()
+		if _, duplicate := imported[name]; duplicate {
+			rule.fail(fmt.Sprintf("Proxy rule '%s' appears more than once in the import", name))
+			conflicts = true
+			continue
+		}
+		imported[name] = rule
+
+		if _, exists := stored[name]; exists {
+			if mode != importModeUpsert {
+				rule.fail(fmt.Sprintf("Proxy rule with name '%s' already exists", name))
+				conflicts = true
+				continue
+			}
+			// The index may be a moment old; a rule deleted since is created again
+			existing, err := resource.Get(r.Context(), name, metav1.GetOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return false, err
+			}
+			rule.existing = existing
+		}
+	}
+
+	// Stored rules that the import replaces no longer hold their domains
+	owners := make([]string, 0, len(stored))
+	for name := range stored {
+		if _, replaced := imported[name]; !replaced {
+			owners = append(owners, name)
+		}
+	}
+	sort.Strings(owners)
+
+	for i, rule := range rules {
+		if rule.result.Status == "error" {
+			continue
+		}
+		domains := getRuleDomains(rule.obj)
+
+		var dupErr *duplicateDomainError
+		for _, owner := range owners {
+			if dupErr = findDomainConflict(owner, stored[owner], domains); dupErr != nil {
+				break
+			}
+		}
+		for _, earlier := range rules[:i] {
+			if dupErr != nil {
+				break
+			}
+			if earlier.obj.GetName() != rule.obj.GetName() {
+				dupErr = findDomainConflict(earlier.obj.GetName(), getRuleDomains(earlier.obj), domains)
+			}
+		}
+		if dupErr == nil {
+			continue
+		}
+
+		if h.allowDuplicateDomains {
+			rule.warning = dupErr.Error()
+			continue
+		}
+		rule.fail(dupErr.Error())
+		conflicts = true
+	}
+	return conflicts, nil
+}
+
+// writeImportedRule creates the rule, or updates the stored rule it replaces
+func (h *ProxyRulesHandler) writeImportedRule(w http.ResponseWriter, r *http.Request, rule *importedRule) {
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(rule.obj.GetNamespace())
+
+	// Claim the domains against concurrent writers the conflict check could not see
+	excludeName := ""
+	if rule.existing != nil {
+		excludeName = rule.obj.GetName()
+	}
+	release, err := h.checkDuplicateDomain(r.Context(), rule.obj, excludeName)
+	// A duplicate the conflict check already warned about is not warned about again
+	repeated := err != nil && h.allowDuplicateDomains && err.Error() == rule.warning
+	if err != nil && !repeated && !h.allowDuplicate(w, err) {
+		rule.fail(err.Error())
+		return
+	}
+
+	if rule.existing == nil {
+		result, err := resource.Create(r.Context(), rule.obj, metav1.CreateOptions{})
+		release(err == nil)
+		if err != nil {
+			rule.fail(fmt.Sprintf("Error creating proxyrule: %v", err))
+			return
+		}
+		rule.result.Status = "created"
+		h.recorder.Event(result, events.EventTypeNormal, events.ReasonCreated, fmt.Sprintf("Proxy rule %s created by import", result.GetName()))
+		h.auditMutation(r, audit.OperationCreate, result.GetName(), nil, result)
+		return
+	}
+
+	updated := rule.existing.DeepCopy()
+	updated.Object["spec"] = rule.obj.Object["spec"]
+	updated.SetLabels(rule.obj.GetLabels())
+	updated.SetAnnotations(rule.obj.GetAnnotations())
+	recordHistory(rule.existing, updated, audit.OperationUpdate, audit.SubjectFromContext(r.Context()))
+	result, err := resource.Update(r.Context(), updated, metav1.UpdateOptions{})
+	release(err == nil)
+	if err != nil {
+		rule.fail(fmt.Sprintf("Error updating proxyrule: %v", err))
+		return
+	}
+	rule.result.Status = "updated"
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("Proxy rule %s updated by import", result.GetName()))
+	h.auditMutation(r, audit.OperationUpdate, result.GetName(), rule.existing, result)
+}
+
+// fail marks the rule as not importable
+func (rule *importedRule) fail(message string) {
+	rule.result.Status = "error"
+	rule.result.Error = message
+}
+
+// rejectImport reports the results of an import that wrote nothing: failed
+// rules with their error, all others as skipped
+func rejectImport(response ImportResponse, rules []*importedRule) ImportResponse {
+	for _, rule := range rules {
+		if rule.result.Status != "error" {
+			rule.result = ImportResult{Name: rule.result.Name, Status: "skipped"}
+		}
+		response.Results = append(response.Results, rule.result)
+	}
+	return response
+}
@@ -5,13 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 )
 
+// ingressGVR is the GroupVersionResource of networking.k8s.io/v1 Ingresses
+var ingressGVR = schema.GroupVersionResource{
+	Group:    "networking.k8s.io",
+	Version:  "v1",
+	Resource: "ingresses",
+}
+
 type IngressHandler struct {
 	dynamicClient dynamic.Interface
 }
@@ -23,11 +33,7 @@ func NewIngressHandler(client dynamic.Interface) *IngressHandler {
 }
 
 func (h *IngressHandler) getIngressGVR() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "networking.k8s.io",
-		Version:  "v1",
-		Resource: "ingresses",
-	}
+	return ingressGVR
 }
 
 // GetIngresses returns all ingresses from all namespaces, excluding those that belong to proxy rules
@@ -38,7 +44,7 @@ func (h *IngressHandler) GetIngresses(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get all ingresses from all namespaces
-	list, err := h.dynamicClient.Resource(h.getIngressGVR()).Namespace("").List(context.Background(), metav1.ListOptions{})
+	list, err := h.dynamicClient.Resource(h.getIngressGVR()).Namespace("").List(r.Context(), metav1.ListOptions{})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching ingresses: %v", err), http.StatusInternalServerError)
 		return
@@ -73,3 +79,121 @@ func (h *IngressHandler) belongsToProxyRule(ingress unstructured.Unstructured) b
 	namespace := ingress.GetNamespace()
 	return namespace == "proxy-rules"
 }
+
+// isIngressForRule checks if an ingress was generated for the named proxy rule in
+// namespace, either through an owner reference or by sharing the rule's name
+func isIngressForRule(ingress unstructured.Unstructured, namespace, ruleName string) bool {
+	if ingress.GetNamespace() != namespace {
+		return false
+	}
+	return isOwnedByRule(ingress, ruleName) || ingress.GetName() == ruleName
+}
+
+// ProxyRuleOwnerReference returns the owner reference that an ingress generated
+// for the proxy rule with name and uid should carry. The rule is the managing
+// controller, so Kubernetes garbage collection deletes the ingress together
+// with the rule, and a foreground delete of the rule waits for it.
+func ProxyRuleOwnerReference(name string, uid types.UID) metav1.OwnerReference {
+	isController := true
+	return metav1.OwnerReference{
+		APIVersion:         ProxyRulesGVR.GroupVersion().String(),
+		Kind:               "Proxyrule",
+		Name:               name,
+		UID:                uid,
+		Controller:         &isController,
+		BlockOwnerDeletion: &isController,
+	}
+}
+
+// isOwnedByRule checks if an ingress names the proxy rule as an owner
+func isOwnedByRule(ingress unstructured.Unstructured, ruleName string) bool {
+	for _, ref := range ingress.GetOwnerReferences() {
+		if ref.Kind == "Proxyrule" && ref.Name == ruleName {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteRuleIngresses deletes the ingresses generated for the named proxy rule in
+// namespace, collecting failures instead of stopping at the first one. With
+// dryRun set, the ingresses that would be deleted are reported but kept.
+func (h *ProxyRulesHandler) deleteRuleIngresses(ctx context.Context, namespace, ruleName string, dryRun []string) CascadeDeleteResult {
+	result := CascadeDeleteResult{
+		Name:             ruleName,
+		DeletedIngresses: []string{},
+	}
+
+	list, err := h.dynamicClient.Resource(ingressGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("error listing ingresses: %v", err))
+		return result
+	}
+
+	for _, ingress := range list.Items {
+		if !isIngressForRule(ingress, namespace, ruleName) {
+			continue
+		}
+		if err := h.dynamicClient.Resource(ingressGVR).Namespace(namespace).Delete(ctx, ingress.GetName(), metav1.DeleteOptions{DryRun: dryRun}); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("error deleting ingress '%s': %v", ingress.GetName(), err))
+			continue
+		}
+		result.DeletedIngresses = append(result.DeletedIngresses, ingress.GetName())
+	}
+
+	return result
+}
+
+// GetProxyRuleIngress returns the ingress generated for a proxy rule, preferring
+// one that names the rule as its owner over one that merely shares its name.
+// Responds 404 if the rule does not exist or has no ingress yet.
+func (h *ProxyRulesHandler) GetProxyRuleIngress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}/ingress
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}/ingress", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+	namespace := requestNamespace(r)
+
+	if _, err := h.getRule(r.Context(), namespace, name); err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), status)
+		return
+	}
+
+	list, err := h.dynamicClient.Resource(ingressGVR).Namespace(namespace).List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching ingresses: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var found *unstructured.Unstructured
+	for i := range list.Items {
+		ingress := &list.Items[i]
+		if !isIngressForRule(*ingress, namespace, name) {
+			continue
+		}
+		if found == nil || isOwnedByRule(*ingress, name) {
+			found = ingress
+		}
+	}
+	if found == nil {
+		http.Error(w, fmt.Sprintf("No ingress has been generated for proxy rule '%s' yet", name), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(found); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// WithDestinationLoopDetection also rejects rules whose destination is routed by
+// another rule's domain. Rules pointing at their own domain are always rejected;
+// this check needs the namespace's rules, which come from the duplicate domain index.
+func WithDestinationLoopDetection(enabled bool) Option {
+	return func(h *ProxyRulesHandler) {
+		h.detectDestinationLoops = enabled
+	}
+}
+
+// rejectDestinationLoops responds 400 and returns true if a destination of obj
+// is routed by the domain of another rule, when loop detection is enabled.
+// excludeName is the rule being updated, whose stored domains are replaced.
+func (h *ProxyRulesHandler) rejectDestinationLoops(w http.ResponseWriter, r *http.Request, obj *unstructured.Unstructured, excludeName string) bool {
+	if !h.detectDestinationLoops {
+		return false
+	}
+
+	loopErrs, err := h.findDestinationLoops(r.Context(), obj, excludeName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error checking for destination loops: %v", err), http.StatusInternalServerError)
+		return true
+	}
+	if len(loopErrs) == 0 {
+		return false
+	}
+	h.recorder.Event(obj, events.EventTypeWarning, events.ReasonValidationFailed, loopErrs.Error())
+	validation.HandleValidationError(w, r, loopErrs)
+	return true
+}
+
+// findDestinationLoops returns an error for each destination of obj that the
+// domain of another rule in its namespace routes
+func (h *ProxyRulesHandler) findDestinationLoops(ctx context.Context, obj *unstructured.Unstructured, excludeName string) (validation.ValidationErrors, error) {
+	rules, err := h.storedRuleDomains(ctx, obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	return destinationLoops(obj, rules, excludeName), nil
+}
+
+// storedRuleDomains returns the domains of each stored rule in namespace, by name
+func (h *ProxyRulesHandler) storedRuleDomains(ctx context.Context, namespace string) (map[string][]string, error) {
+	list := func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		return h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	}
+	rules, _, err := h.domains.rules(ctx, namespace, list)
+	return rules, err
+}
+
+// destinationLoops returns an error for each destination of obj that a domain
+// in rules routes, ignoring obj itself and excludeName
+func destinationLoops(obj *unstructured.Unstructured, rules map[string][]string, excludeName string) validation.ValidationErrors {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		if name != excludeName && name != obj.GetName() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var loopErrs validation.ValidationErrors
+	check := func(field, destination string) {
+		for _, name := range names {
+			for _, domain := range rules[name] {
+				if validation.DestinationLoopsTo(destination, domain) {
+					loopErrs = append(loopErrs, validation.ValidationError{
+						Field:   field,
+						Message: fmt.Sprintf("destination '%s' is routed by domain '%s' of rule '%s'; the proxy would forward requests back to itself", destination, domain, name),
+					})
+					return
+				}
+			}
+		}
+	}
+
+	if destination, found, err := unstructured.NestedString(obj.Object, "spec", "destination"); err == nil && found && destination != "" {
+		check("spec.destination", destination)
+	}
+	if destinations, found, err := unstructured.NestedStringSlice(obj.Object, "spec", "destinations"); err == nil && found {
+		for i, destination := range destinations {
+			check(fmt.Sprintf("spec.destinations[%d]", i), destination)
+		}
+	}
+	return loopErrs
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+)
+
+type namespaceKey struct{}
+
+type basePathKey struct{}
+
+// WithNamespace returns a context addressing proxy rules in namespace instead
+// of the default proxy-rules namespace
+func WithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// requestNamespace returns the namespace a request operates on
+func requestNamespace(r *http.Request) string {
+	if namespace, ok := r.Context().Value(namespaceKey{}).(string); ok && namespace != "" {
+		return namespace
+	}
+	return DefaultNamespace
+}
+
+// WithBasePath returns a context recording the path prefix that was stripped
+// from a request before routing, so that links in responses include it again
+func WithBasePath(ctx context.Context, basePath string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, basePath)
+}
+
+// ruleURL returns the API path of the named rule in the form the request used,
+// so namespaced requests get namespaced links back
+func ruleURL(r *http.Request, name string) string {
+	basePath, _ := r.Context().Value(basePathKey{}).(string)
+	if namespace, ok := r.Context().Value(namespaceKey{}).(string); ok && namespace != "" {
+		return basePath + "/api/namespaces/" + namespace + "/proxyrules/" + name
+	}
+	return basePath + "/api/proxyrules/" + name
+}
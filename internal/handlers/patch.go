@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// mergePatchContentType selects RFC 7386 JSON merge patch semantics
+	mergePatchContentType = "application/merge-patch+json"
+	// jsonPatchContentType selects RFC 6902 JSON patch semantics
+	jsonPatchContentType = "application/json-patch+json"
+)
+
+// PatchProxyRule applies a JSON merge patch or JSON patch (chosen by Content-Type)
+// to a rule. The patched rule goes through the same normalization, validation and
+// duplicate-domain checks as a full update. Patches that change the rule's name,
+// namespace, apiVersion or kind are rejected with 422.
+func (h *ProxyRulesHandler) PatchProxyRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	// Extract rule name from path
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != mergePatchContentType && contentType != jsonPatchContentType {
+		http.Error(w, fmt.Sprintf("Content-Type must be '%s' or '%s'", mergePatchContentType, jsonPatchContentType), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	// Read request body before fetching, so oversized bodies fail without a round trip
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	existing, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching existing proxyrule: %v", err), http.StatusNotFound)
+		return
+	}
+
+	original, err := json.Marshal(existing.Object)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding existing proxyrule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var patchedJSON []byte
+	if contentType == jsonPatchContentType {
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Error parsing JSON patch: %v", err), http.StatusBadRequest)
+			return
+		}
+		if patchedJSON, err = patch.Apply(original); err != nil {
+			http.Error(w, fmt.Sprintf("Error applying JSON patch: %v", err), http.StatusUnprocessableEntity)
+			return
+		}
+	} else {
+		if patchedJSON, err = jsonpatch.MergePatch(original, body); err != nil {
+			http.Error(w, fmt.Sprintf("Error applying merge patch: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	patched := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patchedJSON, &patched.Object); err != nil {
+		http.Error(w, fmt.Sprintf("Error parsing patched proxyrule: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if field := changedIdentityField(existing, patched); field != "" {
+		http.Error(w, fmt.Sprintf("Patch must not change %s", field), http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Write against the version the patch was applied to, or the one named by If-Match
+	patched.SetResourceVersion(existing.GetResourceVersion())
+	if rv := ifMatchResourceVersion(r); rv != "" {
+		patched.SetResourceVersion(rv)
+	}
+
+	// Store domains and destinations in canonical form
+	validation.NormalizeProxyRule(patched)
+	logParsedRule(audit.OperationPatch, patched)
+
+	// Validate patched ProxyRule
+	if validationErrs := validation.ValidateProxyRuleUpdate(patched, h.validationOptions); len(validationErrs) > 0 {
+		h.recorder.Event(patched, events.EventTypeWarning, events.ReasonValidationFailed, validationErrs.Error())
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+	h.addValidationWarnings(w, patched)
+
+	if h.rejectDestinationLoops(w, r, patched, name) {
+		return
+	}
+	h.addSharedDestinationWarnings(w, r, patched, name)
+
+	// Check for duplicate domain (excluding the current rule)
+	release, err := h.checkDuplicateDomain(r.Context(), patched, name)
+	if err != nil && !h.allowDuplicate(w, err) {
+		writeDomainCheckError(w, err)
+		return
+	}
+
+	recordHistory(existing, patched, audit.OperationPatch, audit.SubjectFromContext(r.Context()))
+
+	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Update(r.Context(), patched, metav1.UpdateOptions{})
+	release(err == nil)
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			if ifMatchResourceVersion(r) != "" {
+				http.Error(w, fmt.Sprintf("Proxy rule '%s' was modified since it was read (If-Match precondition failed)", name), http.StatusPreconditionFailed)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Proxy rule '%s' was modified while the patch was applied, please retry", name), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error updating proxyrule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("Proxy rule %s patched", result.GetName()))
+	h.auditMutation(r, audit.OperationPatch, result.GetName(), existing, result)
+
+	setETag(w, result)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
+
+// changedIdentityField returns the first identifying field that differs between
+// the original and patched rule, or "" if none does
+func changedIdentityField(original, patched *unstructured.Unstructured) string {
+	switch {
+	case patched.GetName() != original.GetName():
+		return "metadata.name"
+	case patched.GetNamespace() != original.GetNamespace():
+		return "metadata.namespace"
+	case patched.GetAPIVersion() != original.GetAPIVersion():
+		return "apiVersion"
+	case patched.GetKind() != original.GetKind():
+		return "kind"
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// DefaultMaxConcurrentProbes is the server-wide limit on simultaneous probe connections
+	DefaultMaxConcurrentProbes = 64
+	// probeTimeout bounds a whole probe request, including waiting for a free slot
+	probeTimeout = 10 * time.Second
+	// probeDialTimeout bounds a single connection attempt
+	probeDialTimeout = 3 * time.Second
+)
+
+// ProbeResult is the outcome of a connection attempt to one destination
+type ProbeResult struct {
+	Destination string `json:"destination"`
+	Address     string `json:"address"`
+	Reachable   bool   `json:"reachable"`
+	LatencyMs   int64  `json:"latencyMs"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ProbeResponse reports the reachability of all destinations of a rule
+type ProbeResponse struct {
+	Name    string        `json:"name"`
+	Results []ProbeResult `json:"results"`
+}
+
+// probeLimiter is a semaphore bounding outbound probe connections across all requests
+type probeLimiter struct {
+	slots chan struct{}
+}
+
+func newProbeLimiter(max int) *probeLimiter {
+	if max < 1 {
+		max = DefaultMaxConcurrentProbes
+	}
+	return &probeLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits for a free slot until ctx is done
+func (l *probeLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *probeLimiter) release() {
+	<-l.slots
+}
+
+// ProbeProxyRule opens a TCP connection to each destination of a rule and reports
+// which ones are reachable. Connections count against the server-wide probe limit;
+// if no slot frees up before the request times out, 503 is returned.
+func (h *ProxyRulesHandler) ProbeProxyRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}/probe
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}/probe", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	rule, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), probeTimeout)
+	defer cancel()
+
+	destinations := getRuleDestinations(rule)
+	port := probePort(rule)
+	results := make([]ProbeResult, len(destinations))
+	saturated := make([]bool, len(destinations))
+
+	var wg sync.WaitGroup
+	for i, destination := range destinations {
+		wg.Add(1)
+		go func(i int, destination string) {
+			defer wg.Done()
+
+			if err := h.probeLimiter.acquire(ctx); err != nil {
+				saturated[i] = true
+				return
+			}
+			defer h.probeLimiter.release()
+
+			results[i] = h.probeDestination(ctx, destination, port)
+		}(i, destination)
+	}
+	wg.Wait()
+
+	for _, s := range saturated {
+		if s {
+			http.Error(w, "Probe capacity exhausted, try again later", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, ProbeResponse{Name: name, Results: results})
+}
+
+// probeDestination dials destination:port once and records the outcome
+func (h *ProxyRulesHandler) probeDestination(ctx context.Context, destination string, port int) ProbeResult {
+	address := net.JoinHostPort(destination, strconv.Itoa(port))
+	result := ProbeResult{Destination: destination, Address: address}
+
+	dialCtx, cancel := context.WithTimeout(ctx, probeDialTimeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := h.dialContext(dialCtx, "tcp", address)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	conn.Close()
+
+	result.Reachable = true
+	return result
+}
+
+// probePort returns the port to probe: spec.port, or the default port of the destination scheme
+func probePort(rule *unstructured.Unstructured) int {
+	resolved := resolveProxyRule(rule)
+	if port, found, err := unstructured.NestedInt64(resolved.Object, "spec", "port"); err == nil && found && port > 0 {
+		return int(port)
+	}
+	if port, found, err := unstructured.NestedFloat64(resolved.Object, "spec", "port"); err == nil && found && port > 0 {
+		return int(port)
+	}
+	return validation.DefaultHTTPPort
+}
@@ -3,12 +3,21 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -16,46 +25,225 @@ import (
 )
 
 const (
-	proxyRulesNamespace = "proxy-rules"
+	// DefaultNamespace holds the proxy rules of requests that name no namespace
+	DefaultNamespace = "proxy-rules"
+
+	// maxDomainCheckAttempts bounds how often a duplicate domain check is
+	// repeated because concurrent writes kept changing the rules under it
+	maxDomainCheckAttempts = 3
 )
 
 type ProxyRulesHandler struct {
-	dynamicClient dynamic.Interface
+	dynamicClient          dynamic.Interface
+	recorder               events.Recorder
+	allowDuplicateDomains  bool
+	maxListResponseBytes   int64
+	maxBodyBytes           int64
+	probeLimiter           *probeLimiter
+	dialContext            func(ctx context.Context, network, address string) (net.Conn, error)
+	auditLogger            audit.Logger
+	notFoundSuggestions    int
+	readCache              ReadCache
+	maxCacheStaleness      time.Duration
+	domains                *domainIndex
+	readOnly               bool
+	deletions              *deletionClock
+	detectDestinationLoops bool
+	warnSharedDestinations bool
+	domainLock             string
+	maxRules               int
+	defaultDestinationPort int64
+	validationOptions      validation.Options
 }
 
-func NewProxyRulesHandler(client dynamic.Interface) *ProxyRulesHandler {
-	return &ProxyRulesHandler{
-		dynamicClient: client,
+// Option configures optional behaviour of a ProxyRulesHandler
+type Option func(*ProxyRulesHandler)
+
+// WithEventRecorder replaces the default cluster event recorder
+func WithEventRecorder(recorder events.Recorder) Option {
+	return func(h *ProxyRulesHandler) {
+		h.recorder = recorder
 	}
 }
 
-func (h *ProxyRulesHandler) getGVR() schema.GroupVersionResource {
-	return schema.GroupVersionResource{
-		Group:    "bausteln.io",
-		Version:  "v1",
-		Resource: "proxyrules",
+// WithAllowDuplicateDomains makes duplicate domains a warning instead of a conflict
+func WithAllowDuplicateDomains(allow bool) Option {
+	return func(h *ProxyRulesHandler) {
+		h.allowDuplicateDomains = allow
+	}
+}
+
+// WithMaxListResponseBytes rejects list responses larger than max bytes; zero disables the cap
+func WithMaxListResponseBytes(max int64) Option {
+	return func(h *ProxyRulesHandler) {
+		h.maxListResponseBytes = max
+	}
+}
+
+// WithMaxBodyBytes limits the size of request bodies; values below 1 keep the default
+func WithMaxBodyBytes(max int64) Option {
+	return func(h *ProxyRulesHandler) {
+		if max > 0 {
+			h.maxBodyBytes = max
+		}
+	}
+}
+
+// WithMaxConcurrentProbes limits the number of simultaneous destination probe connections server-wide
+func WithMaxConcurrentProbes(max int) Option {
+	return func(h *ProxyRulesHandler) {
+		h.probeLimiter = newProbeLimiter(max)
+	}
+}
+
+// WithValidationOptions sets the server-wide settings rules are validated with
+func WithValidationOptions(opts validation.Options) Option {
+	return func(h *ProxyRulesHandler) {
+		h.validationOptions = opts
 	}
 }
 
+func NewProxyRulesHandler(client dynamic.Interface, opts ...Option) *ProxyRulesHandler {
+	h := &ProxyRulesHandler{
+		dynamicClient: client,
+		recorder:      events.NewDynamicRecorder(client),
+		probeLimiter:  newProbeLimiter(DefaultMaxConcurrentProbes),
+		dialContext:   (&net.Dialer{}).DialContext,
+		auditLogger:   audit.Discard,
+		maxBodyBytes:  validation.DefaultMaxRequestBodySize,
+		domains:       newDomainIndex(),
+		deletions:     newDeletionClock(),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// ProxyRulesGVR identifies the proxy rule custom resource
+var ProxyRulesGVR = schema.GroupVersionResource{
+	Group:    "bausteln.io",
+	Version:  "v1",
+	Resource: "proxyrules",
+}
+
+func (h *ProxyRulesHandler) getGVR() schema.GroupVersionResource {
+	return ProxyRulesGVR
+}
+
+// Namespace returns the default namespace the handler manages proxy rules in
+func (h *ProxyRulesHandler) Namespace() string {
+	return DefaultNamespace
+}
+
+// CheckConnectivity verifies that proxy rules can be listed from the API server
+func (h *ProxyRulesHandler) CheckConnectivity(ctx context.Context) error {
+	_, err := h.dynamicClient.Resource(h.getGVR()).Namespace(DefaultNamespace).List(ctx, metav1.ListOptions{Limit: 1})
+	return err
+}
+
 func (h *ProxyRulesHandler) GetProxyRules(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	// Validate the optional label selector before passing it to the API server
+	labelSelector, err := parseLabelSelector(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid labelSelector: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	fieldFilters, err := parseFieldFilters(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid field query: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	view, err := parseView(r, viewSummary)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid view: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	enabledFilter := r.URL.Query().Get("enabled")
+	var enabled bool
+	if enabledFilter != "" {
+		enabled, err = strconv.ParseBool(enabledFilter)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid enabled value '%s': must be true or false", enabledFilter), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if !h.checkCacheFresh(w) {
+		return
+	}
+
 	// Get proxyrules from proxy-rules namespace
-	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).List(context.Background(), metav1.ListOptions{})
+	list, err := h.listRules(r.Context(), requestNamespace(r), labelSelector)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Return as JSON
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(list); err != nil {
+	// Optionally keep only rules pointing at a given destination
+	if destination := r.URL.Query().Get("destination"); destination != "" {
+		list.Items = filterByDestination(list.Items, destination)
+	}
+
+	// Optionally keep only enabled or disabled rules
+	if enabledFilter != "" {
+		list.Items = filterByEnabled(list.Items, enabled)
+	}
+
+	// Optionally keep only rules with the queried spec field values
+	if len(fieldFilters) > 0 {
+		list.Items = filterByFields(list.Items, fieldFilters)
+	}
+
+	// The API server and the read cache already order by name, but sort again so
+	// identical lists always serialize to identical bytes and the ETag is stable
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+
+	// Serialize up front so oversized lists can be rejected before anything is written
+	var payload interface{} = list
+	if view == viewSummary {
+		payload = newSummaryList(list.Items)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
 		return
 	}
+	if h.maxListResponseBytes > 0 && int64(len(body)) > h.maxListResponseBytes {
+		http.Error(w, fmt.Sprintf("List response of %d bytes exceeds the maximum of %d bytes; use the paginated /api/proxyrules/export endpoint instead", len(body), h.maxListResponseBytes), http.StatusBadRequest)
+		return
+	}
+
+	// Rules carry no deletion time, so a deletion through this handler counts
+	// as a modification of the list
+	lastModified := h.deletions.last(requestNamespace(r))
+	for i := range list.Items {
+		if modified := ruleLastModified(&list.Items[i]); modified.After(lastModified) {
+			lastModified = modified
+		}
+	}
+
+	etag := weakETag(body)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if ifNoneMatch(r, etag) || notModifiedSince(r, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Return as JSON
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
 }
 
 func (h *ProxyRulesHandler) GetProxyRule(w http.ResponseWriter, r *http.Request) {
@@ -77,13 +265,44 @@ func (h *ProxyRulesHandler) GetProxyRule(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	view, err := parseView(r, viewSpec)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid view: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if !h.checkCacheFresh(w) {
+		return
+	}
+
 	// Get specific proxyrule from proxy-rules namespace
-	rule, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Get(context.Background(), name, metav1.GetOptions{})
+	rule, err := h.getRule(r.Context(), requestNamespace(r), name)
 	if err != nil {
+		if h.notFoundSuggestions > 0 && apierrors.IsNotFound(err) {
+			h.writeNotFoundWithSuggestions(w, r, name)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), http.StatusNotFound)
 		return
 	}
 
+	setETag(w, rule)
+
+	// Optionally leave out the Kubernetes bookkeeping
+	if view == viewSpec {
+		writeJSON(w, http.StatusOK, newSpecView(rule))
+		return
+	}
+
+	// Optionally show the stored object next to its resolved form
+	if debug, _ := strconv.ParseBool(r.URL.Query().Get("debug")); debug {
+		writeJSON(w, http.StatusOK, DebugView{
+			Raw:      rule.Object,
+			Resolved: resolveProxyRule(rule).Object,
+		})
+		return
+	}
+
 	// Return as JSON
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(rule); err != nil {
@@ -92,42 +311,144 @@ func (h *ProxyRulesHandler) GetProxyRule(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// HeadProxyRule reports whether a rule exists without sending it: 200 with the
+// rule's ETag if it does, 404 if not
+func (h *ProxyRulesHandler) HeadProxyRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[2] == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if !h.checkCacheFresh(w) {
+		return
+	}
+
+	rule, err := h.getRule(r.Context(), requestNamespace(r), parts[2])
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, rule)
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetProxyRuleByUID returns the rule whose metadata.uid matches the path.
+// Unlike names, UIDs are never reused after a rule is deleted.
+func (h *ProxyRulesHandler) GetProxyRuleByUID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Extract UID from path: /api/proxyrules/by-uid/{uid}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[3] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/by-uid/{uid}", http.StatusBadRequest)
+		return
+	}
+	uid := parts[3]
+
+	// The API server cannot select on UID, so list and search
+	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).List(r.Context(), metav1.ListOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching proxyrules: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	for i := range list.Items {
+		rule := &list.Items[i]
+		if string(rule.GetUID()) != uid {
+			continue
+		}
+
+		setETag(w, rule)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rule); err != nil {
+			http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Error(w, fmt.Sprintf("Proxy rule with uid '%s' not found", uid), http.StatusNotFound)
+}
+
+// withCreateMetadata restricts the metadata of a create body to the fields a
+// client may choose, the same ones an export keeps, so that labels and
+// annotations are carried into the stored rule while fields the API server
+// populates, such as uid or resourceVersion, cannot fail the create
+func withCreateMetadata(obj map[string]interface{}) map[string]interface{} {
+	existing, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return obj
+	}
+	metadata := make(map[string]interface{}, len(exportedMetadata))
+	for _, field := range exportedMetadata {
+		if value, found := existing[field]; found {
+			metadata[field] = value
+		}
+	}
+	obj["metadata"] = metadata
+	return obj
+}
+
 func (h *ProxyRulesHandler) CreateProxyRule(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	if !h.checkWritable(w) {
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dryRun value: %v", err), http.StatusBadRequest)
+		return
+	}
+
 	// Validate request (content-type, body size)
-	if err := validation.ValidateJSONRequest(w, r); err != nil {
-		validation.HandleValidationError(w, err)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
 		return
 	}
 
 	// Read request body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		validation.HandleValidationError(w, err)
+		validation.HandleValidationError(w, r, err)
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate request body
-	if err := validation.ValidateRequestBody(body); err != nil {
-		validation.HandleValidationError(w, err)
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
 		return
 	}
 
-	// Parse JSON into unstructured object
-	var obj map[string]interface{}
-	if err := json.Unmarshal(body, &obj); err != nil {
-		http.Error(w, fmt.Sprintf("Error parsing JSON: %v", err), http.StatusBadRequest)
+	// Parse JSON or YAML into unstructured object
+	obj, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Create unstructured object
+	// Create unstructured object, keeping the labels and annotations the caller set
 	unstructuredObj := &unstructured.Unstructured{
-		Object: obj,
+		Object: withCreateMetadata(obj),
 	}
 
 	// Set apiVersion and kind if not provided
@@ -138,39 +459,71 @@ func (h *ProxyRulesHandler) CreateProxyRule(w http.ResponseWriter, r *http.Reque
 		unstructuredObj.SetKind("Proxyrule")
 	}
 
-	// Set namespace if not provided
+	// Set namespace if not provided; a different one than addressed is an error
+	namespace := requestNamespace(r)
 	if unstructuredObj.GetNamespace() == "" {
-		unstructuredObj.SetNamespace(proxyRulesNamespace)
+		unstructuredObj.SetNamespace(namespace)
+	} else if unstructuredObj.GetNamespace() != namespace {
+		http.Error(w, fmt.Sprintf("metadata.namespace '%s' does not match the request namespace '%s'", unstructuredObj.GetNamespace(), namespace), http.StatusBadRequest)
+		return
 	}
 
+	// Store domains and destinations in canonical form
+	validation.NormalizeProxyRule(unstructuredObj)
+	h.applyDefaultDestinationPort(w, unstructuredObj)
+	logParsedRule(audit.OperationCreate, unstructuredObj)
+
 	// Validate ProxyRule
-	if validationErrs := validation.ValidateProxyRuleCreate(unstructuredObj); len(validationErrs) > 0 {
-		validation.HandleValidationError(w, validationErrs)
+	if validationErrs := validation.ValidateProxyRuleCreate(unstructuredObj, h.validationOptions); len(validationErrs) > 0 {
+		h.recorder.Event(unstructuredObj, events.EventTypeWarning, events.ReasonValidationFailed, validationErrs.Error())
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+	h.addValidationWarnings(w, unstructuredObj)
+
+	if h.rejectDestinationLoops(w, r, unstructuredObj, "") {
 		return
 	}
+	h.addSharedDestinationWarnings(w, r, unstructuredObj, "")
 
 	// Check for duplicate name
-	existingByName, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Get(context.Background(), unstructuredObj.GetName(), metav1.GetOptions{})
+	existingByName, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Get(r.Context(), unstructuredObj.GetName(), metav1.GetOptions{})
 	if err == nil && existingByName != nil {
-		http.Error(w, fmt.Sprintf("Proxy rule with name '%s' already exists", unstructuredObj.GetName()), http.StatusConflict)
+		writeDuplicateName(w, unstructuredObj.GetName())
+		return
+	}
+
+	if !h.checkRuleQuota(w, r, requestNamespace(r), 1) {
 		return
 	}
 
 	// Check for duplicate domain
-	if err := h.checkDuplicateDomain(unstructuredObj, ""); err != nil {
-		http.Error(w, err.Error(), http.StatusConflict)
+	release, err := h.checkDuplicateDomain(r.Context(), unstructuredObj, "")
+	if err != nil && !h.allowDuplicate(w, err) {
+		writeDomainCheckError(w, err)
 		return
 	}
 
 	// Create the resource
-	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Create(context.Background(), unstructuredObj, metav1.CreateOptions{})
+	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Create(r.Context(), unstructuredObj, metav1.CreateOptions{DryRun: dryRun})
+	release(err == nil && dryRun == nil)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error creating proxyrule: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// A dry run returns the rule that would have been created, without side effects
+	if dryRun != nil {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonCreated, fmt.Sprintf("Proxy rule %s created", result.GetName()))
+	h.auditMutation(r, audit.OperationCreate, result.GetName(), nil, result)
+
 	// Return created resource
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", ruleURL(r, result.GetName()))
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
@@ -178,12 +531,34 @@ func (h *ProxyRulesHandler) CreateProxyRule(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// storedMetadata returns the metadata of a stored rule after checking that it,
+// its labels and its annotations are objects. The API server guarantees this,
+// but an object that does not have this shape would otherwise panic the merge.
+func storedMetadata(obj *unstructured.Unstructured) (map[string]interface{}, error) {
+	metadata, ok := obj.Object["metadata"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("metadata is not an object")
+	}
+	for _, field := range []string{"labels", "annotations"} {
+		if value, found := metadata[field]; found && value != nil {
+			if _, ok := value.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("metadata.%s is not an object", field)
+			}
+		}
+	}
+	return metadata, nil
+}
+
 func (h *ProxyRulesHandler) UpdateProxyRule(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	if !h.checkWritable(w) {
+		return
+	}
+
 	// Extract rule name from path
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(parts) != 3 {
@@ -197,39 +572,57 @@ func (h *ProxyRulesHandler) UpdateProxyRule(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Validate request (content-type, body size)
-	if err := validation.ValidateJSONRequest(w, r); err != nil {
-		validation.HandleValidationError(w, err)
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dryRun value: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Fetch the existing resource to get resourceVersion
-	existing, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Get(context.Background(), name, metav1.GetOptions{})
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching existing proxyrule: %v", err), http.StatusNotFound)
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
 		return
 	}
 
-	// Read request body
+	// Read request body before fetching, so oversized bodies fail without a round trip
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		validation.HandleValidationError(w, err)
+		validation.HandleValidationError(w, r, err)
 		return
 	}
 	defer r.Body.Close()
 
 	// Validate request body
-	if err := validation.ValidateRequestBody(body); err != nil {
-		validation.HandleValidationError(w, err)
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	// Parse JSON or YAML into map
+	updates, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
 		return
 	}
 
-	// Parse JSON into map
-	var updates map[string]interface{}
-	if err := json.Unmarshal(body, &updates); err != nil {
-		http.Error(w, fmt.Sprintf("Error parsing JSON: %v", err), http.StatusBadRequest)
+	// Fetch the existing resource to get resourceVersion
+	existing, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error fetching existing proxyrule: %v", err), http.StatusNotFound)
 		return
 	}
+	// The request's labels and annotations are merged into the stored metadata
+	existingMetadata, err := storedMetadata(existing)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Stored proxyrule '%s' cannot be updated: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+	before := existing.DeepCopy()
+
+	// Honor If-Match: the API server rejects the update if the object changed since
+	if rv := ifMatchResourceVersion(r); rv != "" {
+		existing.SetResourceVersion(rv)
+	}
 
 	// Update the spec field from the request
 	if spec, ok := updates["spec"]; ok {
@@ -238,8 +631,6 @@ func (h *ProxyRulesHandler) UpdateProxyRule(w http.ResponseWriter, r *http.Reque
 
 	// Update metadata labels and annotations if provided
 	if metadata, ok := updates["metadata"].(map[string]interface{}); ok {
-		existingMetadata := existing.Object["metadata"].(map[string]interface{})
-
 		if labels, ok := metadata["labels"]; ok {
 			existingMetadata["labels"] = labels
 		}
@@ -248,26 +639,55 @@ func (h *ProxyRulesHandler) UpdateProxyRule(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// Store domains and destinations in canonical form
+	validation.NormalizeProxyRule(existing)
+	logParsedRule(audit.OperationUpdate, existing)
+
 	// Validate updated ProxyRule
-	if validationErrs := validation.ValidateProxyRuleUpdate(existing); len(validationErrs) > 0 {
-		validation.HandleValidationError(w, validationErrs)
+	if validationErrs := validation.ValidateProxyRuleUpdate(existing, h.validationOptions); len(validationErrs) > 0 {
+		h.recorder.Event(existing, events.EventTypeWarning, events.ReasonValidationFailed, validationErrs.Error())
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+	h.addValidationWarnings(w, existing)
+
+	if h.rejectDestinationLoops(w, r, existing, name) {
 		return
 	}
+	h.addSharedDestinationWarnings(w, r, existing, name)
 
 	// Check for duplicate domain (excluding the current rule)
-	if err := h.checkDuplicateDomain(existing, name); err != nil {
-		http.Error(w, err.Error(), http.StatusConflict)
+	release, err := h.checkDuplicateDomain(r.Context(), existing, name)
+	if err != nil && !h.allowDuplicate(w, err) {
+		writeDomainCheckError(w, err)
 		return
 	}
 
+	recordHistory(before, existing, audit.OperationUpdate, audit.SubjectFromContext(r.Context()))
+
 	// Update the resource
-	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Update(context.Background(), existing, metav1.UpdateOptions{})
+	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Update(r.Context(), existing, metav1.UpdateOptions{DryRun: dryRun})
+	release(err == nil && dryRun == nil)
 	if err != nil {
+		if apierrors.IsConflict(err) && ifMatchResourceVersion(r) != "" {
+			http.Error(w, fmt.Sprintf("Proxy rule '%s' was modified since it was read (If-Match precondition failed)", name), http.StatusPreconditionFailed)
+			return
+		}
 		http.Error(w, fmt.Sprintf("Error updating proxyrule: %v", err), http.StatusInternalServerError)
 		return
 	}
 
+	// A dry run returns the rule as it would have been stored, without side effects
+	if dryRun != nil {
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("Proxy rule %s updated", result.GetName()))
+	h.auditMutation(r, audit.OperationUpdate, result.GetName(), before, result)
+
 	// Return updated resource
+	setETag(w, result)
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(result); err != nil {
 		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
@@ -275,39 +695,189 @@ func (h *ProxyRulesHandler) UpdateProxyRule(w http.ResponseWriter, r *http.Reque
 	}
 }
 
-// checkDuplicateDomain checks if another proxy rule already uses the same domain
-// excludeName is used during updates to exclude the rule being updated from the check
-func (h *ProxyRulesHandler) checkDuplicateDomain(obj *unstructured.Unstructured, excludeName string) error {
-	// Get the domain from the spec
-	domain, found, err := unstructured.NestedString(obj.Object, "spec", "domain")
-	if err != nil || !found || domain == "" {
-		return nil // No domain to check
+// checkDuplicateDomain checks if another proxy rule in the rule's namespace already uses any of the rule's domains.
+// It gives up with ctx's error once ctx is done, e.g. when the client disconnects.
+// excludeName is used during updates to exclude the rule being updated from the check.
+// If the check passes, the domains stay claimed against concurrent writes through
+// this handler until the returned release is called with whether the write succeeded.
+func (h *ProxyRulesHandler) checkDuplicateDomain(ctx context.Context, obj *unstructured.Unstructured, excludeName string) (release func(written bool), err error) {
+	namespace := obj.GetNamespace()
+	release = func(written bool) {
+		if written {
+			h.domains.invalidate(namespace)
+		}
 	}
 
-	// List all proxy rules
-	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).List(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("error checking for duplicate domain: %v", err)
+	// Get the domains from the spec
+	domains := getRuleDomains(obj)
+	if len(domains) == 0 {
+		return release, nil // No domain to check
 	}
 
-	// Check each rule for matching domain
-	for _, item := range list.Items {
-		// Skip the rule we're updating (if any)
-		if excludeName != "" && item.GetName() == excludeName {
-			continue
+	list := func(ctx context.Context) (*unstructured.UnstructuredList, error) {
+		return h.dynamicClient.Resource(h.getGVR()).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	}
+
+	for attempt := 0; attempt < maxDomainCheckAttempts; attempt++ {
+		rules, generation, err := h.domains.rules(ctx, namespace, list)
+		if err != nil {
+			return release, fmt.Errorf("error checking for duplicate domain: %w", err)
 		}
 
-		existingDomain, found, err := unstructured.NestedString(item.Object, "spec", "domain")
-		if err != nil || !found {
-			continue
+		// Check each rule for matching domain, in name order like the API server lists them
+		names := make([]string, 0, len(rules))
+		for name := range rules {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// Skip the rule we're updating (if any)
+			if excludeName != "" && name == excludeName {
+				continue
+			}
+			if dupErr := findDomainConflict(name, rules[name], domains); dupErr != nil {
+				slog.Debug("Duplicate domain check found a conflict", "namespace", namespace, "rule", obj.GetName(), "domains", domains, "rulesChecked", len(names), "conflictingRule", name, "conflictingDomain", dupErr.ExistingDomain)
+				return release, dupErr
+			}
+		}
+
+		id, current, err := h.domains.claim(namespace, generation, obj.GetName(), excludeName, domains)
+		if err != nil {
+			slog.Debug("Duplicate domain check found a conflict with a write in progress", "namespace", namespace, "rule", obj.GetName(), "domains", domains, "error", err)
+			return release, err
+		}
+		if current {
+			slog.Debug("Duplicate domain check passed", "namespace", namespace, "rule", obj.GetName(), "domains", domains, "rulesChecked", len(names))
+			releaseClaim := func(written bool) { h.domains.release(namespace, id, written) }
+			if h.domainLock == "" || h.allowDuplicateDomains {
+				return releaseClaim, nil
+			}
+
+			// The index only covers this replica; the lock covers every writer
+			releaseLock, err := h.reserveDomains(ctx, namespace, obj.GetName(), excludeName, domains)
+			if err != nil {
+				releaseClaim(false)
+				return release, err
+			}
+			return func(written bool) {
+				releaseLock(written)
+				releaseClaim(written)
+			}, nil
 		}
+		// Another write finished since the rules were listed; check again
+	}
+
+	return release, fmt.Errorf("error checking for duplicate domain: proxy rules changed during the check, please retry")
+}
+
+// duplicateDomainError reports a domain that is already used by another rule
+type duplicateDomainError struct {
+	Domain         string
+	ExistingDomain string
+	RuleName       string
+}
+
+func (e *duplicateDomainError) Error() string {
+	if e.ExistingDomain != "" && canonicalDomain(e.ExistingDomain) != canonicalDomain(e.Domain) {
+		return fmt.Sprintf("proxy rule domain '%s' overlaps with domain '%s' (used by rule '%s')", e.Domain, e.ExistingDomain, e.RuleName)
+	}
+	return fmt.Sprintf("proxy rule with domain '%s' already exists (used by rule '%s')", e.Domain, e.RuleName)
+}
+
+// allowDuplicate reports whether err is a duplicate domain that the handler is
+// configured to tolerate. Tolerated duplicates are logged and returned to the
+// client as a Warning header.
+func (h *ProxyRulesHandler) allowDuplicate(w http.ResponseWriter, err error) bool {
+	var dupErr *duplicateDomainError
+	if !h.allowDuplicateDomains || !errors.As(err, &dupErr) {
+		return false
+	}
+
+	slog.Warn(dupErr.Error())
+	addWarning(w, dupErr.Error())
+	return true
+}
+
+// WithDefaultDestinationPort sets spec.port of created rules that omit it to
+// port. Zero leaves the port unset, so the data plane uses 80 or 443.
+func WithDefaultDestinationPort(port int) Option {
+	return func(h *ProxyRulesHandler) {
+		h.defaultDestinationPort = int64(port)
+	}
+}
 
-		if existingDomain == domain {
-			return fmt.Errorf("proxy rule with domain '%s' already exists (used by rule '%s')", domain, item.GetName())
+// applyDefaultDestinationPort sets spec.port of obj to the configured default
+// if it has none, and says so in a Warning header
+func (h *ProxyRulesHandler) applyDefaultDestinationPort(w http.ResponseWriter, obj *unstructured.Unstructured) {
+	spec, ok := obj.Object["spec"].(map[string]interface{})
+	if h.defaultDestinationPort == 0 || !ok {
+		return
+	}
+	if _, found := spec["port"]; found {
+		return
+	}
+	spec["port"] = h.defaultDestinationPort
+	addWarning(w, fmt.Sprintf("spec.port was omitted; applied the default destination port %d", h.defaultDestinationPort))
+}
+
+// addWarning adds an RFC 7234 style Warning header, the same format the
+// Kubernetes API server uses to surface non-fatal problems
+func addWarning(w http.ResponseWriter, message string) {
+	w.Header().Add("Warning", fmt.Sprintf("299 - %q", message))
+}
+
+// logParsedRule logs a rule as decoded from a request, before validation
+func logParsedRule(operation string, obj *unstructured.Unstructured) {
+	slog.Debug("Parsed proxy rule", "operation", operation, "namespace", obj.GetNamespace(), "name", obj.GetName(), "spec", obj.Object["spec"])
+}
+
+// addValidationWarnings adds a Warning header for each likely mistake in a valid rule
+func (h *ProxyRulesHandler) addValidationWarnings(w http.ResponseWriter, obj *unstructured.Unstructured) {
+	for _, warning := range validation.ProxyRuleWarnings(obj, h.validationOptions) {
+		addWarning(w, warning)
+	}
+}
+
+// getRuleDomains returns all domains of a rule, combining spec.domain and spec.domains
+func getRuleDomains(obj *unstructured.Unstructured) []string {
+	var domains []string
+
+	if domain, found, err := unstructured.NestedString(obj.Object, "spec", "domain"); err == nil && found && domain != "" {
+		domains = append(domains, domain)
+	}
+	if list, found, err := unstructured.NestedStringSlice(obj.Object, "spec", "domains"); err == nil && found {
+		for _, domain := range list {
+			if domain != "" {
+				domains = append(domains, domain)
+			}
 		}
 	}
 
-	return nil
+	return domains
+}
+
+// domainsConflict reports whether two rule domains would route the same host.
+// Domains are compared case-insensitively without trailing dots, and a wildcard
+// such as *.example.com overlaps every host one label below it (a.example.com).
+func domainsConflict(a, b string) bool {
+	a, b = canonicalDomain(a), canonicalDomain(b)
+	return a == b || wildcardMatches(a, b) || wildcardMatches(b, a)
+}
+
+// canonicalDomain lowercases a domain and strips a trailing dot
+func canonicalDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
+
+// wildcardMatches reports whether the wildcard pattern (*.example.com) matches host.
+// As with DNS and TLS wildcards, the * stands for exactly one label.
+func wildcardMatches(pattern, host string) bool {
+	suffix, ok := strings.CutPrefix(pattern, "*.")
+	if !ok || strings.HasPrefix(host, "*.") {
+		return false
+	}
+	label, rest, found := strings.Cut(host, ".")
+	return found && label != "" && rest == suffix
 }
 
 func (h *ProxyRulesHandler) DeleteProxyRule(w http.ResponseWriter, r *http.Request) {
@@ -316,6 +886,10 @@ func (h *ProxyRulesHandler) DeleteProxyRule(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if !h.checkWritable(w) {
+		return
+	}
+
 	// Extract rule name from path
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(parts) != 3 {
@@ -329,13 +903,81 @@ func (h *ProxyRulesHandler) DeleteProxyRule(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Optionally also delete the ingresses generated for the rule
+	cascade := false
+	if value := r.URL.Query().Get("cascade"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid cascade value '%s': must be true or false", value), http.StatusBadRequest)
+			return
+		}
+		cascade = parsed
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid dryRun value: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r))
+
+	// Capture the rule for the audit log; a missing rule fails in Delete below
+	before, _ := resource.Get(r.Context(), name, metav1.GetOptions{})
+
 	// Delete the resource
-	err := h.dynamicClient.Resource(h.getGVR()).Namespace(proxyRulesNamespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	err = resource.Delete(r.Context(), name, metav1.DeleteOptions{DryRun: dryRun})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error deleting proxyrule: %v", err), http.StatusNotFound)
 		return
 	}
 
-	// Return success
-	w.WriteHeader(http.StatusNoContent)
+	// A dry run returns what would have been deleted, without side effects
+	if dryRun != nil {
+		if cascade {
+			writeJSON(w, http.StatusOK, h.deleteRuleIngresses(r.Context(), requestNamespace(r), name, dryRun))
+			return
+		}
+		writeJSON(w, http.StatusOK, before)
+		return
+	}
+	h.domains.invalidate(requestNamespace(r))
+	h.deletions.record(requestNamespace(r))
+	h.releaseDomains(r.Context(), requestNamespace(r), name)
+
+	h.recorder.Event(h.newObjectReference(requestNamespace(r), name), events.EventTypeNormal, events.ReasonDeleted, fmt.Sprintf("Proxy rule %s deleted", name))
+	h.auditMutation(r, audit.OperationDelete, name, before, nil)
+
+	if !cascade {
+		// Return success
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// The rule is gone either way; report ingress failures as a partial success
+	result := h.deleteRuleIngresses(context.Background(), requestNamespace(r), name, nil)
+	status := http.StatusOK
+	if len(result.Errors) > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	writeJSON(w, status, result)
+}
+
+// CascadeDeleteResult reports what a cascading delete removed
+type CascadeDeleteResult struct {
+	Name             string   `json:"name"`
+	DeletedIngresses []string `json:"deletedIngresses"`
+	Errors           []string `json:"errors,omitempty"`
+}
+
+// newObjectReference builds a minimal ProxyRule object identifying name in namespace,
+// used to reference rules that no longer exist (e.g. in delete events)
+func (h *ProxyRulesHandler) newObjectReference(namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("bausteln.io/v1")
+	obj.SetKind("Proxyrule")
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	return obj
 }
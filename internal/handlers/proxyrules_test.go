@@ -2,12 +2,35 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/clientip"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
 )
 
 func TestProxyRulesHandler_CreateProxyRule(t *testing.T) {
@@ -44,7 +67,7 @@ func TestProxyRulesHandler_CreateProxyRule(t *testing.T) {
 					"destination": "10.0.0.50",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  "domain is required",
 		},
 		{
@@ -57,7 +80,7 @@ func TestProxyRulesHandler_CreateProxyRule(t *testing.T) {
 					"domain": "example.com",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  "destination is required",
 		},
 		{
@@ -72,7 +95,7 @@ func TestProxyRulesHandler_CreateProxyRule(t *testing.T) {
 					"port":        70000,
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  "port must be between",
 		},
 		{
@@ -86,9 +109,79 @@ func TestProxyRulesHandler_CreateProxyRule(t *testing.T) {
 					"destination": "10.300.500.400",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  "invalid",
 		},
+		{
+			name: "reserved name health-summary",
+			body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "health-summary",
+				},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.50",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "reserved",
+		},
+		{
+			name: "reserved name import",
+			body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "import",
+				},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.50",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "reserved",
+		},
+		{
+			name: "reserved name count",
+			body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "count",
+				},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.50",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "reserved",
+		},
+		{
+			name: "reserved name export",
+			body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "export",
+				},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.50",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "reserved",
+		},
+		{
+			name: "reserved name by-uid",
+			body: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "by-uid",
+				},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.50",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "reserved",
+		},
 		{
 			name: "duplicate name",
 			body: map[string]interface{}{
@@ -241,9 +334,32 @@ func TestProxyRulesHandler_UpdateProxyRule(t *testing.T) {
 					"destination": "10.0.0.50",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			expectedError:  "consecutive dots",
 		},
+		{
+			name:     "update removes all destinations",
+			ruleName: "test-rule",
+			body: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"domain": "example.com",
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "either destination or destinations is required",
+		},
+		{
+			name:     "update sets empty destinations",
+			ruleName: "test-rule",
+			body: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"domain":       "example.com",
+					"destinations": []interface{}{},
+				},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "either destination or destinations is required",
+		},
 		{
 			name:     "non-existent rule",
 			ruleName: "non-existent",
@@ -285,6 +401,134 @@ func TestProxyRulesHandler_UpdateProxyRule(t *testing.T) {
 	}
 }
 
+func TestProxyRulesHandler_UpdateProxyRuleMalformedStored(t *testing.T) {
+	spec := map[string]interface{}{"domain": "broken.example.com", "destination": "10.0.0.50", "port": int64(3000)}
+	withLabels := `{"metadata":{"labels":{"team":"web"}},"spec":{"domain":"broken.example.com","destination":"10.0.0.51","port":3000}}`
+	specOnly := `{"spec":{"domain":"broken.example.com","destination":"10.0.0.51","port":3000}}`
+
+	tests := []struct {
+		name          string
+		metadata      interface{}
+		body          string
+		expectedError string
+	}{
+		{
+			name:          "metadata is a string",
+			metadata:      "broken",
+			body:          withLabels,
+			expectedError: "metadata is not an object",
+		},
+		{
+			name:          "metadata is a list",
+			metadata:      []interface{}{"broken"},
+			body:          specOnly,
+			expectedError: "metadata is not an object",
+		},
+		{
+			name:          "labels are a list",
+			metadata:      map[string]interface{}{"name": "broken-rule", "namespace": "proxy-rules", "labels": []interface{}{"team=web"}},
+			body:          withLabels,
+			expectedError: "metadata.labels is not an object",
+		},
+		{
+			name:          "annotations are a string",
+			metadata:      map[string]interface{}{"name": "broken-rule", "namespace": "proxy-rules", "annotations": "owner=web"},
+			body:          specOnly,
+			expectedError: "metadata.annotations is not an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "bausteln.io/v1",
+				"kind":       "Proxyrule",
+				"metadata":   tt.metadata,
+				"spec":       spec,
+			}}
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedAt(testutil.ProxyRulesGVR, "proxy-rules", "broken-rule", stored)
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/broken-rule", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.UpdateProxyRule(w, req)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("expected status 500, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %s", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_UpdateProxyRuleLabels(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedLabels map[string]string
+	}{
+		{
+			name:           "labels replaced",
+			body:           `{"metadata":{"labels":{"team":"api"}},"spec":{"domain":"test.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusOK,
+			expectedLabels: map[string]string{"team": "api"},
+		},
+		{
+			name:           "labels kept without metadata",
+			body:           `{"spec":{"domain":"test.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusOK,
+			expectedLabels: map[string]string{"team": "web"},
+		},
+		{
+			name:           "labels that are not a map",
+			body:           `{"metadata":{"labels":"team=api"},"spec":{"domain":"test.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedLabels: map[string]string{"team": "web"},
+		},
+		{
+			name:           "invalid label value",
+			body:           `{"metadata":{"labels":{"team":"web team"}},"spec":{"domain":"test.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedLabels: map[string]string{"team": "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := testutil.NewProxyRule("test-rule", "test.example.com", "10.0.0.50", 3000)
+			rule.SetNamespace("proxy-rules")
+			rule.SetLabels(map[string]string{"team": "web"})
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/test-rule", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.UpdateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get rule: %v", err)
+			}
+			if !reflect.DeepEqual(stored.GetLabels(), tt.expectedLabels) {
+				t.Errorf("expected labels %v, got %v", tt.expectedLabels, stored.GetLabels())
+			}
+		})
+	}
+}
+
 func TestProxyRulesHandler_DeleteProxyRule(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -355,3 +599,4604 @@ func TestProxyRulesHandler_DuplicateDomain(t *testing.T) {
 		t.Error("expected error message about duplicate domain")
 	}
 }
+
+func TestProxyRulesHandler_Events(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	recorder := testutil.NewFakeEventRecorder()
+	handler := NewProxyRulesHandler(fakeClient, WithEventRecorder(recorder))
+
+	// Create
+	createBody, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "event-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "events.example.com",
+			"destination": "10.0.0.50",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(createBody))
+	req.Header.Set("Content-Type", "application/json")
+	handler.CreateProxyRule(httptest.NewRecorder(), req)
+
+	// Update
+	updateBody, _ := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"domain":      "events.example.com",
+			"destination": "10.0.0.51",
+		},
+	})
+	req = httptest.NewRequest(http.MethodPut, "/api/proxyrules/event-rule", bytes.NewReader(updateBody))
+	req.Header.Set("Content-Type", "application/json")
+	handler.UpdateProxyRule(httptest.NewRecorder(), req)
+
+	// Rejected update
+	invalidBody, _ := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"domain":      "invalid..com",
+			"destination": "10.0.0.51",
+		},
+	})
+	req = httptest.NewRequest(http.MethodPut, "/api/proxyrules/event-rule", bytes.NewReader(invalidBody))
+	req.Header.Set("Content-Type", "application/json")
+	handler.UpdateProxyRule(httptest.NewRecorder(), req)
+
+	// Delete
+	req = httptest.NewRequest(http.MethodDelete, "/api/proxyrules/event-rule", nil)
+	handler.DeleteProxyRule(httptest.NewRecorder(), req)
+
+	expected := []testutil.RecordedEvent{
+		{Name: "event-rule", EventType: "Normal", Reason: "Created"},
+		{Name: "event-rule", EventType: "Normal", Reason: "Updated"},
+		{Name: "event-rule", EventType: "Warning", Reason: "ValidationFailed"},
+		{Name: "event-rule", EventType: "Normal", Reason: "Deleted"},
+	}
+
+	if len(recorder.Events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(recorder.Events), recorder.Events)
+	}
+	for i, want := range expected {
+		got := recorder.Events[i]
+		if got.Name != want.Name || got.EventType != want.EventType || got.Reason != want.Reason {
+			t.Errorf("event %d: expected %s/%s for %s, got %s/%s for %s", i, want.EventType, want.Reason, want.Name, got.EventType, got.Reason, got.Name)
+		}
+	}
+}
+
+func TestProxyRulesHandler_DefaultEventRecorder(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	handler := NewProxyRulesHandler(fakeClient)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "event-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "events.example.com",
+			"destination": "10.0.0.50",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateProxyRule(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+
+	// Events are written in the background
+	handler.recorder.(*events.DynamicRecorder).Wait()
+
+	eventsGVR := schema.GroupVersionResource{Version: "v1", Resource: "events"}
+	list, err := fakeClient.Resource(eventsGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(list.Items))
+	}
+
+	reason, _, _ := unstructured.NestedString(list.Items[0].Object, "reason")
+	involvedName, _, _ := unstructured.NestedString(list.Items[0].Object, "involvedObject", "name")
+	if reason != "Created" || involvedName != "event-rule" {
+		t.Errorf("expected Created event for event-rule, got %s for %s", reason, involvedName)
+	}
+}
+
+func TestProxyRulesHandler_GetHealthSummary(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("valid-rule", "proxy-rules", "valid.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("invalid-rule", "proxy-rules", "invalid.example.com", "300.0.0.1", 3000)
+	fakeClient.SeedProxyRule("conflict-a", "proxy-rules", "shared.example.com", "10.0.0.51", 3000)
+	fakeClient.SeedProxyRule("conflict-b", "proxy-rules", "shared.example.com", "10.0.0.52", 3000)
+
+	// Only valid-rule has a generated ingress
+	ingress := &unstructured.Unstructured{}
+	ingress.SetAPIVersion("networking.k8s.io/v1")
+	ingress.SetKind("Ingress")
+	ingress.SetName("valid-rule")
+	ingress.SetNamespace("proxy-rules")
+	fakeClient.Seed(schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, ingress)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/health-summary", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetHealthSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var summary HealthSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	expected := HealthSummary{Total: 4, Valid: 3, Invalid: 1, DomainConflicts: 1, Orphaned: 3}
+	if summary != expected {
+		t.Errorf("expected summary %+v, got %+v", expected, summary)
+	}
+}
+
+func TestProxyRulesHandler_DuplicateDomainInDomainsArray(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           map[string]interface{}
+		expectedStatus int
+	}{
+		{
+			name: "new rule domains collide with existing single domain",
+			spec: map[string]interface{}{
+				"domains":     []interface{}{"other.example.com", "example.com"},
+				"destination": "10.0.0.60",
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "new rule single domain collides with existing domains array",
+			spec: map[string]interface{}{
+				"domain":      "www.multi.example.com",
+				"destination": "10.0.0.60",
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "no overlap",
+			spec: map[string]interface{}{
+				"domains":     []interface{}{"fresh.example.com", "www.fresh.example.com"},
+				"destination": "10.0.0.60",
+			},
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("rule1", "proxy-rules", "example.com", "10.0.0.50", 3000)
+
+			multi := testutil.NewProxyRule("rule2", "multi.example.com", "10.0.0.51", 3000)
+			unstructured.SetNestedStringSlice(multi.Object, []string{"www.multi.example.com"}, "spec", "domains")
+			fakeClient.Seed(testutil.ProxyRulesGVR, multi)
+
+			handler := NewProxyRulesHandler(fakeClient)
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "rule3"},
+				"spec":     tt.spec,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_ExportProxyRules(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	const ruleCount = 250
+	for i := 0; i < ruleCount; i++ {
+		fakeClient.SeedProxyRule(fmt.Sprintf("rule-%d", i), "proxy-rules", fmt.Sprintf("app%d.example.com", i), "10.0.0.50", 3000)
+	}
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/export", nil)
+	w := httptest.NewRecorder()
+
+	handler.ExportProxyRules(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("expected Content-Type application/yaml, got %q", ct)
+	}
+
+	documents := strings.Count(w.Body.String(), "---\n")
+	if documents != ruleCount {
+		t.Errorf("expected %d YAML documents, got %d", ruleCount, documents)
+	}
+	if !strings.Contains(w.Body.String(), "domain: app42.example.com") {
+		t.Error("expected exported rule spec in output")
+	}
+}
+
+func TestProxyRulesHandler_ExportProxyRulesSanitized(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	rule := testutil.NewProxyRule("app", "app.example.com", "10.0.0.50", 3000)
+	rule.SetLabels(map[string]string{"team": "web"})
+	rule.SetAnnotations(map[string]string{
+		"owner":               "web-team",
+		lastAppliedAnnotation: `{"kind":"Proxyrule"}`,
+	})
+	rule.SetCreationTimestamp(metav1.NewTime(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)))
+	rule.SetGeneration(3)
+	rule.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "mortar-backend", Operation: metav1.ManagedFieldsOperationUpdate}})
+	rule.Object["status"] = map[string]interface{}{"ready": true}
+	fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+
+	handler := NewProxyRulesHandler(fakeClient)
+	w := httptest.NewRecorder()
+	handler.ExportProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/export", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="proxyrules.yaml"` {
+		t.Errorf("expected a YAML attachment, got Content-Disposition %q", cd)
+	}
+
+	var exported map[string]interface{}
+	if err := yaml.Unmarshal([]byte(strings.TrimPrefix(w.Body.String(), "---\n")), &exported); err != nil {
+		t.Fatalf("failed to parse export: %v", err)
+	}
+	want := map[string]interface{}{
+		"apiVersion": "bausteln.io/v1",
+		"kind":       "Proxyrule",
+		"metadata": map[string]interface{}{
+			"name":        "app",
+			"namespace":   "proxy-rules",
+			"labels":      map[string]interface{}{"team": "web"},
+			"annotations": map[string]interface{}{"owner": "web-team"},
+		},
+		"spec": map[string]interface{}{
+			"domain":      "app.example.com",
+			"destination": "10.0.0.50",
+			"port":        float64(3000),
+			"tls":         true,
+		},
+	}
+	if !reflect.DeepEqual(exported, want) {
+		t.Errorf("expected only re-appliable fields\nwant: %v\ngot:  %v", want, exported)
+	}
+
+	// The stored rule keeps its bookkeeping
+	stored, _ := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "app", metav1.GetOptions{})
+	if stored.GetUID() == "" || len(stored.GetManagedFields()) == 0 {
+		t.Error("expected export not to modify the stored rule")
+	}
+}
+
+func TestProxyRulesHandler_ExportProxyRulesCSV(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	rule := testutil.NewProxyRule("multi", "app.example.com", "", 8080)
+	unstructured.RemoveNestedField(rule.Object, "spec", "destination")
+	unstructured.SetNestedStringSlice(rule.Object, []string{"10.0.0.50", "10.0.0.51"}, "spec", "destinations")
+	rule.SetLabels(map[string]string{"team": "web", "env": "prod"})
+	fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+	fakeClient.SeedProxyRule("single", "proxy-rules", "single.example.com", "10.0.0.52", 0)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/export?format=csv", nil)
+	w := httptest.NewRecorder()
+
+	handler.ExportProxyRules(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("expected Content-Type text/csv, got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="proxyrules.csv"`) {
+		t.Errorf("expected Content-Disposition with filename, got %q", cd)
+	}
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	expected := []string{
+		"name,domain,destinations,port,tls,labels",
+		"multi,app.example.com,10.0.0.50;10.0.0.51,8080,true,env=prod;team=web",
+		"single,single.example.com,10.0.0.52,80,true,",
+	}
+	if fmt.Sprint(lines) != fmt.Sprint(expected) {
+		t.Errorf("expected CSV\n%s\ngot\n%s", strings.Join(expected, "\n"), w.Body.String())
+	}
+
+	// Unknown formats are rejected
+	w = httptest.NewRecorder()
+	handler.ExportProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/export?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown format, got %d", w.Code)
+	}
+}
+
+func BenchmarkProxyRulesHandler_ExportProxyRules(b *testing.B) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	for i := 0; i < 5000; i++ {
+		fakeClient.SeedProxyRule(fmt.Sprintf("rule-%d", i), "proxy-rules", fmt.Sprintf("app%d.example.com", i), "10.0.0.50", 3000)
+	}
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/export", nil)
+		handler.ExportProxyRules(discardResponseWriter{header: http.Header{}}, req)
+	}
+}
+
+// discardResponseWriter is a ResponseWriter that drops the body, so benchmarks
+// measure the handler's own allocations rather than the recorder's buffer
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d discardResponseWriter) Header() http.Header         { return d.header }
+func (d discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d discardResponseWriter) WriteHeader(int)             {}
+
+func TestProxyRulesHandler_CreateProxyRuleLocationHeader(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	handler := NewProxyRulesHandler(fakeClient)
+
+	bodyBytes, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "located-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "located.example.com",
+			"destination": "10.0.0.50",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.CreateProxyRule(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/api/proxyrules/located-rule" {
+		t.Errorf("expected Location /api/proxyrules/located-rule, got %q", location)
+	}
+}
+
+func TestProxyRulesHandler_ValidationOptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           validation.Options
+		spec           map[string]interface{}
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "tls without port is a warning by default",
+			spec:           map[string]interface{}{"domain": "tls.example.com", "destination": "backend.internal", "tls": true},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "tls without port is rejected when required",
+			opts:           validation.Options{RequireTLSPort: true},
+			spec:           map[string]interface{}{"domain": "tls.example.com", "destination": "backend.internal", "tls": true},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "spec.port is not set",
+		},
+		{
+			name:           "destinations over the configured limit",
+			opts:           validation.Options{MaxDestinations: 1},
+			spec:           map[string]interface{}{"domain": "many.example.com", "destinations": []interface{}{"10.0.0.1", "10.0.0.2"}},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "more than 1 entries",
+		},
+		{
+			name:           "destination outside the allowed ranges",
+			opts:           validation.Options{AllowedDestinationCIDRs: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}},
+			spec:           map[string]interface{}{"domain": "outside.example.com", "destination": "192.168.1.10"},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   "outside the allowed ranges",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			handler := NewProxyRulesHandler(testutil.NewFakeDynamicClient(), WithValidationOptions(tt.opts), WithEventRecorder(testutil.NewFakeEventRecorder()))
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "options-rule"},
+				"spec":     tt.spec,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body containing %q, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_CreateProxyRuleMetadata(t *testing.T) {
+	tests := []struct {
+		name                string
+		metadata            map[string]interface{}
+		expectedStatus      int
+		expectedLabels      map[string]string
+		expectedAnnotations map[string]string
+		expectedField       string
+	}{
+		{
+			name: "labels and annotations are stored",
+			metadata: map[string]interface{}{
+				"name":        "tagged-rule",
+				"labels":      map[string]interface{}{"team": "web", "app.kubernetes.io/env": "staging"},
+				"annotations": map[string]interface{}{"example.com/owner": "Web Team <web@example.com>"},
+			},
+			expectedStatus:      http.StatusCreated,
+			expectedLabels:      map[string]string{"team": "web", "app.kubernetes.io/env": "staging"},
+			expectedAnnotations: map[string]string{"example.com/owner": "Web Team <web@example.com>"},
+		},
+		{
+			name: "server populated metadata is dropped",
+			metadata: map[string]interface{}{
+				"name":            "tagged-rule",
+				"labels":          map[string]interface{}{"team": "web"},
+				"uid":             "0d3c7f6e-1111-2222-3333-444455556666",
+				"resourceVersion": "42",
+			},
+			expectedStatus: http.StatusCreated,
+			expectedLabels: map[string]string{"team": "web"},
+		},
+		{
+			name: "invalid label key",
+			metadata: map[string]interface{}{
+				"name":   "tagged-rule",
+				"labels": map[string]interface{}{"bad key": "web"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedField:  "metadata.labels.bad key",
+		},
+		{
+			name: "invalid label value",
+			metadata: map[string]interface{}{
+				"name":   "tagged-rule",
+				"labels": map[string]interface{}{"team": "web team"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedField:  "metadata.labels.team",
+		},
+		{
+			name: "non-string annotation value",
+			metadata: map[string]interface{}{
+				"name":        "tagged-rule",
+				"annotations": map[string]interface{}{"example.com/replicas": 3},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedField:  "metadata.annotations.example.com/replicas",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			handler := NewProxyRulesHandler(fakeClient)
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": tt.metadata,
+				"spec":     map[string]interface{}{"domain": "tagged.example.com", "destination": "10.0.0.50", "port": 3000},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedField != "" {
+				var response validation.ValidationErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to parse response: %v", err)
+				}
+				if len(response.Errors) != 1 || response.Errors[0].Field != tt.expectedField {
+					t.Errorf("expected one error on %s, got %+v", tt.expectedField, response.Errors)
+				}
+				return
+			}
+
+			stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "tagged-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected the rule to be stored: %v", err)
+			}
+			if !reflect.DeepEqual(stored.GetLabels(), tt.expectedLabels) {
+				t.Errorf("expected labels %v, got %v", tt.expectedLabels, stored.GetLabels())
+			}
+			for key, value := range tt.expectedAnnotations {
+				if got := stored.GetAnnotations()[key]; got != value {
+					t.Errorf("expected annotation %s=%q, got %q", key, value, got)
+				}
+			}
+			if stored.GetUID() == "0d3c7f6e-1111-2222-3333-444455556666" {
+				t.Errorf("expected the client's uid to be dropped")
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_SharedDestinationWarnings(t *testing.T) {
+	tests := []struct {
+		name             string
+		disabled         bool
+		method           string
+		path             string
+		contentType      string
+		body             string
+		expectedStatus   int
+		expectedWarnings []string
+	}{
+		{
+			name:           "create with a shared destination",
+			method:         http.MethodPost,
+			path:           "/api/proxyrules",
+			contentType:    "application/json",
+			body:           `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusCreated,
+			expectedWarnings: []string{
+				`299 - "destination '10.0.0.50' is also used by rules 'api', 'web'; check that the rules are meant to share a backend"`,
+			},
+		},
+		{
+			name:           "check disabled",
+			disabled:       true,
+			method:         http.MethodPost,
+			path:           "/api/proxyrules",
+			contentType:    "application/json",
+			body:           `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "create with a unique destination",
+			method:         http.MethodPost,
+			path:           "/api/proxyrules",
+			contentType:    "application/json",
+			body:           `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destination":"10.0.0.70","port":3000}}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "one warning per shared destination",
+			method:         http.MethodPost,
+			path:           "/api/proxyrules",
+			contentType:    "application/json",
+			body:           `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destinations":["10.0.0.60","10.0.0.70","10.0.0.50"],"port":3000}}`,
+			expectedStatus: http.StatusCreated,
+			expectedWarnings: []string{
+				`299 - "destination '10.0.0.60' is also used by rule 'other'; check that the rules are meant to share a backend"`,
+				`299 - "destination '10.0.0.50' is also used by rules 'api', 'web'; check that the rules are meant to share a backend"`,
+			},
+		},
+		{
+			name:           "update ignores the rule itself",
+			method:         http.MethodPut,
+			path:           "/api/proxyrules/web",
+			contentType:    "application/json",
+			body:           `{"spec":{"domain":"web.example.com","destination":"10.0.0.50","port":3000}}`,
+			expectedStatus: http.StatusOK,
+			expectedWarnings: []string{
+				`299 - "destination '10.0.0.50' is also used by rule 'api'; check that the rules are meant to share a backend"`,
+			},
+		},
+		{
+			name:           "patch onto a shared destination",
+			method:         http.MethodPatch,
+			path:           "/api/proxyrules/other",
+			contentType:    "application/merge-patch+json",
+			body:           `{"spec":{"destination":"10.0.0.50"}}`,
+			expectedStatus: http.StatusOK,
+			expectedWarnings: []string{
+				`299 - "destination '10.0.0.50' is also used by rules 'api', 'web'; check that the rules are meant to share a backend"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("web", "proxy-rules", "web.example.com", "10.0.0.50", 3000)
+			fakeClient.SeedProxyRule("api", "proxy-rules", "api.example.com", "10.0.0.50", 3000)
+			fakeClient.SeedProxyRule("other", "proxy-rules", "other.example.com", "10.0.0.60", 3000)
+			handler := NewProxyRulesHandler(fakeClient, WithSharedDestinationWarnings(!tt.disabled))
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			switch tt.method {
+			case http.MethodPost:
+				handler.CreateProxyRule(w, req)
+			case http.MethodPut:
+				handler.UpdateProxyRule(w, req)
+			case http.MethodPatch:
+				handler.PatchProxyRule(w, req)
+			}
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			var warnings []string
+			for _, warning := range w.Header().Values("Warning") {
+				if strings.Contains(warning, "also used by") {
+					warnings = append(warnings, warning)
+				}
+			}
+			if fmt.Sprint(warnings) != fmt.Sprint(tt.expectedWarnings) {
+				t.Errorf("expected warnings %q, got %q", tt.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_CreateProxyRuleDefaultPort(t *testing.T) {
+	tests := []struct {
+		name          string
+		defaultPort   int
+		spec          map[string]interface{}
+		expectedPort  int64
+		expectWarning bool
+	}{
+		{
+			name:          "default applied to rule without port",
+			defaultPort:   8080,
+			spec:          map[string]interface{}{"domain": "default.example.com", "destination": "10.0.0.50"},
+			expectedPort:  8080,
+			expectWarning: true,
+		},
+		{
+			name:         "explicit port untouched",
+			defaultPort:  8080,
+			spec:         map[string]interface{}{"domain": "explicit.example.com", "destination": "10.0.0.50", "port": 9090},
+			expectedPort: 9090,
+		},
+		{
+			name: "no default configured",
+			spec: map[string]interface{}{"domain": "unset.example.com", "destination": "10.0.0.50"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			handler := NewProxyRulesHandler(fakeClient, WithDefaultDestinationPort(tt.defaultPort))
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "port-rule"},
+				"spec":     tt.spec,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var created struct {
+				Spec struct {
+					Port *int64 `json:"port"`
+				} `json:"spec"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if tt.expectedPort == 0 && created.Spec.Port != nil {
+				t.Errorf("expected no spec.port, got %d", *created.Spec.Port)
+			}
+			if tt.expectedPort != 0 && (created.Spec.Port == nil || *created.Spec.Port != tt.expectedPort) {
+				t.Errorf("expected spec.port %d, got %v", tt.expectedPort, created.Spec.Port)
+			}
+
+			warning := w.Header().Get("Warning")
+			if tt.expectWarning && !strings.Contains(warning, "8080") {
+				t.Errorf("expected Warning header naming the default port, got %q", warning)
+			}
+			if !tt.expectWarning && warning != "" {
+				t.Errorf("expected no Warning header, got %q", warning)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_ImportProxyRulesDefaultPort(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	// The default has to be applied before validation, which would otherwise reject the TLS rule
+	handler := NewProxyRulesHandler(fakeClient,
+		WithDefaultDestinationPort(8443),
+		WithValidationOptions(validation.Options{RequireTLSPort: true}),
+	)
+
+	body := "metadata:\n  name: tls-rule\nspec:\n  domain: tls.example.com\n  destination: 10.0.0.50\n  tls: true\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/yaml")
+	w := httptest.NewRecorder()
+	handler.ImportProxyRules(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if warning := w.Header().Get("Warning"); !strings.Contains(warning, "8443") {
+		t.Errorf("expected Warning header naming the default port, got %q", warning)
+	}
+
+	stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "tls-rule", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the rule to be imported: %v", err)
+	}
+	if port, _, _ := unstructured.NestedFieldNoCopy(stored.Object, "spec", "port"); fmt.Sprint(port) != "8443" {
+		t.Errorf("expected spec.port 8443, got %v", port)
+	}
+}
+
+func TestProxyRulesHandler_YAMLBodies(t *testing.T) {
+	createYAML := `apiVersion: bausteln.io/v1
+kind: Proxyrule
+metadata:
+  name: yaml-rule
+spec:
+  domain: yaml.example.com
+  destination: 10.0.0.50
+  port: 8080
+  tls: true
+  upstreamServerName: backend.example.com
+`
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "application/yaml", contentType: "application/yaml", body: createYAML, expectedStatus: http.StatusCreated},
+		{name: "text/yaml with charset", contentType: "text/yaml; charset=utf-8", body: createYAML, expectedStatus: http.StatusCreated},
+		{name: "yaml sent as json", contentType: "application/json", body: createYAML, expectedStatus: http.StatusBadRequest},
+		{name: "malformed yaml", contentType: "application/yaml", body: "metadata: [unclosed", expectedStatus: http.StatusBadRequest},
+		{name: "yaml list", contentType: "application/yaml", body: "- name: a\n", expectedStatus: http.StatusBadRequest},
+		{name: "unsupported charset", contentType: "application/yaml; charset=latin1", body: createYAML, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			rule, err := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "yaml-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("rule not stored: %v", err)
+			}
+			if port, _, _ := unstructured.NestedFieldNoCopy(rule.Object, "spec", "port"); port != float64(8080) && port != int64(8080) {
+				t.Errorf("expected port 8080, got %v (%T)", port, port)
+			}
+		})
+	}
+
+	t.Run("update", func(t *testing.T) {
+		fakeClient := testutil.NewFakeDynamicClient()
+		fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+		handler := NewProxyRulesHandler(fakeClient)
+
+		body := "spec:\n  domain: updated.example.com\n  destination: 10.0.0.60\n"
+		req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/test-rule", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/yaml")
+		w := httptest.NewRecorder()
+		handler.UpdateProxyRule(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if !strings.Contains(w.Body.String(), "updated.example.com") {
+			t.Errorf("expected updated domain in response, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestProxyRulesHandler_AllowDuplicateDomains(t *testing.T) {
+	tests := []struct {
+		name           string
+		allow          bool
+		expectedStatus int
+		expectWarning  bool
+	}{
+		{
+			name:           "duplicates blocked by default",
+			allow:          false,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "duplicates allowed with warning",
+			allow:          true,
+			expectedStatus: http.StatusCreated,
+			expectWarning:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("rule1", "proxy-rules", "example.com", "10.0.0.50", 3000)
+
+			handler := NewProxyRulesHandler(fakeClient, WithAllowDuplicateDomains(tt.allow))
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "rule2"},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": "10.0.0.60",
+				},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			warning := w.Header().Get("Warning")
+			if tt.expectWarning && !strings.Contains(warning, "rule1") {
+				t.Errorf("expected Warning header naming rule1, got %q", warning)
+			}
+			if !tt.expectWarning && warning != "" {
+				t.Errorf("expected no Warning header, got %q", warning)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_UpstreamServerNameWarning(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec           map[string]interface{}
+		expectedStatus int
+		expectWarning  bool
+	}{
+		{
+			name:           "tls to an IP without a server name",
+			spec:           map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50", "tls": true, "port": 8443},
+			expectedStatus: http.StatusCreated,
+			expectWarning:  true,
+		},
+		{
+			name:           "tls to an IP with a server name",
+			spec:           map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50", "tls": true, "port": 8443, "upstreamServerName": "backend.example.com"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "public profile rejects a missing server name",
+			spec: map[string]interface{}{
+				"domain":      "example.com",
+				"destination": "10.0.0.50",
+				"tls":         true,
+				"annotations": map[string]interface{}{"mortar.io/validation-profile": "public"},
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyRulesHandler(testutil.NewFakeDynamicClient())
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "tls-rule"},
+				"spec":     tt.spec,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			warning := w.Header().Get("Warning")
+			if tt.expectWarning && !strings.Contains(warning, "upstreamServerName") {
+				t.Errorf("expected Warning header about upstreamServerName, got %q", warning)
+			}
+			if !tt.expectWarning && warning != "" {
+				t.Errorf("expected no Warning header, got %q", warning)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_OptimisticConcurrency(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	// Read the rule and remember its ETag
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule", nil)
+	w := httptest.NewRecorder()
+	handler.GetProxyRule(w, req)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header on GET")
+	}
+
+	update := func(destination, ifMatch string) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"domain":      "example.com",
+				"destination": destination,
+			},
+		})
+		req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/test-rule", bytes.NewReader(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.UpdateProxyRule(w, req)
+		return w
+	}
+
+	// First writer with the current ETag succeeds
+	if w := update("10.0.0.60", etag); w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 with current ETag, got %d: %s", w.Code, w.Body.String())
+	} else if w.Header().Get("ETag") == etag {
+		t.Error("expected ETag to change after update")
+	}
+
+	// Second writer with the now stale ETag is rejected
+	if w := update("10.0.0.70", etag); w.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected status 412 with stale ETag, got %d", w.Code)
+	}
+
+	// Without If-Match the last write wins
+	if w := update("10.0.0.80", ""); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 without If-Match, got %d", w.Code)
+	}
+}
+
+func TestProxyRulesHandler_MaxListResponseBytes(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+
+	// Seed many rules with large specs
+	padding := strings.Repeat("x", 1024)
+	for i := 0; i < 50; i++ {
+		rule := testutil.NewProxyRule(fmt.Sprintf("rule-%d", i), fmt.Sprintf("example%d.com", i), "10.0.0.50", 3000)
+		unstructured.SetNestedField(rule.Object, padding, "spec", "description")
+		fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+	}
+
+	tests := []struct {
+		name           string
+		maxBytes       int64
+		expectedStatus int
+	}{
+		{
+			name:           "no cap by default",
+			maxBytes:       0,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list under the cap",
+			maxBytes:       1 << 20,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list over the cap",
+			maxBytes:       16 * 1024,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyRulesHandler(fakeClient, WithMaxListResponseBytes(tt.maxBytes))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), "/api/proxyrules/export") {
+				t.Errorf("expected error to suggest the paginated export, got %q", w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusOK && int64(w.Body.Len()) <= 50*1024 {
+				t.Errorf("expected full list in response, got %d bytes", w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_NormalizesDomains(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	handler := NewProxyRulesHandler(fakeClient)
+
+	create := func(name, domain string) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name},
+			"spec": map[string]interface{}{
+				"domain":       domain,
+				"destinations": []interface{}{"Backend.Local."},
+			},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.CreateProxyRule(w, req)
+		return w
+	}
+
+	if w := create("rule1", "Example.COM."); w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "rule1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get stored rule: %v", err)
+	}
+	if domain, _, _ := unstructured.NestedString(stored.Object, "spec", "domain"); domain != "example.com" {
+		t.Errorf("expected stored domain 'example.com', got %q", domain)
+	}
+	if dests, _, _ := unstructured.NestedStringSlice(stored.Object, "spec", "destinations"); len(dests) != 1 || dests[0] != "backend.local" {
+		t.Errorf("expected stored destinations [backend.local], got %v", dests)
+	}
+
+	// The canonical form is what the duplicate check sees
+	if w := create("rule2", "example.com"); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for equivalent domain, got %d", w.Code)
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesLabelSelector(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	for name, team := range map[string]string{"rule1": "web", "rule2": "api", "rule3": "web"} {
+		rule := testutil.NewProxyRule(name, name+".example.com", "10.0.0.50", 3000)
+		rule.SetLabels(map[string]string{"team": team})
+		fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+	}
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+	}{
+		{
+			name:           "no selector returns all rules",
+			query:          "",
+			expectedStatus: http.StatusOK,
+			expectedCount:  3,
+		},
+		{
+			name:           "equality selector",
+			query:          "?labelSelector=team%3Dweb",
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+		},
+		{
+			name:           "selector matching nothing",
+			query:          "?labelSelector=team%3Dops",
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+		},
+		{
+			name:           "malformed selector",
+			query:          "?labelSelector=team+in+(",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Items []interface{} `json:"items"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if len(result.Items) != tt.expectedCount {
+				t.Errorf("expected %d items, got %d", tt.expectedCount, len(result.Items))
+			}
+		})
+	}
+}
+
+// failingIngressDeleteClient wraps the fake client so that deleting ingresses fails
+type failingIngressDeleteClient struct {
+	*testutil.FakeDynamicClient
+}
+
+func (c failingIngressDeleteClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	resource := c.FakeDynamicClient.Resource(gvr)
+	if gvr == ingressGVR {
+		return failingDeleteResource{resource}
+	}
+	return resource
+}
+
+type failingDeleteResource struct {
+	dynamic.NamespaceableResourceInterface
+}
+
+func (r failingDeleteResource) Namespace(ns string) dynamic.ResourceInterface {
+	return failingDeleteNamespacedResource{r.NamespaceableResourceInterface.Namespace(ns)}
+}
+
+type failingDeleteNamespacedResource struct {
+	dynamic.ResourceInterface
+}
+
+func (r failingDeleteNamespacedResource) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	return fmt.Errorf("ingress delete refused")
+}
+
+func TestProxyRulesHandler_DeleteProxyRuleCascade(t *testing.T) {
+	newIngress := func(name, namespace, owner string) *unstructured.Unstructured {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetAPIVersion("networking.k8s.io/v1")
+		ingress.SetKind("Ingress")
+		ingress.SetName(name)
+		ingress.SetNamespace(namespace)
+		if owner != "" {
+			ingress.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "bausteln.io/v1", Kind: "Proxyrule", Name: owner}})
+		}
+		return ingress
+	}
+
+	tests := []struct {
+		name              string
+		query             string
+		failIngressDelete bool
+		expectedStatus    int
+		expectedDeleted   []string
+		expectedRemaining []string
+	}{
+		{
+			name:              "no cascade keeps ingresses",
+			query:             "",
+			expectedStatus:    http.StatusNoContent,
+			expectedRemaining: []string{"other-rule", "test-rule", "test-rule-www"},
+		},
+		{
+			name:              "cascade deletes ingresses by name and owner",
+			query:             "?cascade=true",
+			expectedStatus:    http.StatusOK,
+			expectedDeleted:   []string{"test-rule", "test-rule-www"},
+			expectedRemaining: []string{"other-rule"},
+		},
+		{
+			name:              "cascade ingress failure is a partial success",
+			query:             "?cascade=true",
+			failIngressDelete: true,
+			expectedStatus:    http.StatusMultiStatus,
+			expectedDeleted:   []string{},
+			expectedRemaining: []string{"other-rule", "test-rule", "test-rule-www"},
+		},
+		{
+			name:              "invalid cascade value",
+			query:             "?cascade=maybe",
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"other-rule", "test-rule", "test-rule-www"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+			fakeClient.Seed(ingressGVR, newIngress("test-rule", "proxy-rules", ""))
+			fakeClient.Seed(ingressGVR, newIngress("test-rule-www", "proxy-rules", "test-rule"))
+			fakeClient.Seed(ingressGVR, newIngress("other-rule", "proxy-rules", "other-rule"))
+
+			var client dynamic.Interface = fakeClient
+			if tt.failIngressDelete {
+				client = failingIngressDeleteClient{fakeClient}
+			}
+			handler := NewProxyRulesHandler(client)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/proxyrules/test-rule"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.DeleteProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedDeleted != nil {
+				var result CascadeDeleteResult
+				if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+					t.Fatalf("failed to parse response: %v", err)
+				}
+				if fmt.Sprint(result.DeletedIngresses) != fmt.Sprint(tt.expectedDeleted) {
+					t.Errorf("expected deleted ingresses %v, got %v", tt.expectedDeleted, result.DeletedIngresses)
+				}
+				if tt.failIngressDelete && len(result.Errors) != 2 {
+					t.Errorf("expected 2 errors, got %v", result.Errors)
+				}
+			}
+
+			remaining, _ := fakeClient.Resource(ingressGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+			var names []string
+			for _, item := range remaining.Items {
+				names = append(names, item.GetName())
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expectedRemaining) {
+				t.Errorf("expected remaining ingresses %v, got %v", tt.expectedRemaining, names)
+			}
+		})
+	}
+}
+
+func TestIngressHandler_GetIngresses(t *testing.T) {
+	newIngress := func(name, namespace string) *unstructured.Unstructured {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetAPIVersion("networking.k8s.io/v1")
+		ingress.SetKind("Ingress")
+		ingress.SetName(name)
+		ingress.SetNamespace(namespace)
+		return ingress
+	}
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.Seed(ingressGVR, newIngress("shop", "web"))
+	fakeClient.Seed(ingressGVR, newIngress("blog", "web"))
+	fakeClient.Seed(ingressGVR, newIngress("grafana", "monitoring"))
+	fakeClient.Seed(ingressGVR, newIngress("test-rule", "proxy-rules"))
+
+	handler := NewIngressHandler(fakeClient)
+	req := httptest.NewRequest(http.MethodGet, "/api/ingresses", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetIngresses(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	var got []string
+	for _, item := range response.Items {
+		got = append(got, item.Metadata.Namespace+"/"+item.Metadata.Name)
+	}
+	// Ingresses generated for proxy rules are left out
+	want := []string{"monitoring/grafana", "web/blog", "web/shop"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected ingresses %v, got %v", want, got)
+	}
+}
+
+func TestProxyRuleOwnerReference(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("owner-rule", "proxy-rules", "owner.example.com", "10.0.0.50", 3000)
+	rule, err := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "owner-rule", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get seeded rule: %v", err)
+	}
+
+	// Generate an ingress for the rule the way the ingress controller does
+	ingress := &unstructured.Unstructured{}
+	ingress.SetAPIVersion("networking.k8s.io/v1")
+	ingress.SetKind("Ingress")
+	ingress.SetName("owner-rule-generated")
+	ingress.SetNamespace("proxy-rules")
+	ingress.SetOwnerReferences([]metav1.OwnerReference{ProxyRuleOwnerReference(rule.GetName(), rule.GetUID())})
+	if _, err := fakeClient.Resource(ingressGVR).Namespace("proxy-rules").Create(context.Background(), ingress, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create ingress: %v", err)
+	}
+
+	created, err := fakeClient.Resource(ingressGVR).Namespace("proxy-rules").Get(context.Background(), "owner-rule-generated", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get ingress: %v", err)
+	}
+	refs := created.GetOwnerReferences()
+	if len(refs) != 1 {
+		t.Fatalf("expected one owner reference, got %v", refs)
+	}
+	ref := refs[0]
+	if ref.APIVersion != "bausteln.io/v1" || ref.Kind != "Proxyrule" || ref.Name != "owner-rule" {
+		t.Errorf("expected owner reference to bausteln.io/v1 Proxyrule owner-rule, got %s %s %s", ref.APIVersion, ref.Kind, ref.Name)
+	}
+	if rule.GetUID() == "" || ref.UID != rule.GetUID() {
+		t.Errorf("expected owner reference UID %q, got %q", rule.GetUID(), ref.UID)
+	}
+	if ref.Controller == nil || !*ref.Controller {
+		t.Error("expected the rule to be the controller of the ingress")
+	}
+	if ref.BlockOwnerDeletion == nil || !*ref.BlockOwnerDeletion {
+		t.Error("expected the owner reference to block owner deletion")
+	}
+	if !isIngressForRule(*created, "proxy-rules", "owner-rule") {
+		t.Error("expected the ingress to be matched to its rule")
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRuleIngress(t *testing.T) {
+	newIngress := func(name, owner string) *unstructured.Unstructured {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetAPIVersion("networking.k8s.io/v1")
+		ingress.SetKind("Ingress")
+		ingress.SetName(name)
+		ingress.SetNamespace("proxy-rules")
+		if owner != "" {
+			ingress.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "bausteln.io/v1", Kind: "Proxyrule", Name: owner}})
+		}
+		return ingress
+	}
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("owned-rule", "proxy-rules", "owned.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("named-rule", "proxy-rules", "named.example.com", "10.0.0.51", 3000)
+	fakeClient.SeedProxyRule("pending-rule", "proxy-rules", "pending.example.com", "10.0.0.52", 3000)
+	fakeClient.Seed(ingressGVR, newIngress("owned-rule", ""))
+	fakeClient.Seed(ingressGVR, newIngress("owned-rule-generated", "owned-rule"))
+	fakeClient.Seed(ingressGVR, newIngress("named-rule", ""))
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name           string
+		rule           string
+		expectedStatus int
+		expectedName   string
+	}{
+		{name: "owner reference preferred over name", rule: "owned-rule", expectedStatus: http.StatusOK, expectedName: "owned-rule-generated"},
+		{name: "matched by name", rule: "named-rule", expectedStatus: http.StatusOK, expectedName: "named-rule"},
+		{name: "no ingress yet", rule: "pending-rule", expectedStatus: http.StatusNotFound},
+		{name: "unknown rule", rule: "missing-rule", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/"+tt.rule+"/ingress", nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRuleIngress(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedName == "" {
+				return
+			}
+
+			var ingress unstructured.Unstructured
+			if err := json.Unmarshal(w.Body.Bytes(), &ingress.Object); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if ingress.GetKind() != "Ingress" || ingress.GetName() != tt.expectedName {
+				t.Errorf("expected Ingress %s, got %s %s", tt.expectedName, ingress.GetKind(), ingress.GetName())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesByDestination(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("single", "proxy-rules", "single.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("other", "proxy-rules", "other.example.com", "10.0.0.51", 3000)
+
+	multi := testutil.NewProxyRule("multi", "multi.example.com", "", 3000)
+	unstructured.RemoveNestedField(multi.Object, "spec", "destination")
+	unstructured.SetNestedStringSlice(multi.Object, []string{"10.0.0.52", "10.0.0.50"}, "spec", "destinations")
+	fakeClient.Seed(testutil.ProxyRulesGVR, multi)
+
+	ipv6 := testutil.NewProxyRule("ipv6", "ipv6.example.com", "2001:db8::1", 3000)
+	fakeClient.Seed(testutil.ProxyRulesGVR, ipv6)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name          string
+		destination   string
+		expectedNames []string
+	}{
+		{
+			name:          "matches single destination and destinations array",
+			destination:   "10.0.0.50",
+			expectedNames: []string{"multi", "single"},
+		},
+		{
+			name:          "match only within destinations array",
+			destination:   "10.0.0.52",
+			expectedNames: []string{"multi"},
+		},
+		{
+			name:          "ipv6 matched in non-canonical form",
+			destination:   "2001:0db8:0:0::1",
+			expectedNames: []string{"ipv6"},
+		},
+		{
+			name:          "no match",
+			destination:   "10.0.0.99",
+			expectedNames: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules?destination="+tt.destination, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRules(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+
+			var result struct {
+				Items []struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			names := []string{}
+			for _, item := range result.Items {
+				names = append(names, item.Metadata.Name)
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expectedNames) {
+				t.Errorf("expected rules %v, got %v", tt.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRuleSpecView(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	w := httptest.NewRecorder()
+	handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule?view=spec", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(body) != 2 || body["name"] != "test-rule" {
+		t.Errorf("expected only name and spec, got %v", body)
+	}
+	spec, _ := body["spec"].(map[string]interface{})
+	if spec["domain"] != "example.com" {
+		t.Errorf("expected spec with domain example.com, got %v", body["spec"])
+	}
+	if w.Header().Get("ETag") == "" {
+		t.Error("expected ETag header")
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule?view=everything", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown view, got %d", w.Code)
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesSummaryView(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, name := range []string{"multi-rule", "test-rule"} {
+		rule := testutil.NewProxyRule(name, name+".example.com", "10.0.0.50", 3000)
+		rule.SetNamespace("proxy-rules")
+		rule.SetCreationTimestamp(metav1.NewTime(created))
+		fakeClient.Seed(ProxyRulesGVR, rule)
+	}
+	handler := NewProxyRulesHandler(fakeClient)
+
+	body := `{"spec":{"domain":"multi-rule.example.com","destinations":["10.0.0.60","10.0.0.61"],"tls":false}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/multi-rule", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules?view=summary", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var list SummaryList
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", list.Items)
+	}
+
+	updated := list.Items[0]
+	if updated.Name != "multi-rule" || updated.Destination != "10.0.0.60" || updated.Port != 80 || updated.TLS {
+		t.Errorf("expected the resolved first destination, port 80 and no tls, got %+v", updated)
+	}
+	if !updated.CreatedAt.Equal(created) || !updated.UpdatedAt.After(created) {
+		t.Errorf("expected createdAt %v and a later updatedAt, got %+v", created, updated)
+	}
+
+	unchanged := list.Items[1]
+	want := SummaryView{Name: "test-rule", Domain: "test-rule.example.com", Destination: "10.0.0.50", Port: 3000, TLS: true, CreatedAt: created, UpdatedAt: created}
+	if !reflect.DeepEqual(unchanged, want) {
+		t.Errorf("expected %+v, got %+v", want, unchanged)
+	}
+
+	stored, _ := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "multi-rule", metav1.GetOptions{})
+	if _, err := time.Parse(time.RFC3339, stored.GetAnnotations()[UpdatedAtAnnotation]); err != nil {
+		t.Errorf("expected an RFC 3339 %s annotation, got %v", UpdatedAtAnnotation, stored.GetAnnotations())
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules?view=spec", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a view lists do not support, got %d", w.Code)
+	}
+}
+
+func TestResponseFieldCasing(t *testing.T) {
+	// assertCamelCase fails for any JSON key that is not camelCase
+	var assertCamelCase func(t *testing.T, path string, v interface{})
+	assertCamelCase = func(t *testing.T, path string, v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if key == "" || strings.ToLower(key[:1]) != key[:1] || strings.ContainsAny(key, "_-") {
+					t.Errorf("field %s%s is not camelCase", path, key)
+				}
+				assertCamelCase(t, path+key+".", child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				assertCamelCase(t, path, child)
+			}
+		}
+	}
+
+	responses := []struct {
+		name   string
+		value  interface{}
+		fields []string
+	}{
+		{
+			name:   "domain check response",
+			value:  DomainCheckResponse{Domain: "app.example.com", ConflictingRule: "rule1", ConflictingDomain: "*.example.com"},
+			fields: []string{"domain", "available", "conflictingRule", "conflictingDomain"},
+		},
+		{
+			name:   "error response",
+			value:  ErrorResponse{Error: ErrorDetail{Code: ConflictReasonDuplicateDomain, Message: "overlaps", ConflictingRule: "rule", ConflictingValue: "*.example.com"}},
+			fields: []string{"error.code", "error.message", "error.conflictingRule", "error.conflictingValue"},
+		},
+		{
+			name:   "health summary",
+			value:  HealthSummary{Total: 1},
+			fields: []string{"total", "domainConflicts"},
+		},
+		{
+			name:   "cascade delete result",
+			value:  CascadeDeleteResult{Name: "rule", DeletedIngresses: []string{"rule"}, Errors: []string{"failed"}},
+			fields: []string{"name", "deletedIngresses", "errors"},
+		},
+		{
+			name:   "summary list",
+			value:  SummaryList{Items: []SummaryView{{Name: "rule", Domain: "example.com"}}},
+			fields: []string{"items"},
+		},
+		{
+			name:   "spec view",
+			value:  SpecView{Name: "rule", Spec: map[string]interface{}{"domain": "example.com"}},
+			fields: []string{"name", "spec.domain"},
+		},
+		{
+			name: "validation error response",
+			value: validation.ValidationErrorResponse{
+				Status:  http.StatusBadRequest,
+				Message: "invalid",
+				Errors:  validation.ValidationErrors{{Field: "spec.port", Message: "invalid"}},
+			},
+			fields: []string{"status", "message", "errors"},
+		},
+		{
+			name:   "history response",
+			value:  HistoryResponse{Name: "rule", Entries: []HistoryEntry{{Operation: "update", Subject: "alice", Changes: []string{"spec.port"}}}},
+			fields: []string{"name", "entries"},
+		},
+		{
+			name:   "import response",
+			value:  ImportResponse{Mode: "upsert", Results: []ImportResult{{Name: "rule", Status: "error", Error: "invalid"}}},
+			fields: []string{"mode", "results"},
+		},
+	}
+
+	for _, tt := range responses {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("failed to marshal: %v", err)
+			}
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("failed to unmarshal: %v", err)
+			}
+
+			assertCamelCase(t, "", decoded)
+
+			for _, field := range tt.fields {
+				var current interface{} = decoded
+				for _, part := range strings.Split(field, ".") {
+					object, ok := current.(map[string]interface{})
+					if !ok {
+						current = nil
+						break
+					}
+					current = object[part]
+				}
+				if current == nil {
+					t.Errorf("expected field %s in %s", field, data)
+				}
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DestinationScheme(t *testing.T) {
+	tests := []struct {
+		name           string
+		destination    string
+		expectedStatus int
+		expectedScheme string
+		expectedDest   string
+	}{
+		{
+			name:           "plain destination defaults to http",
+			destination:    "backend.local",
+			expectedStatus: http.StatusCreated,
+			expectedScheme: "http",
+			expectedDest:   "backend.local",
+		},
+		{
+			name:           "https prefix is recorded",
+			destination:    "https://backend.local",
+			expectedStatus: http.StatusCreated,
+			expectedScheme: "https",
+			expectedDest:   "backend.local",
+		},
+		{
+			name:           "unknown scheme rejected",
+			destination:    "gopher://backend.local",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			handler := NewProxyRulesHandler(fakeClient)
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "test-rule"},
+				"spec": map[string]interface{}{
+					"domain":      "example.com",
+					"destination": tt.destination,
+				},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get stored rule: %v", err)
+			}
+			if scheme, _, _ := unstructured.NestedString(stored.Object, "spec", "destinationScheme"); scheme != tt.expectedScheme {
+				t.Errorf("expected destinationScheme %q, got %q", tt.expectedScheme, scheme)
+			}
+			if dest, _, _ := unstructured.NestedString(stored.Object, "spec", "destination"); dest != tt.expectedDest {
+				t.Errorf("expected destination %q, got %q", tt.expectedDest, dest)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_RequestBodyTooLarge(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	handler := NewProxyRulesHandler(fakeClient)
+
+	// Larger than validation.DefaultMaxRequestBodySize
+	oversized := `{"spec":{"domain":"example.com","destination":"` + strings.Repeat("a", 2*1024*1024) + `"}}`
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		handler func(http.ResponseWriter, *http.Request)
+	}{
+		{
+			name:    "create",
+			method:  http.MethodPost,
+			path:    "/api/proxyrules",
+			handler: handler.CreateProxyRule,
+		},
+		{
+			// The rule does not exist, so a 404 would mean it was fetched before reading the body
+			name:    "update",
+			method:  http.MethodPut,
+			path:    "/api/proxyrules/missing-rule",
+			handler: handler.UpdateProxyRule,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(oversized))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("expected status 413, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_MaxBodyBytes(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient, WithMaxBodyBytes(64))
+
+	body := `{"metadata":{"name":"small-rule"},"spec":{"domain":"small.example.com","destination":"10.0.0.60"}}`
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		handler     func(http.ResponseWriter, *http.Request)
+	}{
+		{name: "create", method: http.MethodPost, path: "/api/proxyrules", contentType: "application/json", handler: handler.CreateProxyRule},
+		{name: "update", method: http.MethodPut, path: "/api/proxyrules/test-rule", contentType: "application/json", handler: handler.UpdateProxyRule},
+		{name: "patch", method: http.MethodPatch, path: "/api/proxyrules/test-rule", contentType: "application/merge-patch+json", handler: handler.PatchProxyRule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			tt.handler(w, req)
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("expected status 413, got %d: %s", w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), "max 64 bytes") {
+				t.Errorf("expected the configured limit in the message, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_ProbeProxyRule(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	rule := testutil.NewProxyRule("test-rule", "example.com", "", 8080)
+	unstructured.RemoveNestedField(rule.Object, "spec", "destination")
+	unstructured.SetNestedStringSlice(rule.Object, []string{"10.0.0.50", "10.0.0.51"}, "spec", "destinations")
+	fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+
+	handler := NewProxyRulesHandler(fakeClient)
+	handler.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if address == "10.0.0.51:8080" {
+			return nil, fmt.Errorf("connection refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule/probe", nil)
+	w := httptest.NewRecorder()
+
+	handler.ProbeProxyRule(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response ProbeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(response.Results))
+	}
+	if !response.Results[0].Reachable || response.Results[0].Address != "10.0.0.50:8080" {
+		t.Errorf("expected 10.0.0.50:8080 to be reachable, got %+v", response.Results[0])
+	}
+	if response.Results[1].Reachable || !strings.Contains(response.Results[1].Error, "connection refused") {
+		t.Errorf("expected 10.0.0.51:8080 to be unreachable, got %+v", response.Results[1])
+	}
+}
+
+func TestProxyRulesHandler_ProbeSaturation(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+
+	handler := NewProxyRulesHandler(fakeClient, WithMaxConcurrentProbes(1))
+
+	// The first probe holds the only slot until released
+	dialing := make(chan struct{})
+	release := make(chan struct{})
+	handler.dialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		close(dialing)
+		<-release
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	firstDone := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		handler.ProbeProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule/probe", nil))
+		firstDone <- w.Code
+	}()
+	<-dialing
+
+	// A second probe gives up waiting for a slot when its context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule/probe", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	handler.ProbeProxyRule(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 while saturated, got %d", w.Code)
+	}
+
+	close(release)
+	if code := <-firstDone; code != http.StatusOK {
+		t.Errorf("expected first probe to complete with 200, got %d", code)
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRuleDebug(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	// Seeded directly, so neither normalized nor defaulted
+	rule := testutil.NewProxyRule("test-rule", "example.com", "Backend.Local", 0)
+	unstructured.RemoveNestedField(rule.Object, "spec", "tls")
+	fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule?debug=true", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetProxyRule(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var view DebugView
+	if err := json.Unmarshal(w.Body.Bytes(), &view); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if view.Raw == nil || view.Resolved == nil {
+		t.Fatalf("expected raw and resolved forms, got %s", w.Body.String())
+	}
+
+	raw := &unstructured.Unstructured{Object: view.Raw}
+	resolved := &unstructured.Unstructured{Object: view.Resolved}
+
+	if _, found, _ := unstructured.NestedFieldNoCopy(raw.Object, "spec", "port"); found {
+		t.Error("expected raw form without port")
+	}
+	if port, _, _ := unstructured.NestedFloat64(resolved.Object, "spec", "port"); port != 80 {
+		t.Errorf("expected resolved port 80, got %v", port)
+	}
+	if tls, found, _ := unstructured.NestedBool(resolved.Object, "spec", "tls"); !found || !tls {
+		t.Error("expected resolved tls to default to true")
+	}
+	if dest, _, _ := unstructured.NestedString(raw.Object, "spec", "destination"); dest != "Backend.Local" {
+		t.Errorf("expected raw destination as stored, got %q", dest)
+	}
+	if dest, _, _ := unstructured.NestedString(resolved.Object, "spec", "destination"); dest != "backend.local" {
+		t.Errorf("expected resolved destination normalized, got %q", dest)
+	}
+
+	// Without debug the stored object is returned unchanged
+	w = httptest.NewRecorder()
+	handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule", nil))
+	if strings.Contains(w.Body.String(), `"resolved"`) {
+		t.Error("expected plain object without debug")
+	}
+}
+
+func TestProxyRulesHandler_CountProxyRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules int
+	}{
+		{name: "empty namespace", rules: 0},
+		{name: "single rule", rules: 1},
+		{name: "more rules than one page", rules: 2*exportPageSize + 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			for i := 0; i < tt.rules; i++ {
+				fakeClient.SeedProxyRule(fmt.Sprintf("rule-%d", i), "proxy-rules", fmt.Sprintf("example%d.com", i), "10.0.0.50", 3000)
+			}
+
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/count", nil)
+			w := httptest.NewRecorder()
+
+			handler.CountProxyRules(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			var response CountResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if response.Count != int64(tt.rules) {
+				t.Errorf("expected count %d, got %d", tt.rules, response.Count)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DeleteProxyRulesBySelector(t *testing.T) {
+	tests := []struct {
+		name              string
+		query             string
+		confirm           bool
+		expectedStatus    int
+		expectedResults   []string
+		expectedRemaining []string
+	}{
+		{
+			name:              "deletes matching rules only",
+			query:             "?labelSelector=team%3Dweb",
+			confirm:           true,
+			expectedStatus:    http.StatusOK,
+			expectedResults:   []string{"rule1:deleted", "rule3:deleted"},
+			expectedRemaining: []string{"rule2", "rule4"},
+		},
+		{
+			name:              "dry run previews without deleting",
+			query:             "?labelSelector=team%3Dweb&dryRun=All",
+			expectedStatus:    http.StatusOK,
+			expectedResults:   []string{"rule1:wouldDelete", "rule3:wouldDelete"},
+			expectedRemaining: []string{"rule1", "rule2", "rule3", "rule4"},
+		},
+		{
+			name:              "bool dry run rejected as on other writes",
+			query:             "?labelSelector=team%3Dweb&dryRun=true",
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3", "rule4"},
+		},
+		{
+			name:              "confirmation header required",
+			query:             "?labelSelector=team%3Dweb",
+			expectedStatus:    http.StatusPreconditionRequired,
+			expectedRemaining: []string{"rule1", "rule2", "rule3", "rule4"},
+		},
+		{
+			name:              "selector required",
+			query:             "",
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3", "rule4"},
+		},
+		{
+			name:              "malformed selector",
+			query:             "?labelSelector=team+in+(",
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3", "rule4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			for name, team := range map[string]string{"rule1": "web", "rule2": "api", "rule3": "web"} {
+				rule := testutil.NewProxyRule(name, name+".example.com", "10.0.0.50", 3000)
+				rule.SetLabels(map[string]string{"team": team})
+				fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+			}
+			fakeClient.SeedProxyRule("rule4", "proxy-rules", "rule4.example.com", "10.0.0.50", 3000)
+
+			handler := NewProxyRulesHandler(fakeClient, WithEventRecorder(testutil.NewFakeEventRecorder()))
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/proxyrules"+tt.query, nil)
+			if tt.confirm {
+				req.Header.Set("X-Confirm-Delete", "true")
+			}
+			w := httptest.NewRecorder()
+
+			handler.DeleteProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedResults != nil {
+				var response BulkDeleteResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to parse response: %v", err)
+				}
+				var results []string
+				for _, result := range response.Results {
+					results = append(results, result.Name+":"+result.Status)
+				}
+				if fmt.Sprint(results) != fmt.Sprint(tt.expectedResults) {
+					t.Errorf("expected results %v, got %v", tt.expectedResults, results)
+				}
+			}
+
+			remaining, _ := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+			var names []string
+			for _, item := range remaining.Items {
+				names = append(names, item.GetName())
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expectedRemaining) {
+				t.Errorf("expected remaining rules %v, got %v", tt.expectedRemaining, names)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_BatchDeleteProxyRules(t *testing.T) {
+	newIngress := func(name, owner string) *unstructured.Unstructured {
+		ingress := &unstructured.Unstructured{}
+		ingress.SetAPIVersion("networking.k8s.io/v1")
+		ingress.SetKind("Ingress")
+		ingress.SetName(name)
+		ingress.SetNamespace("proxy-rules")
+		ingress.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "bausteln.io/v1", Kind: "Proxyrule", Name: owner}})
+		return ingress
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		body               string
+		confirm            bool
+		failIngressDelete  bool
+		expectedStatus     int
+		expectedResults    []string
+		expectedRemaining  []string
+		expectedIngresses  []string
+		expectedIngressErr bool
+	}{
+		{
+			name:              "deletes named rules",
+			body:              `{"names": ["rule1", "rule2"]}`,
+			confirm:           true,
+			expectedStatus:    http.StatusOK,
+			expectedResults:   []string{"rule1:deleted", "rule2:deleted"},
+			expectedRemaining: []string{"rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "missing names do not stop the batch",
+			body:              `{"names": ["missing", "rule2", "rule2", "rule3"]}`,
+			confirm:           true,
+			expectedStatus:    http.StatusMultiStatus,
+			expectedResults:   []string{"missing:notFound", "rule2:deleted", "rule3:deleted"},
+			expectedRemaining: []string{"rule1"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "label selector",
+			body:              `{"labelSelector": "team=web"}`,
+			confirm:           true,
+			expectedStatus:    http.StatusOK,
+			expectedResults:   []string{"rule1:deleted", "rule3:deleted"},
+			expectedRemaining: []string{"rule2"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "cascade deletes ingresses",
+			query:             "?cascade=true",
+			body:              `{"names": ["rule1", "missing"]}`,
+			confirm:           true,
+			expectedStatus:    http.StatusMultiStatus,
+			expectedResults:   []string{"missing:notFound", "rule1:deleted"},
+			expectedRemaining: []string{"rule2", "rule3"},
+			expectedIngresses: []string{"rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:               "cascade ingress failure keeps the rule deleted",
+			query:              "?cascade=true",
+			body:               `{"names": ["rule1"]}`,
+			confirm:            true,
+			failIngressDelete:  true,
+			expectedStatus:     http.StatusMultiStatus,
+			expectedResults:    []string{"rule1:deleted"},
+			expectedRemaining:  []string{"rule2", "rule3"},
+			expectedIngresses:  []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+			expectedIngressErr: true,
+		},
+		{
+			name:              "dry run previews without deleting",
+			query:             "?dryRun=All&cascade=true",
+			body:              `{"names": ["rule1", "missing"]}`,
+			expectedStatus:    http.StatusMultiStatus,
+			expectedResults:   []string{"missing:notFound", "rule1:wouldDelete"},
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "confirmation header required",
+			body:              `{"names": ["rule1"]}`,
+			expectedStatus:    http.StatusPreconditionRequired,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "names and selector are exclusive",
+			body:              `{"names": ["rule1"], "labelSelector": "team=web"}`,
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "empty names",
+			body:              `{"names": []}`,
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "non-string name",
+			body:              `{"names": ["rule1", 7]}`,
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "malformed selector",
+			body:              `{"labelSelector": "team in ("}`,
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "bool dry run rejected as on other writes",
+			query:             "?dryRun=true",
+			body:              `{"names": ["rule1"]}`,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+		{
+			name:              "invalid cascade value",
+			query:             "?cascade=maybe",
+			body:              `{"names": ["rule1"]}`,
+			confirm:           true,
+			expectedStatus:    http.StatusBadRequest,
+			expectedRemaining: []string{"rule1", "rule2", "rule3"},
+			expectedIngresses: []string{"rule1-ingress", "rule2-ingress", "rule3-ingress"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			for name, team := range map[string]string{"rule1": "web", "rule2": "api", "rule3": "web"} {
+				rule := testutil.NewProxyRule(name, name+".example.com", "10.0.0.50", 3000)
+				rule.SetLabels(map[string]string{"team": team})
+				fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+				fakeClient.Seed(ingressGVR, newIngress(name+"-ingress", name))
+			}
+
+			var client dynamic.Interface = fakeClient
+			if tt.failIngressDelete {
+				client = failingIngressDeleteClient{fakeClient}
+			}
+			handler := NewProxyRulesHandler(client, WithEventRecorder(testutil.NewFakeEventRecorder()))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules:delete"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.confirm {
+				req.Header.Set("X-Confirm-Delete", "true")
+			}
+			w := httptest.NewRecorder()
+
+			handler.BatchDeleteProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedResults != nil {
+				var response BulkDeleteResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to parse response: %v", err)
+				}
+				var results []string
+				for _, result := range response.Results {
+					results = append(results, result.Name+":"+result.Status)
+					if (result.Error != "") != (tt.expectedIngressErr || result.Status == "failed") {
+						t.Errorf("unexpected error %q for %s", result.Error, result.Name)
+					}
+				}
+				if fmt.Sprint(results) != fmt.Sprint(tt.expectedResults) {
+					t.Errorf("expected results %v, got %v", tt.expectedResults, results)
+				}
+			}
+
+			remaining, _ := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+			var names []string
+			for _, item := range remaining.Items {
+				names = append(names, item.GetName())
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tt.expectedRemaining) {
+				t.Errorf("expected remaining rules %v, got %v", tt.expectedRemaining, names)
+			}
+
+			ingresses, _ := fakeClient.Resource(ingressGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+			var ingressNames []string
+			for _, item := range ingresses.Items {
+				ingressNames = append(ingressNames, item.GetName())
+			}
+			if fmt.Sprint(ingressNames) != fmt.Sprint(tt.expectedIngresses) {
+				t.Errorf("expected remaining ingresses %v, got %v", tt.expectedIngresses, ingressNames)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_PatchProxyRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+		check          func(t *testing.T, spec map[string]interface{})
+	}{
+		{
+			name:           "json patch removes port",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "remove", "path": "/spec/port"}]`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, spec map[string]interface{}) {
+				if _, found := spec["port"]; found {
+					t.Error("expected port to be removed")
+				}
+			},
+		},
+		{
+			name:        "json patch appends destination",
+			contentType: "application/json-patch+json",
+			body: `[
+				{"op": "remove", "path": "/spec/destination"},
+				{"op": "add", "path": "/spec/destinations", "value": ["10.0.0.51"]},
+				{"op": "add", "path": "/spec/destinations/-", "value": "10.0.0.52"}
+			]`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, spec map[string]interface{}) {
+				if fmt.Sprint(spec["destinations"]) != "[10.0.0.51 10.0.0.52]" {
+					t.Errorf("expected appended destinations, got %v", spec["destinations"])
+				}
+			},
+		},
+		{
+			name:           "json patch adding destinations beside destination",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "add", "path": "/spec/destinations", "value": ["10.0.0.51"]}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "merge patch with labels that are not a map",
+			contentType:    "application/merge-patch+json",
+			body:           `{"metadata": {"labels": "team=api"}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "merge patch replaces domain",
+			contentType:    "application/merge-patch+json",
+			body:           `{"spec": {"domain": "new.example.com"}}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, spec map[string]interface{}) {
+				if spec["domain"] != "new.example.com" {
+					t.Errorf("expected patched domain, got %v", spec["domain"])
+				}
+				if spec["destination"] != "10.0.0.50" {
+					t.Errorf("expected untouched destination, got %v", spec["destination"])
+				}
+			},
+		},
+		{
+			name:           "patched result is validated",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "replace", "path": "/spec/port", "value": 70000}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "merge patch removing the only destination rejected",
+			contentType:    "application/merge-patch+json",
+			body:           `{"spec": {"destination": null}}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "json patch emptying destinations rejected",
+			contentType: "application/json-patch+json",
+			body: `[
+				{"op": "remove", "path": "/spec/destination"},
+				{"op": "add", "path": "/spec/destinations", "value": []}
+			]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "renaming rejected",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "replace", "path": "/metadata/name", "value": "other"}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "changing kind rejected",
+			contentType:    "application/merge-patch+json",
+			body:           `{"kind": "Ingress"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "changing apiVersion rejected",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "replace", "path": "/apiVersion", "value": "bausteln.io/v2"}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "failing operation",
+			contentType:    "application/json-patch+json",
+			body:           `[{"op": "remove", "path": "/spec/missing"}]`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "malformed patch",
+			contentType:    "application/json-patch+json",
+			body:           `{"op": "remove"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported content type",
+			contentType:    "application/json",
+			body:           `{"spec": {"port": 8080}}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/proxyrules/test-rule", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			handler.PatchProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get stored rule: %v", err)
+			}
+			spec, _, _ := unstructured.NestedMap(stored.Object, "spec")
+
+			if tt.check != nil {
+				tt.check(t, spec)
+				return
+			}
+			// Rejected patches leave the rule untouched
+			if spec["domain"] != "example.com" || fmt.Sprint(spec["port"]) != "3000" {
+				t.Errorf("expected rule to be unchanged, got %v", spec)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DomainConflicts(t *testing.T) {
+	tests := []struct {
+		name           string
+		existingDomain string
+		newDomain      string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "case-insensitive match",
+			existingDomain: "Example.COM",
+			newDomain:      "example.com",
+			expectedStatus: http.StatusConflict,
+			expectedError:  "already exists",
+		},
+		{
+			name:           "trailing dot ignored",
+			existingDomain: "example.com.",
+			newDomain:      "example.com",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "new subdomain under existing wildcard",
+			existingDomain: "*.example.com",
+			newDomain:      "a.example.com",
+			expectedStatus: http.StatusConflict,
+			expectedError:  "overlaps with domain '*.example.com'",
+		},
+		{
+			name:           "new wildcard over existing subdomain",
+			existingDomain: "a.example.com",
+			newDomain:      "*.Example.com",
+			expectedStatus: http.StatusConflict,
+			expectedError:  "overlaps with domain 'a.example.com'",
+		},
+		{
+			name:           "wildcard covers a single label only",
+			existingDomain: "*.example.com",
+			newDomain:      "a.b.example.com",
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "wildcard does not cover the apex",
+			existingDomain: "*.example.com",
+			newDomain:      "example.com",
+			expectedStatus: http.StatusCreated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			// Seeded directly, so stored exactly as given
+			fakeClient.SeedProxyRule("existing", "proxy-rules", tt.existingDomain, "10.0.0.50", 3000)
+
+			handler := NewProxyRulesHandler(fakeClient)
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "new-rule"},
+				"spec": map[string]interface{}{
+					"domain":      tt.newDomain,
+					"destination": "10.0.0.60",
+				},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %q", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DestinationStrategy(t *testing.T) {
+	// Deliberately unsorted, with mixed case to exercise normalization
+	destinations := []interface{}{"Backend-C.local", "10.0.0.2", "backend-a.local", "10.0.0.1"}
+	expectedOrder := "[backend-c.local 10.0.0.2 backend-a.local 10.0.0.1]"
+
+	tests := []struct {
+		name             string
+		strategy         string
+		expectedStatus   int
+		expectedResolved string
+	}{
+		{
+			name:             "no strategy resolves to roundrobin",
+			strategy:         "",
+			expectedStatus:   http.StatusCreated,
+			expectedResolved: "roundrobin",
+		},
+		{
+			name:             "roundrobin",
+			strategy:         "roundrobin",
+			expectedStatus:   http.StatusCreated,
+			expectedResolved: "roundrobin",
+		},
+		{
+			name:             "failover preserves order",
+			strategy:         "failover",
+			expectedStatus:   http.StatusCreated,
+			expectedResolved: "failover",
+		},
+		{
+			name:           "unknown strategy",
+			strategy:       "weighted",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			handler := NewProxyRulesHandler(fakeClient)
+
+			spec := map[string]interface{}{
+				"domain":       "example.com",
+				"destinations": destinations,
+			}
+			if tt.strategy != "" {
+				spec["destinationStrategy"] = tt.strategy
+			}
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "test-rule"},
+				"spec":     spec,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get stored rule: %v", err)
+			}
+			if got, _, _ := unstructured.NestedStringSlice(stored.Object, "spec", "destinations"); fmt.Sprint(got) != expectedOrder {
+				t.Errorf("expected destinations in submitted order %s, got %v", expectedOrder, got)
+			}
+			if got, _, _ := unstructured.NestedString(resolveProxyRule(stored).Object, "spec", "destinationStrategy"); got != tt.expectedResolved {
+				t.Errorf("expected resolved destinationStrategy %q, got %q", tt.expectedResolved, got)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_AuditLog(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	var buf bytes.Buffer
+	handler := NewProxyRulesHandler(fakeClient, WithAuditLogger(audit.NewJSONLogger(&buf)))
+
+	// Create
+	createBody, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "audit-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "audit.example.com",
+			"destination": "10.0.0.50",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(createBody))
+	req.Header.Set("Content-Type", "application/json")
+	handler.CreateProxyRule(httptest.NewRecorder(), req)
+
+	// Rejected create is not audited
+	req = httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(createBody))
+	req.Header.Set("Content-Type", "application/json")
+	handler.CreateProxyRule(httptest.NewRecorder(), req)
+
+	// Update by an authenticated subject behind a load balancer
+	updateBody, _ := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"domain":      "audit.example.com",
+			"destination": "10.0.0.51",
+		},
+	})
+	req = httptest.NewRequest(http.MethodPut, "/api/proxyrules/audit-rule", bytes.NewReader(updateBody))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(clientip.WithClientIP(audit.WithSubject(req.Context(), "alice"), "203.0.113.7"))
+	handler.UpdateProxyRule(httptest.NewRecorder(), req)
+
+	// Delete
+	req = httptest.NewRequest(http.MethodDelete, "/api/proxyrules/audit-rule", nil)
+	handler.DeleteProxyRule(httptest.NewRecorder(), req)
+
+	var entries []audit.Entry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry audit.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to parse audit line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	expected := []struct {
+		operation  string
+		subject    string
+		hasBefore  bool
+		hasAfter   bool
+		afterValue string
+	}{
+		{operation: "create", subject: "anonymous", hasAfter: true, afterValue: "10.0.0.50"},
+		{operation: "update", subject: "alice", hasBefore: true, hasAfter: true, afterValue: "10.0.0.51"},
+		{operation: "delete", subject: "anonymous", hasBefore: true},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d audit entries, got %d: %s", len(expected), len(entries), buf.String())
+	}
+	for i, want := range expected {
+		got := entries[i]
+		if got.Operation != want.operation || got.Subject != want.subject || got.Name != "audit-rule" || got.Domain != "audit.example.com" {
+			t.Errorf("entry %d: expected %s by %s for audit-rule/audit.example.com, got %+v", i, want.operation, want.subject, got)
+		}
+		if got.Timestamp.IsZero() {
+			t.Errorf("entry %d: expected timestamp", i)
+		}
+		if (got.Before != nil) != want.hasBefore || (got.After != nil) != want.hasAfter {
+			t.Errorf("entry %d: expected before=%v after=%v, got before=%v after=%v", i, want.hasBefore, want.hasAfter, got.Before, got.After)
+		}
+		if want.afterValue != "" && got.After["destination"] != want.afterValue {
+			t.Errorf("entry %d: expected after destination %s, got %v", i, want.afterValue, got.After["destination"])
+		}
+	}
+	if entries[1].ClientIP != "203.0.113.7" || entries[0].ClientIP != "" {
+		t.Errorf("expected only the update to record client 203.0.113.7, got %q and %q", entries[1].ClientIP, entries[0].ClientIP)
+	}
+	if entries[1].Before["destination"] != "10.0.0.50" {
+		t.Errorf("expected update before destination 10.0.0.50, got %v", entries[1].Before["destination"])
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRuleByUID(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	rule := testutil.NewProxyRule("uid-rule", "uid.example.com", "10.0.0.50", 3000)
+	rule.SetUID("3f1c9a2e-7b4d-4e8a-9c61-2d5f0b8e4a17")
+	fakeClient.Seed(testutil.ProxyRulesGVR, rule)
+	fakeClient.SeedProxyRule("other-rule", "proxy-rules", "other.example.com", "10.0.0.51", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name           string
+		uid            string
+		expectedStatus int
+	}{
+		{
+			name:           "known uid",
+			uid:            "3f1c9a2e-7b4d-4e8a-9c61-2d5f0b8e4a17",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown uid",
+			uid:            "00000000-0000-0000-0000-000000000000",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/by-uid/"+tt.uid, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRuleByUID(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var got unstructured.Unstructured
+			if err := json.Unmarshal(w.Body.Bytes(), &got.Object); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if got.GetName() != "uid-rule" || string(got.GetUID()) != tt.uid {
+				t.Errorf("expected uid-rule with uid %s, got %s with uid %s", tt.uid, got.GetName(), got.GetUID())
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_ConflictReason(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		ruleName         string
+		domain           string
+		expectedReason   string
+		expectedRule     string
+		expectedConflict string
+	}{
+		{
+			name:             "duplicate name",
+			method:           http.MethodPost,
+			path:             "/api/proxyrules",
+			ruleName:         "existing",
+			domain:           "new.example.com",
+			expectedReason:   "DuplicateName",
+			expectedRule:     "existing",
+			expectedConflict: "existing",
+		},
+		{
+			name:             "duplicate domain on create",
+			method:           http.MethodPost,
+			path:             "/api/proxyrules",
+			ruleName:         "new-rule",
+			domain:           "a.example.com",
+			expectedReason:   "DuplicateDomain",
+			expectedRule:     "existing",
+			expectedConflict: "*.example.com",
+		},
+		{
+			name:             "duplicate domain on update",
+			method:           http.MethodPut,
+			path:             "/api/proxyrules/other",
+			ruleName:         "other",
+			domain:           "b.example.com",
+			expectedReason:   "DuplicateDomain",
+			expectedRule:     "existing",
+			expectedConflict: "*.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("existing", "proxy-rules", "*.example.com", "10.0.0.50", 3000)
+			fakeClient.SeedProxyRule("other", "proxy-rules", "other.org", "10.0.0.51", 3000)
+			handler := NewProxyRulesHandler(fakeClient)
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": tt.ruleName},
+				"spec": map[string]interface{}{
+					"domain":      tt.domain,
+					"destination": "10.0.0.60",
+				},
+			})
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			if tt.method == http.MethodPost {
+				handler.CreateProxyRule(w, req)
+			} else {
+				handler.UpdateProxyRule(w, req)
+			}
+
+			if w.Code != http.StatusConflict {
+				t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected JSON body, got Content-Type %q", ct)
+			}
+
+			var body ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			resp := body.Error
+			if resp.Code != tt.expectedReason {
+				t.Errorf("expected code %s, got %s", tt.expectedReason, resp.Code)
+			}
+			if resp.ConflictingRule != tt.expectedRule {
+				t.Errorf("expected conflicting rule %s, got %s", tt.expectedRule, resp.ConflictingRule)
+			}
+			if resp.ConflictingValue != tt.expectedConflict {
+				t.Errorf("expected conflicting value %s, got %s", tt.expectedConflict, resp.ConflictingValue)
+			}
+			if resp.Message == "" {
+				t.Error("expected a human-readable message")
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_NotFoundSuggestions(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	for _, name := range []string{"billing-api", "billing-api-v2", "checkout", "unrelated-service"} {
+		fakeClient.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.50", 3000)
+	}
+
+	tests := []struct {
+		name                string
+		suggestions         int
+		ruleName            string
+		expectedSuggestions []string
+	}{
+		{
+			name:                "typo suggests intended rule",
+			suggestions:         5,
+			ruleName:            "biling-api",
+			expectedSuggestions: []string{"billing-api"},
+		},
+		{
+			name:                "prefix match",
+			suggestions:         5,
+			ruleName:            "check",
+			expectedSuggestions: []string{"checkout"},
+		},
+		{
+			name:                "closest first, limited to configured count",
+			suggestions:         1,
+			ruleName:            "billing-ap",
+			expectedSuggestions: []string{"billing-api"},
+		},
+		{
+			name:                "all close matches",
+			suggestions:         5,
+			ruleName:            "billing-ap",
+			expectedSuggestions: []string{"billing-api", "billing-api-v2"},
+		},
+		{
+			name:                "nothing similar",
+			suggestions:         5,
+			ruleName:            "zzz",
+			expectedSuggestions: []string{},
+		},
+		{
+			name:     "disabled by default",
+			ruleName: "biling-api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyRulesHandler(fakeClient, WithNotFoundSuggestions(tt.suggestions))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/"+tt.ruleName, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRule(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("expected status 404, got %d", w.Code)
+			}
+			if tt.expectedSuggestions == nil {
+				if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "application/json") {
+					t.Errorf("expected plain-text 404 when disabled, got %s: %s", ct, w.Body.String())
+				}
+				return
+			}
+
+			var resp NotFoundResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if fmt.Sprint(resp.Suggestions) != fmt.Sprint(tt.expectedSuggestions) {
+				t.Errorf("expected suggestions %v, got %v", tt.expectedSuggestions, resp.Suggestions)
+			}
+		})
+	}
+}
+
+// staleCache is a ReadCache reporting a fixed staleness that never syncs,
+// so reads fall through to the client
+type staleCache time.Duration
+
+func (c staleCache) Staleness() time.Duration { return time.Duration(c) }
+func (c staleCache) HasSynced() bool          { return false }
+func (c staleCache) List(string, labels.Selector) ([]*unstructured.Unstructured, error) {
+	return nil, nil
+}
+func (c staleCache) Get(string, string) (*unstructured.Unstructured, bool) { return nil, false }
+
+// memoryCache is a synced ReadCache holding a fixed set of rules
+type memoryCache []*unstructured.Unstructured
+
+func (c memoryCache) Staleness() time.Duration { return 0 }
+func (c memoryCache) HasSynced() bool          { return true }
+func (c memoryCache) List(namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	var items []*unstructured.Unstructured
+	for _, rule := range c {
+		if rule.GetNamespace() == namespace && selector.Matches(labels.Set(rule.GetLabels())) {
+			items = append(items, rule.DeepCopy())
+		}
+	}
+	return items, nil
+}
+func (c memoryCache) Get(namespace, name string) (*unstructured.Unstructured, bool) {
+	for _, rule := range c {
+		if rule.GetNamespace() == namespace && rule.GetName() == name {
+			return rule.DeepCopy(), true
+		}
+	}
+	return nil, false
+}
+
+func TestProxyRulesHandler_CachedReads(t *testing.T) {
+	cachedRule := func(name string, labels map[string]string) *unstructured.Unstructured {
+		rule := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "bausteln.io/v1",
+			"kind":       "Proxyrule",
+			"spec":       map[string]interface{}{"domain": name + ".example.com", "destination": "10.0.0.1", "port": int64(80)},
+		}}
+		rule.SetName(name)
+		rule.SetNamespace("proxy-rules")
+		rule.SetLabels(labels)
+		return rule
+	}
+
+	// Only the client knows "live-rule"; only the cache knows the others
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("live-rule", "proxy-rules", "live.example.com", "10.0.0.50", 3000)
+	cache := memoryCache{
+		cachedRule("zeta", map[string]string{"team": "web"}),
+		cachedRule("alpha", map[string]string{"team": "web"}),
+		cachedRule("beta", map[string]string{"team": "db"}),
+	}
+	handler := NewProxyRulesHandler(fakeClient, WithReadCache(cache))
+
+	t.Run("list is served from cache in name order", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.GetProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules?labelSelector=team%3Dweb", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var list unstructured.UnstructuredList
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to decode list: %v", err)
+		}
+		var names []string
+		for _, item := range list.Items {
+			names = append(names, item.GetName())
+		}
+		if !reflect.DeepEqual(names, []string{"alpha", "zeta"}) {
+			t.Errorf("expected [alpha zeta], got %v", names)
+		}
+		if list.GetKind() != "ProxyruleList" {
+			t.Errorf("expected kind ProxyruleList, got %q", list.GetKind())
+		}
+	})
+
+	t.Run("get is served from cache", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/beta", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("get falls back to the client on a cache miss", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/live-rule", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("list falls back to the client until the cache syncs", func(t *testing.T) {
+		handler := NewProxyRulesHandler(fakeClient, WithReadCache(staleCache(0)))
+		w := httptest.NewRecorder()
+		handler.GetProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil))
+		if !strings.Contains(w.Body.String(), "live-rule") {
+			t.Errorf("expected the live rule in the list, got %s", w.Body.String())
+		}
+	})
+}
+
+func TestProxyRulesHandler_MaxCacheStaleness(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           []Option
+		expectedStatus int
+	}{
+		{
+			name:           "no cache",
+			opts:           []Option{WithMaxCacheStaleness(time.Minute)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "cache within bound",
+			opts:           []Option{WithReadCache(staleCache(30 * time.Second)), WithMaxCacheStaleness(time.Minute)},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "stale cache",
+			opts:           []Option{WithReadCache(staleCache(5 * time.Minute)), WithMaxCacheStaleness(time.Minute)},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "stale cache without bound",
+			opts:           []Option{WithReadCache(staleCache(5 * time.Minute))},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+			handler := NewProxyRulesHandler(fakeClient, tt.opts...)
+
+			w := httptest.NewRecorder()
+			handler.GetProxyRules(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("list: expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			w = httptest.NewRecorder()
+			handler.GetProxyRule(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/test-rule", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("get: expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+// slowCreateClient counts proxy rule Lists and delays Creates, widening the
+// window in which concurrent creates could race past the duplicate domain check
+type slowCreateClient struct {
+	*testutil.FakeDynamicClient
+	lists *atomic.Int32
+}
+
+func (c slowCreateClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return slowCreateResource{c.FakeDynamicClient.Resource(gvr), c.lists}
+}
+
+type slowCreateResource struct {
+	dynamic.NamespaceableResourceInterface
+	lists *atomic.Int32
+}
+
+func (r slowCreateResource) Namespace(ns string) dynamic.ResourceInterface {
+	return slowCreateNamespacedResource{r.NamespaceableResourceInterface.Namespace(ns), r.lists}
+}
+
+type slowCreateNamespacedResource struct {
+	dynamic.ResourceInterface
+	lists *atomic.Int32
+}
+
+func (r slowCreateNamespacedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.lists.Add(1)
+	return r.ResourceInterface.List(ctx, opts)
+}
+
+func (r slowCreateNamespacedResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	time.Sleep(20 * time.Millisecond)
+	return r.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func TestProxyRulesHandler_ConcurrentImportsAndCreatesSameDomain(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	var lists atomic.Int32
+	handler := NewProxyRulesHandler(slowCreateClient{fakeClient, &lists})
+
+	const writers = 10
+	written := make(chan bool, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("race-rule-%d", i)
+			w := httptest.NewRecorder()
+			if i%2 == 0 {
+				body := fmt.Sprintf("metadata:\n  name: %s\nspec:\n  domain: race.example.com\n  destination: 10.0.0.60\n", name)
+				req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/import", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/yaml")
+				handler.ImportProxyRules(w, req)
+				written <- w.Code == http.StatusOK
+				return
+			}
+			body := fmt.Sprintf(`{"metadata":{"name":%q},"spec":{"domain":"race.example.com","destination":"10.0.0.60"}}`, name)
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			handler.CreateProxyRule(w, req)
+			written <- w.Code == http.StatusCreated
+		}(i)
+	}
+	wg.Wait()
+	close(written)
+
+	successes := 0
+	for ok := range written {
+		if ok {
+			successes++
+		}
+	}
+	list, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list rules: %v", err)
+	}
+	if successes != 1 || len(list.Items) != 1 {
+		t.Errorf("expected exactly one writer to claim the domain, got %d successes and %d stored rules", successes, len(list.Items))
+	}
+}
+
+func TestProxyRulesHandler_ConcurrentCreatesSameDomain(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("existing-rule", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+	var lists atomic.Int32
+	handler := NewProxyRulesHandler(slowCreateClient{fakeClient, &lists})
+
+	const creates = 10
+	statuses := make(chan int, creates)
+	var wg sync.WaitGroup
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(map[string]interface{}{
+				"metadata": map[string]interface{}{"name": fmt.Sprintf("race-rule-%d", i)},
+				"spec": map[string]interface{}{
+					"domain":      "race.example.com",
+					"destination": "10.0.0.60",
+				},
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.CreateProxyRule(w, req)
+			statuses <- w.Code
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != creates-1 {
+		t.Errorf("expected 1 created and %d conflicts, got %v", creates-1, counts)
+	}
+	if n := lists.Load(); n >= creates {
+		t.Errorf("expected concurrent creates to share domain lookups, got %d Lists for %d creates", n, creates)
+	}
+
+	// A write invalidates the index, so the next check sees the new rule
+	body, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "late-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "race.example.com",
+			"destination": "10.0.0.61",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateProxyRule(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 after the race, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProxyRulesHandler_EnabledFlag(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("active", "proxy-rules", "active.example.com", "10.0.0.50", 3000)
+	disabled := testutil.NewProxyRule("paused", "paused.example.com", "10.0.0.51", 3000)
+	unstructured.SetNestedField(disabled.Object, false, "spec", "enabled")
+	fakeClient.Seed(testutil.ProxyRulesGVR, disabled)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	listNames := func(query string) (int, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/proxyrules"+query, nil)
+		w := httptest.NewRecorder()
+		handler.GetProxyRules(w, req)
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+		var list struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("failed to parse response: %v", err)
+		}
+		names := []string{}
+		for _, item := range list.Items {
+			names = append(names, item.Metadata.Name)
+		}
+		return w.Code, names
+	}
+
+	tests := []struct {
+		query          string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{query: "", expectedStatus: http.StatusOK, expectedNames: []string{"active", "paused"}},
+		{query: "?enabled=true", expectedStatus: http.StatusOK, expectedNames: []string{"active"}},
+		{query: "?enabled=false", expectedStatus: http.StatusOK, expectedNames: []string{"paused"}},
+		{query: "?enabled=maybe", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		status, names := listNames(tt.query)
+		if status != tt.expectedStatus {
+			t.Errorf("GET %q: expected status %d, got %d", tt.query, tt.expectedStatus, status)
+			continue
+		}
+		if tt.expectedNames != nil && !reflect.DeepEqual(names, tt.expectedNames) {
+			t.Errorf("GET %q: expected %v, got %v", tt.query, tt.expectedNames, names)
+		}
+	}
+
+	// A disabled rule still reserves its domains
+	body, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "squatter"},
+		"spec":     map[string]interface{}{"domain": "paused.example.com", "destination": "10.0.0.52"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.CreateProxyRule(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for the domain of a disabled rule, got %d", w.Code)
+	}
+
+	// Re-enable the rule
+	body, _ = json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"domain": "paused.example.com", "destination": "10.0.0.51", "enabled": true},
+	})
+	req = httptest.NewRequest(http.MethodPut, "/api/proxyrules/paused", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 re-enabling the rule, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, names := listNames("?enabled=false"); len(names) != 0 {
+		t.Errorf("expected no disabled rules after re-enabling, got %v", names)
+	}
+
+	// enabled must be a boolean
+	body, _ = json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"domain": "paused.example.com", "destination": "10.0.0.51", "enabled": "no"},
+	})
+	req = httptest.NewRequest(http.MethodPut, "/api/proxyrules/paused", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "enabled must be a boolean") {
+		t.Errorf("expected 422 for a non-boolean enabled, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProxyRulesHandler_StructuredValidationErrors(t *testing.T) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "bad-rule"},
+		"spec": map[string]interface{}{
+			"domain":      "invalid..com",
+			"destination": "10.0.0.50",
+			"port":        70000,
+		},
+	})
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{name: "no accept header", accept: "", contentType: "application/json"},
+		{name: "json", accept: "application/json", contentType: "application/json"},
+		{name: "wildcard", accept: "*/*", contentType: "application/json"},
+		{name: "plain text", accept: "text/plain", contentType: "text/plain; charset=utf-8"},
+		{name: "plain text preferred", accept: "text/plain, application/json", contentType: "text/plain; charset=utf-8"},
+		{name: "plain text refused", accept: "text/plain;q=0, */*", contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyRulesHandler(testutil.NewFakeDynamicClient())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			handler.CreateProxyRule(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status 422, got %d", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.contentType, got)
+			}
+			for _, field := range []string{"spec.domain", "spec.port"} {
+				if !strings.Contains(w.Body.String(), field) {
+					t.Errorf("expected body to mention %s, got %s", field, w.Body.String())
+				}
+			}
+			if tt.contentType != "application/json" {
+				return
+			}
+
+			var response validation.ValidationErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if response.Status != http.StatusUnprocessableEntity || len(response.Errors) < 2 {
+				t.Fatalf("expected status 422 with an error per problem, got %+v", response)
+			}
+			last := response.Errors[len(response.Errors)-1]
+			if response.Errors[0].Field != "spec.domain" || last.Field != "spec.port" || last.Message != "port must be between 1 and 65535" {
+				t.Errorf("expected errors for spec.domain and spec.port, got %+v", response.Errors)
+			}
+
+			// Each error also carries the JSON pointer of its field
+			var raw struct {
+				Errors []map[string]string `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if pointer := raw.Errors[len(raw.Errors)-1]["pointer"]; pointer != "/spec/port" {
+				t.Errorf("expected pointer /spec/port, got %q", pointer)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_HeadProxyRule(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectETag     bool
+	}{
+		{name: "existing rule", path: "/api/proxyrules/test-rule", expectedStatus: http.StatusOK, expectETag: true},
+		{name: "missing rule", path: "/api/proxyrules/missing-rule", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodHead, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			handler.HeadProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("expected no body, got %q", w.Body.String())
+			}
+			if etag := w.Header().Get("ETag"); (etag != "") != tt.expectETag {
+				t.Errorf("unexpected ETag %q", etag)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DryRun(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "10.0.0.50", 3000)
+	ingress := &unstructured.Unstructured{}
+	ingress.SetAPIVersion("networking.k8s.io/v1")
+	ingress.SetKind("Ingress")
+	ingress.SetName("test-rule")
+	ingress.SetNamespace("proxy-rules")
+	fakeClient.Seed(ingressGVR, ingress)
+
+	recorder := testutil.NewFakeEventRecorder()
+	handler := NewProxyRulesHandler(fakeClient, WithEventRecorder(recorder))
+
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		switch method {
+		case http.MethodPost:
+			handler.CreateProxyRule(w, req)
+		case http.MethodPut:
+			handler.UpdateProxyRule(w, req)
+		case http.MethodDelete:
+			handler.DeleteProxyRule(w, req)
+		}
+		return w
+	}
+	storedDestination := func(name string) string {
+		rule, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			return ""
+		}
+		destination, _, _ := unstructured.NestedString(rule.Object, "spec", "destination")
+		return destination
+	}
+
+	// Create
+	w := send(http.MethodPost, "/api/proxyrules?dryRun=All", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "new-rule"},
+		"spec":     map[string]interface{}{"domain": "new.example.com", "destination": "10.0.0.60"},
+	})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"new-rule"`) {
+		t.Errorf("expected dry-run create to return the rule with 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if storedDestination("new-rule") != "" {
+		t.Error("expected dry-run create not to persist the rule")
+	}
+
+	// Checks still run
+	w = send(http.MethodPost, "/api/proxyrules?dryRun=all", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "other-rule"},
+		"spec":     map[string]interface{}{"domain": "example.com", "destination": "10.0.0.60"},
+	})
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected dry-run create of a duplicate domain to fail with 409, got %d", w.Code)
+	}
+
+	// Update
+	w = send(http.MethodPut, "/api/proxyrules/test-rule?dryRun=All", map[string]interface{}{
+		"spec": map[string]interface{}{"domain": "example.com", "destination": "10.0.0.99"},
+	})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "10.0.0.99") {
+		t.Errorf("expected dry-run update to return the changed rule with 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := storedDestination("test-rule"); got != "10.0.0.50" {
+		t.Errorf("expected dry-run update not to persist, destination is %s", got)
+	}
+
+	// Delete
+	w = send(http.MethodDelete, "/api/proxyrules/test-rule?dryRun=All", nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"test-rule"`) {
+		t.Errorf("expected dry-run delete to return the rule with 200, got %d: %s", w.Code, w.Body.String())
+	}
+	w = send(http.MethodDelete, "/api/proxyrules/test-rule?dryRun=All&cascade=true", nil)
+	var result CascadeDeleteResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusOK || !reflect.DeepEqual(result.DeletedIngresses, []string{"test-rule"}) {
+		t.Errorf("expected dry-run cascade delete to report the ingress, got %d: %s", w.Code, w.Body.String())
+	}
+	if storedDestination("test-rule") == "" {
+		t.Error("expected dry-run delete to keep the rule")
+	}
+	if _, err := fakeClient.Resource(ingressGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{}); err != nil {
+		t.Error("expected dry-run cascade delete to keep the ingress")
+	}
+
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no events for dry runs, got %v", recorder.Events)
+	}
+
+	// Only All is accepted
+	w = send(http.MethodDelete, "/api/proxyrules/test-rule?dryRun=true", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for dryRun=true, got %d", w.Code)
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesFieldQuery(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("app", "proxy-rules", "app.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("api", "proxy-rules", "api.example.com", "10.0.0.51", 3000)
+
+	plain := testutil.NewProxyRule("plain", "plain.example.com", "10.0.0.50", 3000)
+	unstructured.RemoveNestedField(plain.Object, "spec", "port")
+	unstructured.SetNestedField(plain.Object, false, "spec", "tls")
+	fakeClient.Seed(testutil.ProxyRulesGVR, plain)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedNames  []string
+	}{
+		{name: "port", query: "spec.port=3000", expectedStatus: http.StatusOK, expectedNames: []string{"api", "app"}},
+		{name: "default port", query: "spec.port=80", expectedStatus: http.StatusOK, expectedNames: []string{"plain"}},
+		{name: "tls", query: "spec.tls=false", expectedStatus: http.StatusOK, expectedNames: []string{"plain"}},
+		{name: "destination", query: "spec.destination=10.0.0.50", expectedStatus: http.StatusOK, expectedNames: []string{"app", "plain"}},
+		{name: "combined", query: "spec.destination=10.0.0.50&spec.port=3000", expectedStatus: http.StatusOK, expectedNames: []string{"app"}},
+		{name: "no match", query: "spec.port=8080", expectedStatus: http.StatusOK, expectedNames: []string{}},
+		{name: "port not an integer", query: "spec.port=http", expectedStatus: http.StatusBadRequest},
+		{name: "tls not a boolean", query: "spec.tls=maybe", expectedStatus: http.StatusBadRequest},
+		{name: "unsupported field", query: "spec.domain=app.example.com", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/proxyrules?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			handler.GetProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				Items []struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				} `json:"items"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			names := []string{}
+			for _, item := range result.Items {
+				names = append(names, item.Metadata.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expectedNames) {
+				t.Errorf("expected %v, got %v", tt.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestIsCRDNotInstalled(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "resource not served",
+			err:  apierrors.NewGenericServerResponse(http.StatusNotFound, http.MethodGet, ProxyRulesGVR.GroupResource(), "test-rule", "404 page not found", 0, true),
+			want: true,
+		},
+		{
+			name: "no kind match",
+			err:  &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "bausteln.io", Kind: "Proxyrule"}},
+			want: true,
+		},
+		{
+			name: "rule not found",
+			err:  apierrors.NewNotFound(ProxyRulesGVR.GroupResource(), "test-rule"),
+		},
+		{
+			name: "other error",
+			err:  fmt.Errorf("connection refused"),
+		},
+		{
+			name: "no error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsCRDNotInstalled(tt.err); got != tt.want {
+				t.Errorf("IsCRDNotInstalled(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesStableETag(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("zeta", "proxy-rules", "zeta.example.com", "10.0.0.52", 3000)
+	fakeClient.SeedProxyRule("alpha", "proxy-rules", "alpha.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("mid", "proxy-rules", "mid.example.com", "10.0.0.51", 3000)
+
+	handler := NewProxyRulesHandler(fakeClient)
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.GetProxyRules(w, req)
+		return w
+	}
+
+	first := get("")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", first.Code, first.Body.String())
+	}
+	etag := first.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("expected a weak ETag, got %q", etag)
+	}
+
+	var result struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(first.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	var names []string
+	for _, item := range result.Items {
+		names = append(names, item.Metadata.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"alpha", "mid", "zeta"}) {
+		t.Errorf("expected items sorted by name, got %v", names)
+	}
+
+	for i := 0; i < 5; i++ {
+		again := get("")
+		if !bytes.Equal(again.Body.Bytes(), first.Body.Bytes()) {
+			t.Fatalf("expected identical bodies for identical requests")
+		}
+		if again.Header().Get("ETag") != etag {
+			t.Fatalf("expected ETag %q, got %q", etag, again.Header().Get("ETag"))
+		}
+	}
+
+	notModified := get(etag)
+	if notModified.Code != http.StatusNotModified {
+		t.Fatalf("expected status 304, got %d", notModified.Code)
+	}
+	if notModified.Body.Len() != 0 {
+		t.Errorf("expected empty body on 304, got %q", notModified.Body.String())
+	}
+	if notModified.Header().Get("ETag") != etag {
+		t.Errorf("expected ETag on 304")
+	}
+
+	// The strong form of the same tag and lists of tags match too
+	if w := get(`"other", ` + strings.TrimPrefix(etag, "W/")); w.Code != http.StatusNotModified {
+		t.Errorf("expected status 304 for a tag list, got %d", w.Code)
+	}
+	if w := get(`W/"other"`); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for a stale ETag, got %d", w.Code)
+	}
+
+	fakeClient.SeedProxyRule("beta", "proxy-rules", "beta.example.com", "10.0.0.53", 3000)
+	changed := get(etag)
+	if changed.Code != http.StatusOK {
+		t.Fatalf("expected status 200 after a change, got %d", changed.Code)
+	}
+	if changed.Header().Get("ETag") == etag {
+		t.Errorf("expected a new ETag after a change")
+	}
+}
+
+// blockingListClient holds proxy rule Lists until their context is cancelled
+// and reports each cancelled List on cancelled
+type blockingListClient struct {
+	*testutil.FakeDynamicClient
+	started   chan struct{}
+	cancelled chan error
+}
+
+func (c blockingListClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return blockingListResource{c.FakeDynamicClient.Resource(gvr), c}
+}
+
+type blockingListResource struct {
+	dynamic.NamespaceableResourceInterface
+	client blockingListClient
+}
+
+func (r blockingListResource) Namespace(ns string) dynamic.ResourceInterface {
+	return blockingListNamespacedResource{r.NamespaceableResourceInterface.Namespace(ns), r.client}
+}
+
+type blockingListNamespacedResource struct {
+	dynamic.ResourceInterface
+	client blockingListClient
+}
+
+func (r blockingListNamespacedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	r.client.started <- struct{}{}
+	<-ctx.Done()
+	r.client.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestProxyRulesHandler_CheckDuplicateDomainCancelled(t *testing.T) {
+	client := blockingListClient{
+		FakeDynamicClient: testutil.NewFakeDynamicClient(),
+		started:           make(chan struct{}, 1),
+		cancelled:         make(chan error, 1),
+	}
+	handler := NewProxyRulesHandler(client)
+	rule := testutil.NewProxyRule("new-rule", "new.example.com", "10.0.0.50", 3000)
+	rule.SetNamespace("proxy-rules")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := handler.checkDuplicateDomain(ctx, rule, "")
+		result <- err
+	}()
+
+	select {
+	case <-client.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the check to List the rules")
+	}
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected a context error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the check to return once its context was cancelled")
+	}
+
+	// No other request waits for the List, so it is aborted as well
+	select {
+	case err := <-client.cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the List context to be cancelled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the List to be cancelled")
+	}
+}
+
+func TestProxyRulesHandler_GetProxyRulesIfModifiedSince(t *testing.T) {
+	created := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	old := testutil.NewProxyRule("old-rule", "old.example.com", "10.0.0.50", 3000)
+	old.SetNamespace("proxy-rules")
+	old.SetCreationTimestamp(metav1.NewTime(created))
+	fakeClient.Seed(testutil.ProxyRulesGVR, old)
+
+	edited := testutil.NewProxyRule("edited-rule", "edited.example.com", "10.0.0.51", 3000)
+	edited.SetNamespace("proxy-rules")
+	edited.SetCreationTimestamp(metav1.NewTime(created))
+	editTime := metav1.NewTime(updated)
+	edited.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "mortar-backend", Operation: metav1.ManagedFieldsOperationUpdate, Time: &editTime},
+	})
+	fakeClient.Seed(testutil.ProxyRulesGVR, edited)
+
+	handler := NewProxyRulesHandler(fakeClient)
+	now := updated.Add(time.Hour)
+	handler.deletions.now = func() time.Time { return now }
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		handler.GetProxyRules(w, req)
+		return w
+	}
+
+	first := get("", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", first.Code, first.Body.String())
+	}
+	lastModified := first.Header().Get("Last-Modified")
+	if lastModified != updated.Format(http.TimeFormat) {
+		t.Fatalf("expected Last-Modified %q from managedFields, got %q", updated.Format(http.TimeFormat), lastModified)
+	}
+
+	if w := get("If-Modified-Since", lastModified); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected an empty 304 when nothing is newer, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get("If-Modified-Since", updated.Add(-time.Second).Format(http.TimeFormat)); w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for an older date, got %d", w.Code)
+	}
+	if w := get("If-Modified-Since", "not a date"); w.Code != http.StatusOK {
+		t.Errorf("expected an invalid date to be ignored, got %d", w.Code)
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	w := httptest.NewRecorder()
+	handler.GetProxyRules(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected a stale If-None-Match to win, got %d", w.Code)
+	}
+
+	// Deleting a rule leaves no timestamp behind, but still modifies the list
+	del := httptest.NewRequest(http.MethodDelete, "/api/proxyrules/old-rule", nil)
+	handler.DeleteProxyRule(httptest.NewRecorder(), del)
+
+	afterDelete := get("If-Modified-Since", lastModified)
+	if afterDelete.Code != http.StatusOK {
+		t.Fatalf("expected status 200 after a deletion, got %d", afterDelete.Code)
+	}
+	if got := afterDelete.Header().Get("Last-Modified"); got != now.Format(http.TimeFormat) {
+		t.Errorf("expected Last-Modified %q after a deletion, got %q", now.Format(http.TimeFormat), got)
+	}
+}
+
+func TestProxyRulesHandler_ImportProxyRules(t *testing.T) {
+	const backup = `---
+apiVersion: bausteln.io/v1
+kind: Proxyrule
+metadata:
+  name: app
+  uid: 0b4c1f0e-0000-0000-0000-000000000000
+  resourceVersion: "42"
+spec:
+  domain: app.example.com
+  destination: 10.0.0.50
+  port: 3000
+---
+metadata:
+  name: api
+spec:
+  domain: api.example.com
+  destination: 10.0.0.51
+`
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		query          string
+		expectedStatus int
+		expectedResult map[string]string
+		expectWritten  bool
+	}{
+		{
+			name:           "yaml backup",
+			contentType:    "application/yaml",
+			body:           backup,
+			expectedStatus: http.StatusOK,
+			expectedResult: map[string]string{"app": "created", "api": "created"},
+			expectWritten:  true,
+		},
+		{
+			name:           "json array",
+			contentType:    "application/json",
+			body:           `[{"metadata":{"name":"app"},"spec":{"domain":"app.example.com","destination":"10.0.0.50"}},{"metadata":{"name":"api"},"spec":{"domain":"api.example.com","destination":"10.0.0.51"}}]`,
+			expectedStatus: http.StatusOK,
+			expectedResult: map[string]string{"app": "created", "api": "created"},
+			expectWritten:  true,
+		},
+		{
+			name:           "one invalid rule rejects all",
+			contentType:    "application/yaml",
+			body:           backup + "---\nmetadata:\n  name: broken\nspec:\n  domain: broken.example.com\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResult: map[string]string{"app": "skipped", "api": "skipped", "broken": "error"},
+		},
+		{
+			name:           "conflicting domains within the import",
+			contentType:    "application/yaml",
+			body:           backup + "---\nmetadata:\n  name: app-copy\nspec:\n  domain: app.example.com\n  destination: 10.0.0.52\n",
+			expectedStatus: http.StatusConflict,
+			expectedResult: map[string]string{"app": "skipped", "api": "skipped", "app-copy": "error"},
+		},
+		{
+			name:           "domain of a stored rule",
+			contentType:    "application/yaml",
+			body:           "metadata:\n  name: taken\nspec:\n  domain: existing.example.com\n  destination: 10.0.0.52\n",
+			expectedStatus: http.StatusConflict,
+			expectedResult: map[string]string{"taken": "error"},
+		},
+		{
+			name:           "existing name without upsert",
+			contentType:    "application/yaml",
+			body:           "metadata:\n  name: existing-rule\nspec:\n  domain: existing.example.com\n  destination: 10.0.0.52\n",
+			expectedStatus: http.StatusConflict,
+			expectedResult: map[string]string{"existing-rule": "error"},
+		},
+		{
+			name:           "upsert",
+			contentType:    "application/yaml",
+			query:          "?mode=upsert",
+			body:           backup + "---\nmetadata:\n  name: existing-rule\nspec:\n  domain: existing.example.com\n  destination: 10.0.0.52\n",
+			expectedStatus: http.StatusOK,
+			expectedResult: map[string]string{"app": "created", "api": "created", "existing-rule": "updated"},
+			expectWritten:  true,
+		},
+		{
+			name:           "invalid mode",
+			contentType:    "application/yaml",
+			query:          "?mode=merge",
+			body:           backup,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty import",
+			contentType:    "application/yaml",
+			body:           "---\n---\n",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("existing-rule", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			handler.ImportProxyRules(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedResult != nil {
+				var response ImportResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				results := map[string]string{}
+				for _, result := range response.Results {
+					results[result.Name] = result.Status
+					if result.Status == "error" && result.Error == "" {
+						t.Errorf("expected an error message for %s", result.Name)
+					}
+				}
+				if !reflect.DeepEqual(results, tt.expectedResult) {
+					t.Errorf("expected results %v, got %v", tt.expectedResult, results)
+				}
+			}
+
+			resource := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules")
+			_, err := resource.Get(context.Background(), "app", metav1.GetOptions{})
+			if written := err == nil; written != tt.expectWritten {
+				t.Errorf("expected app written = %v, got %v", tt.expectWritten, written)
+			}
+			if tt.expectWritten {
+				app, _ := resource.Get(context.Background(), "app", metav1.GetOptions{})
+				if app.GetResourceVersion() == "42" || string(app.GetUID()) == "0b4c1f0e-0000-0000-0000-000000000000" {
+					t.Error("expected server-populated metadata of the backup to be ignored")
+				}
+			}
+			existing, _ := resource.Get(context.Background(), "existing-rule", metav1.GetOptions{})
+			destination, _, _ := unstructured.NestedString(existing.Object, "spec", "destination")
+			if wantUpdated := tt.expectedResult["existing-rule"] == "updated"; (destination == "10.0.0.52") != wantUpdated {
+				t.Errorf("expected existing-rule updated = %v, got destination %s", wantUpdated, destination)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DebugLogging(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(previous)
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("existing-rule", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	create := func(name, domain string) {
+		body := fmt.Sprintf(`{"metadata":{"name":%q},"spec":{"domain":%q,"destination":"10.0.0.51"}}`, name, domain)
+		req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		handler.CreateProxyRule(httptest.NewRecorder(), req)
+	}
+	create("new-rule", "new.example.com")
+	create("clashing-rule", "existing.example.com")
+
+	output := logs.String()
+	for _, want := range []string{
+		`msg="Parsed proxy rule" operation=create namespace=proxy-rules name=new-rule spec=`,
+		`msg="Duplicate domain check passed" namespace=proxy-rules rule=new-rule`,
+		`msg="Duplicate domain check found a conflict" namespace=proxy-rules rule=clashing-rule`,
+		`conflictingRule=existing-rule`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected debug log containing %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestProxyRulesHandler_History(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "test.example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	getHistory := func(t *testing.T, name string) (int, HistoryResponse) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.GetProxyRuleHistory(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules/"+name+"/history", nil))
+		var response HistoryResponse
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	if code, response := getHistory(t, "test-rule"); code != http.StatusOK || len(response.Entries) != 0 {
+		t.Fatalf("expected an empty history for a new rule, got %d %+v", code, response)
+	}
+	if code, _ := getHistory(t, "missing-rule"); code != http.StatusNotFound {
+		t.Errorf("expected 404 for a missing rule, got %d", code)
+	}
+
+	// A forged history in the request is replaced by the stored one
+	body := `{"metadata":{"annotations":{"bausteln.io/history":"[]","owner":"web"}},"spec":{"domain":"test.example.com","destination":"10.0.0.51","port":3000,"tls":true}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/test-rule", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = req.WithContext(audit.WithSubject(req.Context(), "alice"))
+	w := httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update failed: %d %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/proxyrules/test-rule", strings.NewReader(`{"spec":{"port":8080}}`))
+	req.Header.Set("Content-Type", mergePatchContentType)
+	w = httptest.NewRecorder()
+	handler.PatchProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch failed: %d %s", w.Code, w.Body.String())
+	}
+
+	_, response := getHistory(t, "test-rule")
+	if len(response.Entries) != 2 {
+		t.Fatalf("expected 2 history entries, got %+v", response.Entries)
+	}
+	first, second := response.Entries[0], response.Entries[1]
+	// The seeded rule has no destinationScheme, which normalization adds
+	if first.Operation != audit.OperationUpdate || first.Subject != "alice" || !reflect.DeepEqual(first.Changes, []string{"spec.destination", "spec.destinationScheme", "metadata.annotations"}) {
+		t.Errorf("unexpected first entry %+v", first)
+	}
+	if second.Operation != audit.OperationPatch || second.Subject != audit.SubjectFromContext(context.Background()) || !reflect.DeepEqual(second.Changes, []string{"spec.port"}) {
+		t.Errorf("unexpected second entry %+v", second)
+	}
+	if first.Timestamp.IsZero() {
+		t.Error("expected entries to carry a timestamp")
+	}
+}
+
+func TestRecordHistoryTrimsOldestEntries(t *testing.T) {
+	stored := testutil.NewProxyRule("test-rule", "test.example.com", "10.0.0.50", 3000)
+	for i := 0; i < maxHistoryEntries+5; i++ {
+		updated := stored.DeepCopy()
+		unstructured.SetNestedField(updated.Object, fmt.Sprintf("10.0.1.%d", i), "spec", "destination")
+		recordHistory(stored, updated, audit.OperationUpdate, strings.Repeat("s", i))
+		stored = updated
+	}
+
+	entries, err := ruleHistory(stored)
+	if err != nil {
+		t.Fatalf("failed to decode history: %v", err)
+	}
+	if len(entries) != maxHistoryEntries {
+		t.Fatalf("expected %d entries, got %d", maxHistoryEntries, len(entries))
+	}
+	if entries[0].Subject != strings.Repeat("s", 5) {
+		t.Errorf("expected the oldest entries to be dropped, first is from %q", entries[0].Subject)
+	}
+
+	// Unchanged writes add nothing
+	recordHistory(stored, stored.DeepCopy(), audit.OperationUpdate, "")
+	if entries, _ := ruleHistory(stored); len(entries) != maxHistoryEntries {
+		t.Errorf("expected an unchanged write not to be recorded, got %d entries", len(entries))
+	}
+
+	// Entries too large for the annotation are dropped as a whole
+	huge := []HistoryEntry{{Subject: strings.Repeat("x", maxHistoryBytes)}, {Subject: "recent"}}
+	var kept []HistoryEntry
+	if err := json.Unmarshal([]byte(encodeHistory(huge)), &kept); err != nil || len(kept) != 1 || kept[0].Subject != "recent" {
+		t.Errorf("expected only the recent entry to fit, got %+v (%v)", kept, err)
+	}
+}
+
+func TestProxyRulesHandler_DestinationLoops(t *testing.T) {
+	newHandler := func(detect bool) *ProxyRulesHandler {
+		fakeClient := testutil.NewFakeDynamicClient()
+		fakeClient.SeedProxyRule("frontend", "proxy-rules", "app.example.com", "backend.internal", 8080)
+		fakeClient.SeedProxyRule("wildcard", "proxy-rules", "*.apps.example.com", "10.0.0.50", 8080)
+		return NewProxyRulesHandler(fakeClient, WithDestinationLoopDetection(detect))
+	}
+	create := func(handler *ProxyRulesHandler, domain, destination string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"metadata":{"name":"new-rule"},"spec":{"domain":%q,"destination":%q,"port":443}}`, domain, destination)
+		req := httptest.NewRequest(http.MethodPost, "/api/proxyrules", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.CreateProxyRule(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		detect         bool
+		domain         string
+		destination    string
+		expectedStatus int
+		expectedError  string
+	}{
+		{name: "self loop always rejected", domain: "new.example.com", destination: "new.example.com", expectedStatus: http.StatusUnprocessableEntity, expectedError: "own domain 'new.example.com'"},
+		{name: "other rule's domain allowed when disabled", domain: "new.example.com", destination: "app.example.com", expectedStatus: http.StatusCreated},
+		{name: "other rule's domain", detect: true, domain: "new.example.com", destination: "APP.example.com", expectedStatus: http.StatusUnprocessableEntity, expectedError: "domain 'app.example.com' of rule 'frontend'"},
+		{name: "other rule's wildcard", detect: true, domain: "new.example.com", destination: "shop.apps.example.com", expectedStatus: http.StatusUnprocessableEntity, expectedError: "rule 'wildcard'"},
+		{name: "unrelated destination", detect: true, domain: "new.example.com", destination: "backend.internal", expectedStatus: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := create(newHandler(tt.detect), tt.domain, tt.destination)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("expected error mentioning %q, got %s", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+
+	// A rule being updated may point at a domain it gives up in the same write
+	handler := newHandler(true)
+	body := `{"spec":{"domain":"new.example.com","destination":"app.example.com","port":443}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/frontend", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected the update to pass, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Imported rules are checked against the stored rules and the earlier imported ones
+	imports := []struct {
+		name           string
+		detect         bool
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "stored rule's domain allowed when disabled",
+			body:           "metadata:\n  name: new-rule\nspec:\n  domain: new.example.com\n  destination: app.example.com\n  port: 443\n",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "stored rule's domain",
+			detect:         true,
+			body:           "metadata:\n  name: new-rule\nspec:\n  domain: new.example.com\n  destination: app.example.com\n  port: 443\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "domain 'app.example.com' of rule 'frontend'",
+		},
+		{
+			name:           "earlier imported rule's domain",
+			detect:         true,
+			body:           "metadata:\n  name: first\nspec:\n  domain: first.example.com\n  destination: 10.0.0.50\n  port: 443\n---\nmetadata:\n  name: second\nspec:\n  domain: second.example.com\n  destination: first.example.com\n  port: 443\n",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "domain 'first.example.com' of rule 'first'",
+		},
+	}
+	for _, tt := range imports {
+		t.Run("import/"+tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/yaml")
+			w := httptest.NewRecorder()
+			newHandler(tt.detect).ImportProxyRules(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedError != "" && !strings.Contains(w.Body.String(), tt.expectedError) {
+				t.Errorf("expected error mentioning %q, got %s", tt.expectedError, w.Body.String())
+			}
+		})
+	}
+}
+
+// refusingDeleteClient wraps the fake client so that deleting the named proxy rule fails
+type refusingDeleteClient struct {
+	*testutil.FakeDynamicClient
+	name string
+}
+
+func (c refusingDeleteClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return refusingDeleteResource{c.FakeDynamicClient.Resource(gvr), c.name}
+}
+
+type refusingDeleteResource struct {
+	dynamic.NamespaceableResourceInterface
+	name string
+}
+
+func (r refusingDeleteResource) Namespace(ns string) dynamic.ResourceInterface {
+	return refusingDeleteNamespacedResource{r.NamespaceableResourceInterface.Namespace(ns), r.name}
+}
+
+type refusingDeleteNamespacedResource struct {
+	dynamic.ResourceInterface
+	name string
+}
+
+func (r refusingDeleteNamespacedResource) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	if name == r.name {
+		return apierrors.NewInternalError(fmt.Errorf("delete refused"))
+	}
+	return r.ResourceInterface.Delete(ctx, name, options, subresources...)
+}
+
+func TestProxyRulesHandler_SetProxyRuleTLS(t *testing.T) {
+	tests := []struct {
+		name           string
+		rule           string
+		body           string
+		expectedStatus int
+		expectedTLS    bool
+	}{
+		{name: "disable", rule: "tls-rule", body: `{"enabled":false}`, expectedStatus: http.StatusOK, expectedTLS: false},
+		{name: "enable", rule: "tls-rule", body: `{"enabled":true}`, expectedStatus: http.StatusOK, expectedTLS: true},
+		{name: "missing enabled", rule: "tls-rule", body: `{}`, expectedStatus: http.StatusBadRequest, expectedTLS: true},
+		{name: "enabled not a boolean", rule: "tls-rule", body: `{"enabled":"no"}`, expectedStatus: http.StatusBadRequest, expectedTLS: true},
+		{name: "public profile requires tls", rule: "public-rule", body: `{"enabled":false}`, expectedStatus: http.StatusUnprocessableEntity, expectedTLS: true},
+		{name: "unknown rule", rule: "missing-rule", body: `{"enabled":false}`, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("tls-rule", "proxy-rules", "tls.example.com", "10.0.0.50", 3000)
+			public := testutil.NewProxyRule("public-rule", "public.example.com", "backend.example.com", 443)
+			public.Object["spec"].(map[string]interface{})["annotations"] = map[string]interface{}{validation.ValidationProfileAnnotation: "public"}
+			fakeClient.Seed(ProxyRulesGVR, public)
+			handler := NewProxyRulesHandler(fakeClient)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/"+tt.rule+"/tls", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.SetProxyRuleTLS(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus == http.StatusNotFound {
+				return
+			}
+
+			stored, err := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), tt.rule, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get rule: %v", err)
+			}
+			if tls, _, _ := unstructured.NestedBool(stored.Object, "spec", "tls"); tls != tt.expectedTLS {
+				t.Errorf("expected stored spec.tls %v, got %v", tt.expectedTLS, tls)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var updated unstructured.Unstructured
+			if err := json.Unmarshal(w.Body.Bytes(), &updated.Object); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if tls, _, _ := unstructured.NestedBool(updated.Object, "spec", "tls"); tls != tt.expectedTLS {
+				t.Errorf("expected returned spec.tls %v, got %v", tt.expectedTLS, tls)
+			}
+			// The rest of the spec is kept
+			if port, _, _ := unstructured.NestedInt64(stored.Object, "spec", "port"); port != 3000 {
+				t.Errorf("expected spec.port 3000 to be kept, got %d", port)
+			}
+			if domain, _, _ := unstructured.NestedString(stored.Object, "spec", "domain"); domain != "tls.example.com" {
+				t.Errorf("expected spec.domain to be kept, got %q", domain)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_CheckDomain(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("app-rule", "proxy-rules", "app.example.com", "10.0.0.50", 3000)
+	fakeClient.SeedProxyRule("wildcard-rule", "proxy-rules", "*.shop.example.com", "10.0.0.51", 3000)
+	handler := NewProxyRulesHandler(fakeClient)
+
+	tests := []struct {
+		name              string
+		body              string
+		expectedStatus    int
+		expectedAvailable bool
+		expectedRule      string
+		expectedDomain    string
+	}{
+		{name: "free domain", body: `{"domain":"new.example.com"}`, expectedStatus: http.StatusOK, expectedAvailable: true},
+		{name: "taken domain", body: `{"domain":"app.example.com"}`, expectedStatus: http.StatusOK, expectedRule: "app-rule", expectedDomain: "app.example.com"},
+		{name: "taken in another case with trailing dot", body: `{"domain":"App.Example.COM."}`, expectedStatus: http.StatusOK, expectedRule: "app-rule", expectedDomain: "app.example.com"},
+		{name: "covered by wildcard", body: `{"domain":"eu.shop.example.com"}`, expectedStatus: http.StatusOK, expectedRule: "wildcard-rule", expectedDomain: "*.shop.example.com"},
+		{name: "invalid domain", body: `{"domain":"bad..example.com"}`, expectedStatus: http.StatusUnprocessableEntity},
+		{name: "missing domain", body: `{}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/domains/check", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.CheckDomain(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response DomainCheckResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if response.Available != tt.expectedAvailable || response.ConflictingRule != tt.expectedRule || response.ConflictingDomain != tt.expectedDomain {
+				t.Errorf("expected available=%v conflictingRule=%q conflictingDomain=%q, got %+v", tt.expectedAvailable, tt.expectedRule, tt.expectedDomain, response)
+			}
+		})
+	}
+
+	// Checking reserves nothing
+	list, _ := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+	if len(list.Items) != 2 {
+		t.Errorf("expected the check not to write any rule, got %d rules", len(list.Items))
+	}
+}
+
+func TestProxyRulesHandler_RenameProxyRule(t *testing.T) {
+	tests := []struct {
+		name           string
+		rule           string
+		body           string
+		refuseDelete   bool
+		expectedStatus int
+		expectedRules  []string
+	}{
+		{name: "rename", rule: "old-rule", body: `{"newName":"new-rule"}`, expectedStatus: http.StatusCreated, expectedRules: []string{"new-rule", "other-rule"}},
+		{name: "missing new name", rule: "old-rule", body: `{}`, expectedStatus: http.StatusBadRequest, expectedRules: []string{"old-rule", "other-rule"}},
+		{name: "same name", rule: "old-rule", body: `{"newName":"old-rule"}`, expectedStatus: http.StatusBadRequest, expectedRules: []string{"old-rule", "other-rule"}},
+		{name: "invalid new name", rule: "old-rule", body: `{"newName":"New_Rule"}`, expectedStatus: http.StatusUnprocessableEntity, expectedRules: []string{"old-rule", "other-rule"}},
+		{name: "new name taken", rule: "old-rule", body: `{"newName":"other-rule"}`, expectedStatus: http.StatusConflict, expectedRules: []string{"old-rule", "other-rule"}},
+		{name: "unknown rule", rule: "missing-rule", body: `{"newName":"new-rule"}`, expectedStatus: http.StatusNotFound, expectedRules: []string{"old-rule", "other-rule"}},
+		{name: "delete fails and is rolled back", rule: "old-rule", body: `{"newName":"new-rule"}`, refuseDelete: true, expectedStatus: http.StatusInternalServerError, expectedRules: []string{"old-rule", "other-rule"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := testutil.NewFakeDynamicClient()
+			fakeClient.SeedProxyRule("old-rule", "proxy-rules", "old.example.com", "10.0.0.50", 3000)
+			fakeClient.SeedProxyRule("other-rule", "proxy-rules", "other.example.com", "10.0.0.51", 3000)
+			var client dynamic.Interface = fakeClient
+			if tt.refuseDelete {
+				client = refusingDeleteClient{fakeClient, "old-rule"}
+			}
+			recorder := testutil.NewFakeEventRecorder()
+			handler := NewProxyRulesHandler(client, WithEventRecorder(recorder))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/proxyrules/"+tt.rule+"/rename", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler.RenameProxyRule(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			list, _ := fakeClient.Resource(ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+			var names []string
+			for _, item := range list.Items {
+				names = append(names, item.GetName())
+			}
+			if !reflect.DeepEqual(names, tt.expectedRules) {
+				t.Errorf("expected rules %v, got %v", tt.expectedRules, names)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+
+			var renamed unstructured.Unstructured
+			if err := json.Unmarshal(w.Body.Bytes(), &renamed.Object); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+			if domain, _, _ := unstructured.NestedString(renamed.Object, "spec", "domain"); renamed.GetName() != "new-rule" || domain != "old.example.com" {
+				t.Errorf("expected the rule copied to new-rule, got %s with domain %s", renamed.GetName(), domain)
+			}
+			if w.Header().Get("Location") != "/api/proxyrules/new-rule" {
+				t.Errorf("expected Location of the renamed rule, got %q", w.Header().Get("Location"))
+			}
+			if len(recorder.Events) != 2 || recorder.Events[0].Reason != events.ReasonCreated || recorder.Events[1].Reason != events.ReasonDeleted {
+				t.Errorf("expected create and delete events, got %+v", recorder.Events)
+			}
+		})
+	}
+}
+
+func TestProxyRulesHandler_DomainLock(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("existing-rule", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+	handler := NewProxyRulesHandler(fakeClient, WithDomainLock("domain-lock"))
+
+	lockData := func(t *testing.T) map[string]string {
+		t.Helper()
+		cm, err := fakeClient.Resource(configMapGVR).Namespace("proxy-rules").Get(context.Background(), "domain-lock", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get lock: %v", err)
+		}
+		data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+		return data
+	}
+	setLockEntry := func(t *testing.T, key, value string) {
+		t.Helper()
+		cm, _ := fakeClient.Resource(configMapGVR).Namespace("proxy-rules").Get(context.Background(), "domain-lock", metav1.GetOptions{})
+		unstructured.SetNestedField(cm.Object, value, "data", key)
+		if _, err := fakeClient.Resource(configMapGVR).Namespace("proxy-rules").Update(context.Background(), cm, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("failed to update lock: %v", err)
+		}
+	}
+	create := func(name, domain, query string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"metadata":{"name":%q},"spec":{"domain":%q,"destination":"10.0.0.51","port":443}}`, name, domain)
+		req := httptest.NewRequest(http.MethodPost, "/api/proxyrules"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.CreateProxyRule(w, req)
+		return w
+	}
+
+	// The lock is seeded from the stored rules and confirmed after the write
+	if w := create("rule-a", "*.a.example.com", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got, want := lockData(t), map[string]string{"existing.example.com": "existing-rule", "_.a.example.com": "rule-a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected lock %v, got %v", want, got)
+	}
+
+	// A dry run leaves no reservation behind
+	if w := create("rule-dry", "dry.example.com", "?dryRun=All"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a dry run, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, reserved := lockData(t)["dry.example.com"]; reserved {
+		t.Error("expected the dry run's reservation to be rolled back")
+	}
+
+	// A write in progress on another replica holds its domain
+	setLockEntry(t, "b.example.com", fmt.Sprintf("rule-x/%d", time.Now().Unix()))
+	w := create("rule-b", "b.example.com", "")
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"conflictingRule":"rule-x"`) {
+		t.Fatalf("expected 409 naming rule-x, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Abandoned reservations and reservations of deleted rules are taken over
+	setLockEntry(t, "c.example.com", fmt.Sprintf("rule-y/%d", time.Now().Add(-2*pendingReservationTTL).Unix()))
+	setLockEntry(t, "d.example.com", "deleted-rule")
+	for name, domain := range map[string]string{"rule-c": "c.example.com", "rule-d": "d.example.com"} {
+		if w := create(name, domain, ""); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201 for %s, got %d: %s", domain, w.Code, w.Body.String())
+		}
+		if owner := lockData(t)[domain]; owner != name {
+			t.Errorf("expected %s to own %s, got %q", name, domain, owner)
+		}
+	}
+
+	// An update moves the rule's reservation to its new domain
+	req := httptest.NewRequest(http.MethodPut, "/api/proxyrules/rule-c", strings.NewReader(`{"spec":{"domain":"e.example.com","destination":"10.0.0.51","port":443}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.UpdateProxyRule(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	data := lockData(t)
+	if _, stillReserved := data["c.example.com"]; stillReserved || data["e.example.com"] != "rule-c" {
+		t.Errorf("expected rule-c to hold only e.example.com, got %v", data)
+	}
+
+	// Deleting a rule releases its domains
+	w = httptest.NewRecorder()
+	handler.DeleteProxyRule(w, httptest.NewRequest(http.MethodDelete, "/api/proxyrules/rule-a", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, reserved := lockData(t)["_.a.example.com"]; reserved {
+		t.Error("expected the deleted rule's domains to be released")
+	}
+}
+
+func TestDomainLockKey(t *testing.T) {
+	for domain, key := range map[string]string{
+		"Example.COM.":       "example.com",
+		"*.apps.example.com": "_.apps.example.com",
+	} {
+		if got := domainLockKey(domain); got != key {
+			t.Errorf("domainLockKey(%q) = %q, want %q", domain, got, key)
+		}
+		if got := domainFromLockKey(key); got != canonicalDomain(domain) {
+			t.Errorf("domainFromLockKey(%q) = %q, want %q", key, got, canonicalDomain(domain))
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// readOnlyRetryAfter is the Retry-After sent while writes are frozen. The
+// freeze has no known end, so this only paces clients that retry.
+const readOnlyRetryAfter = 5 * time.Minute
+
+// ReadOnlyMessage is the error returned for writes while the handler is read-only
+const ReadOnlyMessage = "Proxy rules are read-only during maintenance; try again later"
+
+// WithReadOnly rejects every write with 503 while reads keep working
+func WithReadOnly(readOnly bool) Option {
+	return func(h *ProxyRulesHandler) {
+		h.readOnly = readOnly
+	}
+}
+
+// ReadOnly reports whether writes are rejected
+func (h *ProxyRulesHandler) ReadOnly() bool {
+	return h.readOnly
+}
+
+// checkWritable responds 503 with a Retry-After and returns false if the
+// handler is read-only, before anything is sent to the cluster
+func (h *ProxyRulesHandler) checkWritable(w http.ResponseWriter) bool {
+	if !h.readOnly {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(readOnlyRetryAfter.Seconds())))
+	http.Error(w, ReadOnlyMessage, http.StatusServiceUnavailable)
+	return false
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RenameProxyRule gives a rule the name in the body's newName field. Kubernetes
+// names are immutable, so the rule is copied to the new name, after the same
+// validation and duplicate checks as a create, and the old rule is then
+// deleted; if that fails, the copy is deleted again. Responds 201 with the
+// renamed rule.
+//
+// This is not atomic: for a moment both rules exist, and ingresses generated
+// for the old name are only replaced once the controller catches up.
+func (h *ProxyRulesHandler) RenameProxyRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}/rename
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}/rename", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	request, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
+		return
+	}
+	newName, _ := request["newName"].(string)
+	if newName == "" {
+		http.Error(w, "newName is required", http.StatusBadRequest)
+		return
+	}
+	if newName == name {
+		http.Error(w, fmt.Sprintf("Proxy rule is already called '%s'", name), http.StatusBadRequest)
+		return
+	}
+
+	namespace := requestNamespace(r)
+	resource := h.dynamicClient.Resource(h.getGVR()).Namespace(namespace)
+
+	existing, err := resource.Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), status)
+		return
+	}
+
+	// Copy everything a client may set; the server fills in the rest
+	renamed := sanitizeForExport(existing)
+	renamed.SetName(newName)
+
+	validation.NormalizeProxyRule(renamed)
+	if validationErrs := validation.ValidateProxyRuleCreate(renamed, h.validationOptions); len(validationErrs) > 0 {
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+
+	if _, err := resource.Get(r.Context(), newName, metav1.GetOptions{}); err == nil {
+		writeDuplicateName(w, newName)
+		return
+	}
+
+	// The old rule gives up its domains to the copy
+	release, err := h.checkDuplicateDomain(r.Context(), renamed, name)
+	if err != nil && !h.allowDuplicate(w, err) {
+		writeDomainCheckError(w, err)
+		return
+	}
+	if h.rejectDestinationLoops(w, r, renamed, name) {
+		release(false)
+		return
+	}
+
+	result, err := resource.Create(r.Context(), renamed, metav1.CreateOptions{})
+	release(err == nil)
+	if err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			writeDuplicateName(w, newName)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error creating proxyrule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Only delete the rule as it was copied; a concurrent change fails the rename
+	rv := existing.GetResourceVersion()
+	err = resource.Delete(r.Context(), name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &rv}})
+	if err != nil && !apierrors.IsNotFound(err) {
+		rollbackErr := resource.Delete(r.Context(), newName, metav1.DeleteOptions{})
+		h.domains.invalidate(namespace)
+		if rollbackErr != nil {
+			http.Error(w, fmt.Sprintf("Error deleting proxyrule '%s': %v; the copy '%s' could not be removed either: %v", name, err, newName, rollbackErr), http.StatusInternalServerError)
+			return
+		}
+		status := http.StatusInternalServerError
+		if apierrors.IsConflict(err) {
+			status = http.StatusConflict
+		}
+		http.Error(w, fmt.Sprintf("Error deleting proxyrule '%s', the rename was rolled back: %v", name, err), status)
+		return
+	}
+	h.domains.invalidate(namespace)
+	h.deletions.record(namespace)
+	h.releaseDomains(r.Context(), namespace, name)
+
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonCreated, fmt.Sprintf("Proxy rule %s created by renaming %s", newName, name))
+	h.recorder.Event(h.newObjectReference(namespace, name), events.EventTypeNormal, events.ReasonDeleted, fmt.Sprintf("Proxy rule %s renamed to %s", name, newName))
+	h.auditMutation(r, audit.OperationCreate, newName, nil, result)
+	h.auditMutation(r, audit.OperationDelete, name, existing, nil)
+
+	w.Header().Set("Location", ruleURL(r, newName))
+	writeJSON(w, http.StatusCreated, result)
+}
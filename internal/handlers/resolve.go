@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DebugView shows a rule as stored next to the form the data plane will use
+type DebugView struct {
+	Raw      map[string]interface{} `json:"raw"`
+	Resolved map[string]interface{} `json:"resolved"`
+}
+
+// resolveProxyRule returns a copy of rule with normalization and all defaults
+// applied, i.e. the effective configuration the data plane acts on
+func resolveProxyRule(rule *unstructured.Unstructured) *unstructured.Unstructured {
+	resolved := rule.DeepCopy()
+	validation.NormalizeProxyRule(resolved)
+
+	spec, ok := resolved.Object["spec"].(map[string]interface{})
+	if !ok {
+		return resolved
+	}
+
+	if _, found := spec["destinationStrategy"]; !found {
+		spec["destinationStrategy"] = validation.DestinationStrategyRoundRobin
+	}
+	if _, found := spec["tls"]; !found {
+		spec["tls"] = true
+	}
+	if _, found := spec["enabled"]; !found {
+		spec["enabled"] = true
+	}
+	if _, found := spec["port"]; !found {
+		scheme, _ := spec["destinationScheme"].(string)
+		spec["port"] = int64(validation.DefaultDestinationPort(scheme))
+	}
+
+	return resolved
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Response bodies defined by this API (as opposed to the Kubernetes objects it
+// passes through) use camelCase JSON field names, matching the Kubernetes objects
+// served alongside them. New response types should follow the same convention
+// and be written with writeJSON.
+
+// ErrorResponse is the body of structured error responses
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a single error with a machine-readable code
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// ConflictingRule and ConflictingValue name the rule and the name or domain
+	// a 409 conflicts with
+	ConflictingRule  string `json:"conflictingRule,omitempty"`
+	ConflictingValue string `json:"conflictingValue,omitempty"`
+}
+
+// writeJSON writes v as the JSON response body with the given status code.
+// Encoding errors are only logged since the status has already been sent.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// WithSharedDestinationWarnings adds a warning to creates and updates whose
+// destination is already a destination of another rule in the namespace, which
+// is often a copy-paste mistake. The write still succeeds.
+func WithSharedDestinationWarnings(enabled bool) Option {
+	return func(h *ProxyRulesHandler) {
+		h.warnSharedDestinations = enabled
+	}
+}
+
+// addSharedDestinationWarnings adds a Warning header for each destination of
+// obj that another rule also points at, when the check is enabled. excludeName
+// is the rule being updated, whose stored destinations are replaced. The check
+// never fails the request: if the rules cannot be listed it is skipped.
+func (h *ProxyRulesHandler) addSharedDestinationWarnings(w http.ResponseWriter, r *http.Request, obj *unstructured.Unstructured, excludeName string) {
+	if !h.warnSharedDestinations {
+		return
+	}
+
+	destinations := getRuleDestinations(obj)
+	if len(destinations) == 0 {
+		return
+	}
+
+	list, err := h.listRules(r.Context(), obj.GetNamespace(), "")
+	if err != nil {
+		slog.Warn("Skipping shared destination check", "namespace", obj.GetNamespace(), "name", obj.GetName(), "error", err)
+		return
+	}
+
+	// Rules are listed in name order, so each warning names them in that order
+	users := make(map[string][]string)
+	for i := range list.Items {
+		rule := &list.Items[i]
+		if rule.GetName() == excludeName || rule.GetName() == obj.GetName() {
+			continue
+		}
+		for _, destination := range getRuleDestinations(rule) {
+			key := strings.ToLower(destination)
+			if len(users[key]) == 0 || users[key][len(users[key])-1] != rule.GetName() {
+				users[key] = append(users[key], rule.GetName())
+			}
+		}
+	}
+
+	warned := make(map[string]bool)
+	for _, destination := range destinations {
+		key := strings.ToLower(destination)
+		if warned[key] || len(users[key]) == 0 {
+			continue
+		}
+		warned[key] = true
+		addWarning(w, fmt.Sprintf("destination '%s' is also used by %s '%s'; check that the rules are meant to share a backend",
+			destination, pluralize(len(users[key]), "rule", "rules"), strings.Join(users[key], "', '")))
+	}
+}
+
+// pluralize returns singular for one and plural otherwise
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
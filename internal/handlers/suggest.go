@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxSuggestionCandidates bounds how many rule names are compared on a 404
+const maxSuggestionCandidates = 1000
+
+// NotFoundResponse is the body of a 404 for a rule name when suggestions are enabled
+type NotFoundResponse struct {
+	Message     string   `json:"message"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// WithNotFoundSuggestions makes a 404 on GET by name list up to max similarly
+// named rules; zero (the default) keeps the plain-text 404
+func WithNotFoundSuggestions(max int) Option {
+	return func(h *ProxyRulesHandler) {
+		h.notFoundSuggestions = max
+	}
+}
+
+// writeNotFoundWithSuggestions responds 404 for name, listing existing rules
+// whose names share a prefix with it or are a few edits away
+func (h *ProxyRulesHandler) writeNotFoundWithSuggestions(w http.ResponseWriter, r *http.Request, name string) {
+	response := NotFoundResponse{
+		Message:     fmt.Sprintf("Proxy rule '%s' not found", name),
+		Suggestions: []string{},
+	}
+
+	list, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).List(r.Context(), metav1.ListOptions{Limit: maxSuggestionCandidates})
+	if err == nil {
+		names := make([]string, 0, len(list.Items))
+		for _, item := range list.Items {
+			names = append(names, item.GetName())
+		}
+		response.Suggestions = suggestNames(name, names, h.notFoundSuggestions)
+	}
+
+	writeJSON(w, http.StatusNotFound, response)
+}
+
+// suggestNames returns up to max candidates similar to name, closest first.
+// A candidate is similar if one name is a prefix of the other or the edit
+// distance is at most a third of the name's length (minimum 2).
+func suggestNames(name string, candidates []string, max int) []string {
+	type match struct {
+		name     string
+		distance int
+	}
+
+	threshold := len(name) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	var matches []match
+	for _, candidate := range candidates {
+		distance := levenshtein(name, candidate)
+		if distance <= threshold || strings.HasPrefix(candidate, name) || strings.HasPrefix(name, candidate) {
+			matches = append(matches, match{name: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < max; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
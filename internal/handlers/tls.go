@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/events"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SetProxyRuleTLS sets spec.tls of a rule to the body's enabled field and
+// leaves the rest of the spec as stored. The rule is validated again, since
+// some profiles and settings depend on TLS. Responds 200 with the updated rule.
+func (h *ProxyRulesHandler) SetProxyRuleTLS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !h.checkWritable(w) {
+		return
+	}
+
+	// Extract rule name from path: /api/proxyrules/{name}/tls
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) != 4 || parts[2] == "" {
+		http.Error(w, "Invalid path format. Expected: /api/proxyrules/{name}/tls", http.StatusBadRequest)
+		return
+	}
+	name := parts[2]
+
+	// Validate request (content-type, body size)
+	if err := validation.ValidateJSONRequest(w, r, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := validation.ValidateRequestBody(body, h.maxBodyBytes); err != nil {
+		validation.HandleValidationError(w, r, err)
+		return
+	}
+
+	request, err := validation.DecodeRequestBody(r, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error %v", err), http.StatusBadRequest)
+		return
+	}
+	enabled, ok := request["enabled"].(bool)
+	if !ok {
+		http.Error(w, "enabled is required and must be true or false", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Get(r.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if apierrors.IsNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Error fetching proxyrule: %v", err), status)
+		return
+	}
+
+	updated := existing.DeepCopy()
+	if err := unstructured.SetNestedField(updated.Object, enabled, "spec", "tls"); err != nil {
+		http.Error(w, fmt.Sprintf("Error setting spec.tls: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+	if rv := ifMatchResourceVersion(r); rv != "" {
+		updated.SetResourceVersion(rv)
+	}
+
+	validation.NormalizeProxyRule(updated)
+	logParsedRule(audit.OperationPatch, updated)
+
+	if validationErrs := validation.ValidateProxyRuleUpdate(updated, h.validationOptions); len(validationErrs) > 0 {
+		h.recorder.Event(updated, events.EventTypeWarning, events.ReasonValidationFailed, validationErrs.Error())
+		validation.HandleValidationError(w, r, validationErrs)
+		return
+	}
+	h.addValidationWarnings(w, updated)
+
+	recordHistory(existing, updated, audit.OperationPatch, audit.SubjectFromContext(r.Context()))
+
+	result, err := h.dynamicClient.Resource(h.getGVR()).Namespace(requestNamespace(r)).Update(r.Context(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			if ifMatchResourceVersion(r) != "" {
+				http.Error(w, fmt.Sprintf("Proxy rule '%s' was modified since it was read (If-Match precondition failed)", name), http.StatusPreconditionFailed)
+				return
+			}
+			http.Error(w, fmt.Sprintf("Proxy rule '%s' was modified while TLS was set, please retry", name), http.StatusConflict)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Error updating proxyrule: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	h.recorder.Event(result, events.EventTypeNormal, events.ReasonUpdated, fmt.Sprintf("Proxy rule %s TLS %s", result.GetName(), state))
+	h.auditMutation(r, audit.OperationPatch, result.GetName(), existing, result)
+
+	setETag(w, result)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+		return
+	}
+}
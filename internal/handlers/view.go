@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// viewFull returns the complete Kubernetes object (the default)
+	viewFull = ""
+	// viewSpec returns only the name and spec of a rule
+	viewSpec = "spec"
+	// viewSummary returns a flat summary of each rule in a list
+	viewSummary = "summary"
+)
+
+// SpecView is a rule without its Kubernetes bookkeeping (managedFields,
+// resourceVersion, ...), as returned with ?view=spec
+type SpecView struct {
+	Name string                 `json:"name"`
+	Spec map[string]interface{} `json:"spec"`
+}
+
+// SummaryView is the flat form of a rule returned in lists with ?view=summary,
+// with the resolved port and tls so defaults show up
+type SummaryView struct {
+	Name   string `json:"name"`
+	Domain string `json:"domain"`
+	// Destination is spec.destination, or the first of spec.destinations
+	Destination string    `json:"destination"`
+	Port        int64     `json:"port"`
+	TLS         bool      `json:"tls"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// UpdatedAt is the last update through this backend, or CreatedAt if there was none
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SummaryList is the body of a list with ?view=summary
+type SummaryList struct {
+	Items []SummaryView `json:"items"`
+}
+
+// parseView returns the view query parameter after checking that it is one of
+// views or the full view
+func parseView(r *http.Request, views ...string) (string, error) {
+	view := r.URL.Query().Get("view")
+	if view == viewFull {
+		return view, nil
+	}
+	for _, known := range views {
+		if view == known {
+			return view, nil
+		}
+	}
+	return "", fmt.Errorf("unknown view '%s' (must be %s)", view, strings.Join(views, " or "))
+}
+
+// newSpecView returns the spec view of rule
+func newSpecView(rule *unstructured.Unstructured) SpecView {
+	spec, _, _ := unstructured.NestedMap(rule.Object, "spec")
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	return SpecView{Name: rule.GetName(), Spec: spec}
+}
+
+// newSummaryList returns the summary view of rules
+func newSummaryList(rules []unstructured.Unstructured) SummaryList {
+	list := SummaryList{Items: make([]SummaryView, 0, len(rules))}
+	for i := range rules {
+		list.Items = append(list.Items, newSummaryView(&rules[i]))
+	}
+	return list
+}
+
+// newSummaryView returns the summary view of rule
+func newSummaryView(rule *unstructured.Unstructured) SummaryView {
+	resolved := resolveProxyRule(rule)
+
+	summary := SummaryView{
+		Name:      rule.GetName(),
+		CreatedAt: rule.GetCreationTimestamp().UTC(),
+	}
+	summary.Domain, _, _ = unstructured.NestedString(resolved.Object, "spec", "domain")
+	if destinations := getRuleDestinations(resolved); len(destinations) > 0 {
+		summary.Destination = destinations[0]
+	}
+	port, _, _ := unstructured.NestedFieldNoCopy(resolved.Object, "spec", "port")
+	switch port := port.(type) {
+	case int64:
+		summary.Port = port
+	case float64:
+		summary.Port = int64(port)
+	}
+	summary.TLS, _, _ = unstructured.NestedBool(resolved.Object, "spec", "tls")
+
+	summary.UpdatedAt = summary.CreatedAt
+	if updatedAt, err := time.Parse(time.RFC3339, rule.GetAnnotations()[UpdatedAtAnnotation]); err == nil {
+		summary.UpdatedAt = updatedAt.UTC()
+	}
+	return summary
+}
@@ -0,0 +1,137 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultCacheResyncPeriod is how often the informer replays its cache to event handlers
+const DefaultCacheResyncPeriod = 10 * time.Minute
+
+// ResourceCache keeps a watch-backed local copy of one resource across all
+// namespaces. Reads return deep copies, so callers may modify them.
+type ResourceCache struct {
+	factory  dynamicinformer.DynamicSharedInformerFactory
+	informer informers.GenericInformer
+	now      func() time.Time
+
+	mu           sync.Mutex
+	staleSince   time.Time
+	staleVersion string
+}
+
+// NewResourceCache creates a cache of gvr; call Start to begin watching
+func NewResourceCache(client dynamic.Interface, gvr schema.GroupVersionResource) *ResourceCache {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, DefaultCacheResyncPeriod)
+	c := &ResourceCache{
+		factory:  factory,
+		informer: factory.ForResource(gvr),
+		now:      time.Now,
+	}
+
+	informer := c.informer.Informer()
+	// Only fails once the informer has started
+	_ = informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, r *cache.Reflector, err error) {
+		c.markStale()
+		cache.DefaultWatchErrorHandler(ctx, r, err)
+	})
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { c.markFresh() },
+		UpdateFunc: func(interface{}, interface{}) { c.markFresh() },
+		DeleteFunc: func(interface{}) { c.markFresh() },
+	})
+	return c
+}
+
+// Start begins watching and blocks until the initial list has been loaded,
+// ctx is done or timeout has passed. Watching stops when ctx is done.
+func (c *ResourceCache) Start(ctx context.Context, timeout time.Duration) error {
+	c.factory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), c.informer.Informer().HasSynced) {
+		return fmt.Errorf("timed out after %v waiting for the cache to sync", timeout)
+	}
+	return nil
+}
+
+// HasSynced reports whether the initial list has been loaded
+func (c *ResourceCache) HasSynced() bool {
+	return c.informer.Informer().HasSynced()
+}
+
+// List returns the cached objects in namespace matching selector
+func (c *ResourceCache) List(namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	objects, err := c.informer.Lister().ByNamespace(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			items = append(items, u.DeepCopy())
+		}
+	}
+	return items, nil
+}
+
+// Get returns the cached object, or false if it is not in the cache
+func (c *ResourceCache) Get(namespace, name string) (*unstructured.Unstructured, bool) {
+	obj, err := c.informer.Lister().ByNamespace(namespace).Get(name)
+	if err != nil {
+		return nil, false
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+	return u.DeepCopy(), true
+}
+
+// Staleness returns how long the watch has been broken, or zero while it is
+// connected. A relist after reconnecting advances the informer's resource
+// version, which marks the cache fresh again even if nothing changed.
+func (c *ResourceCache) Staleness() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.staleSince.IsZero() {
+		return 0
+	}
+	if c.informer.Informer().LastSyncResourceVersion() != c.staleVersion {
+		c.staleSince = time.Time{}
+		return 0
+	}
+	return c.now().Sub(c.staleSince)
+}
+
+// markStale records that the watch failed, unless it was already broken
+func (c *ResourceCache) markStale() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.staleSince.IsZero() {
+		c.staleSince = c.now()
+		c.staleVersion = c.informer.Informer().LastSyncResourceVersion()
+	}
+}
+
+// markFresh records that an event arrived, proving the watch works
+func (c *ResourceCache) markFresh() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.staleSince = time.Time{}
+}
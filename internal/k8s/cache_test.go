@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var testRuleGVR = schema.GroupVersionResource{Group: "bausteln.io", Version: "v1", Resource: "proxyrules"}
+
+func testRule(namespace, name, team string) *unstructured.Unstructured {
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "bausteln.io/v1",
+		"kind":       "Proxyrule",
+	}}
+	rule.SetNamespace(namespace)
+	rule.SetName(name)
+	rule.SetLabels(map[string]string{"team": team})
+	return rule
+}
+
+func TestResourceCache(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{testRuleGVR: "ProxyruleList"},
+		testRule("proxy-rules", "web", "frontend"),
+		testRule("proxy-rules", "api", "backend"),
+		testRule("other", "web", "frontend"),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := NewResourceCache(client, testRuleGVR)
+	if err := c.Start(ctx, 5*time.Second); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !c.HasSynced() {
+		t.Fatal("expected cache to have synced")
+	}
+
+	items, err := c.List("proxy-rules", labels.SelectorFromSet(labels.Set{"team": "frontend"}))
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(items) != 1 || items[0].GetName() != "web" || items[0].GetNamespace() != "proxy-rules" {
+		t.Errorf("List() = %v, want only proxy-rules/web", items)
+	}
+
+	rule, ok := c.Get("proxy-rules", "api")
+	if !ok {
+		t.Fatal("expected proxy-rules/api in cache")
+	}
+	// Callers get copies and must not be able to corrupt the cache
+	rule.SetLabels(nil)
+	if cached, _ := c.Get("proxy-rules", "api"); cached.GetLabels()["team"] != "backend" {
+		t.Error("modifying a returned rule changed the cache")
+	}
+
+	if _, ok := c.Get("proxy-rules", "missing"); ok {
+		t.Error("expected a miss for an unknown rule")
+	}
+
+	// Writes made through the client show up once the watch delivers them
+	if _, err := client.Resource(testRuleGVR).Namespace("proxy-rules").Create(ctx, testRule("proxy-rules", "new", "frontend"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := c.Get("proxy-rules", "new"); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("created rule never appeared in the cache")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestResourceCacheStaleness(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{testRuleGVR: "ProxyruleList"})
+	c := NewResourceCache(client, testRuleGVR)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	if got := c.Staleness(); got != 0 {
+		t.Errorf("Staleness() = %v before any watch error, want 0", got)
+	}
+
+	c.markStale()
+	now = now.Add(time.Minute)
+	// A second error while still broken must not reset the start
+	c.markStale()
+	now = now.Add(time.Minute)
+	if got := c.Staleness(); got != 2*time.Minute {
+		t.Errorf("Staleness() = %v, want 2m", got)
+	}
+
+	c.markFresh()
+	if got := c.Staleness(); got != 0 {
+		t.Errorf("Staleness() = %v after an event, want 0", got)
+	}
+}
@@ -9,25 +9,36 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+const (
+	// ConfigSourceInCluster means the client authenticates with the pod's ServiceAccount
+	ConfigSourceInCluster = "in-cluster"
+	// ConfigSourceKubeconfig means the client uses ~/.kube/config
+	ConfigSourceKubeconfig = "kubeconfig"
+)
+
 // NewDynamicClient creates a new Kubernetes dynamic client
 // It first tries to use in-cluster config (when running in a pod with ServiceAccount)
 // If that fails, it falls back to using kubeconfig file (for local development)
-func NewDynamicClient() (dynamic.Interface, error) {
+// The returned source is ConfigSourceInCluster or ConfigSourceKubeconfig.
+func NewDynamicClient() (client dynamic.Interface, source string, err error) {
 	// Try in-cluster config first (for production deployment)
+	source = ConfigSourceInCluster
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		// Fall back to kubeconfig file (for local development)
+		source = ConfigSourceKubeconfig
 		home, err := os.UserHomeDir()
 		if err != nil {
-			return nil, err
+			return nil, source, err
 		}
 		kubeconfig := filepath.Join(home, ".kube", "config")
 
 		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
 		if err != nil {
-			return nil, err
+			return nil, source, err
 		}
 	}
 
-	return dynamic.NewForConfig(config)
+	client, err = dynamic.NewForConfig(config)
+	return client, source, err
 }
@@ -0,0 +1,191 @@
+package k8s
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// ClientCalls counts Kubernetes API calls by verb and result
+var ClientCalls = metrics.NewCounterVec(
+	"mortar_k8s_client_calls_total",
+	"Kubernetes API calls made by the backend, by verb and result.",
+	"verb", "result",
+)
+
+// ClientCallDuration tracks the time spent in Kubernetes API calls by verb
+var ClientCallDuration = metrics.NewSummaryVec(
+	"mortar_k8s_client_call_duration_seconds",
+	"Time spent in Kubernetes API calls made by the backend, by verb.",
+	"verb",
+)
+
+func init() {
+	metrics.DefaultRegistry.Register(ClientCalls, ClientCallDuration)
+}
+
+const (
+	// DefaultSlowCallThreshold is the duration above which calls are logged as slow
+	DefaultSlowCallThreshold = 1 * time.Second
+)
+
+// InstrumentedDynamicClient wraps a dynamic.Interface, counting and timing every
+// call and logging calls slower than SlowCallThreshold
+type InstrumentedDynamicClient struct {
+	// SlowCallThreshold is the duration above which a call is logged; zero disables logging
+	SlowCallThreshold time.Duration
+
+	delegate dynamic.Interface
+	logf     func(format string, args ...interface{})
+}
+
+// NewInstrumentedDynamicClient wraps client so all calls are counted and timed
+func NewInstrumentedDynamicClient(client dynamic.Interface) *InstrumentedDynamicClient {
+	return &InstrumentedDynamicClient{
+		SlowCallThreshold: DefaultSlowCallThreshold,
+		delegate:          client,
+		logf:              log.Printf,
+	}
+}
+
+// Resource returns an instrumented namespace-able resource interface
+func (c *InstrumentedDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	delegate := c.delegate.Resource(resource)
+	return &instrumentedResource{
+		client:        c,
+		gvr:           resource,
+		delegate:      delegate,
+		namespaceable: delegate,
+	}
+}
+
+// instrumentedResource records metrics for calls on a (possibly namespaced) resource
+type instrumentedResource struct {
+	client        *InstrumentedDynamicClient
+	gvr           schema.GroupVersionResource
+	namespace     string
+	delegate      dynamic.ResourceInterface
+	namespaceable dynamic.NamespaceableResourceInterface
+}
+
+func (r *instrumentedResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &instrumentedResource{
+		client:        r.client,
+		gvr:           r.gvr,
+		namespace:     ns,
+		delegate:      r.namespaceable.Namespace(ns),
+		namespaceable: r.namespaceable,
+	}
+}
+
+func (r *instrumentedResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.Create(ctx, obj, options, subresources...)
+	r.record("create", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.Update(ctx, obj, options, subresources...)
+	r.record("update", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.UpdateStatus(ctx, obj, options)
+	r.record("updatestatus", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	start := time.Now()
+	err := r.delegate.Delete(ctx, name, options, subresources...)
+	r.record("delete", start, err)
+	return err
+}
+
+func (r *instrumentedResource) DeleteCollection(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	start := time.Now()
+	err := r.delegate.DeleteCollection(ctx, options, listOptions)
+	r.record("deletecollection", start, err)
+	return err
+}
+
+func (r *instrumentedResource) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.Get(ctx, name, options, subresources...)
+	r.record("get", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	start := time.Now()
+	result, err := r.delegate.List(ctx, opts)
+	r.record("list", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	start := time.Now()
+	result, err := r.delegate.Watch(ctx, opts)
+	r.record("watch", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.Patch(ctx, name, pt, data, options, subresources...)
+	r.record("patch", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) Apply(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.Apply(ctx, name, obj, options, subresources...)
+	r.record("apply", start, err)
+	return result, err
+}
+
+func (r *instrumentedResource) ApplyStatus(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions) (*unstructured.Unstructured, error) {
+	start := time.Now()
+	result, err := r.delegate.ApplyStatus(ctx, name, obj, options)
+	r.record("applystatus", start, err)
+	return result, err
+}
+
+// record counts and times a call to verb, logging it if it was slow
+func (r *instrumentedResource) record(verb string, start time.Time, err error) {
+	elapsed := time.Since(start)
+
+	ClientCalls.Inc(verb, resultLabel(err))
+	ClientCallDuration.Observe(elapsed.Seconds(), verb)
+
+	if threshold := r.client.SlowCallThreshold; threshold > 0 && elapsed > threshold {
+		r.client.logf("Slow Kubernetes API call: %s %s in namespace %q took %v (result: %s)", verb, r.gvr.Resource, r.namespace, elapsed, resultLabel(err))
+	}
+}
+
+// resultLabel classifies the outcome of a Kubernetes API call
+func resultLabel(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case apierrors.IsNotFound(err):
+		return "notfound"
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return "conflict"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestInstrumentedDynamicClient_CountsCalls(t *testing.T) {
+	client := NewInstrumentedDynamicClient(testutil.NewFakeDynamicClient())
+	resource := client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules")
+
+	createSuccess := ClientCalls.Value("create", "success")
+	createConflict := ClientCalls.Value("create", "conflict")
+	getNotFound := ClientCalls.Value("get", "notfound")
+
+	rule := testutil.NewProxyRule("metrics-rule", "metrics.example.com", "10.0.0.50", 3000)
+
+	if _, err := resource.Create(context.Background(), rule, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unexpected error creating rule: %v", err)
+	}
+	if _, err := resource.Create(context.Background(), rule, metav1.CreateOptions{}); err == nil {
+		t.Fatal("expected conflict creating rule twice")
+	}
+	if _, err := resource.Get(context.Background(), "missing", metav1.GetOptions{}); err == nil {
+		t.Fatal("expected not found error")
+	}
+
+	if got := ClientCalls.Value("create", "success") - createSuccess; got != 1 {
+		t.Errorf("expected 1 successful create, got %d", got)
+	}
+	if got := ClientCalls.Value("create", "conflict") - createConflict; got != 1 {
+		t.Errorf("expected 1 conflicting create, got %d", got)
+	}
+	if got := ClientCalls.Value("get", "notfound") - getNotFound; got != 1 {
+		t.Errorf("expected 1 not found get, got %d", got)
+	}
+}
+
+func TestInstrumentedDynamicClient_ForwardsCalls(t *testing.T) {
+	fake := testutil.NewFakeDynamicClient()
+	fake.SeedProxyRule("existing", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+
+	client := NewInstrumentedDynamicClient(fake)
+	resource := client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules")
+
+	// Results come from the underlying client
+	got, err := resource.Get(context.Background(), "existing", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	domain, _, _ := unstructured.NestedString(got.Object, "spec", "domain")
+	if domain != "existing.example.com" {
+		t.Errorf("expected forwarded object, got domain %q", domain)
+	}
+
+	// Errors are returned unchanged
+	_, err = resource.Get(context.Background(), "missing", metav1.GetOptions{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected forwarded not found error, got %v", err)
+	}
+
+	// Writes reach the underlying client
+	if err := resource.Delete(context.Background(), "existing", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("unexpected error deleting: %v", err)
+	}
+	if _, err := fake.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "existing", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected rule to be deleted in underlying client, got %v", err)
+	}
+}
+
+func TestInstrumentedDynamicClient_RecordsTiming(t *testing.T) {
+	client := NewInstrumentedDynamicClient(testutil.NewFakeDynamicClient())
+
+	var logged []string
+	client.logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	// Every call is slower than a nanosecond, so every call is logged
+	client.SlowCallThreshold = time.Nanosecond
+
+	listCount := ClientCallDuration.Count("list")
+
+	if _, err := client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := ClientCallDuration.Count("list") - listCount; got != 1 {
+		t.Errorf("expected 1 timed list call, got %d", got)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "list proxyrules") {
+		t.Errorf("expected slow list call to be logged, got %v", logged)
+	}
+
+	// Disabling the threshold stops slow-call logging
+	client.SlowCallThreshold = 0
+	client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{})
+	if len(logged) != 1 {
+		t.Errorf("expected no additional log lines, got %v", logged)
+	}
+}
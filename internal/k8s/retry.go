@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// DefaultRetryMaxAttempts is how often a call is tried in total before its error is returned
+	DefaultRetryMaxAttempts = 3
+	// DefaultRetryBaseDelay is the wait before the first retry; it doubles with every further retry
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// maxRetryDelay caps a single wait, including delays the API server asks for
+	maxRetryDelay = 5 * time.Second
+)
+
+// RetryingDynamicClient wraps a dynamic.Interface, retrying calls that fail with
+// transient errors (throttling, server timeouts, connection resets) with
+// exponential backoff. Other errors, such as not found, invalid or conflict,
+// are returned immediately: resending an object whose resourceVersion conflicts
+// cannot succeed, so conflicts are left to the caller.
+type RetryingDynamicClient struct {
+	delegate    dynamic.Interface
+	maxAttempts int
+	baseDelay   time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetryingDynamicClient wraps client so transient errors are retried up to
+// maxAttempts calls in total, waiting baseDelay, 2*baseDelay, ... in between
+func NewRetryingDynamicClient(client dynamic.Interface, maxAttempts int, baseDelay time.Duration) *RetryingDynamicClient {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryingDynamicClient{
+		delegate:    client,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		sleep:       sleepContext,
+	}
+}
+
+// Resource returns a retrying namespace-able resource interface
+func (c *RetryingDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	delegate := c.delegate.Resource(resource)
+	return &retryingResource{
+		client:        c,
+		delegate:      delegate,
+		namespaceable: delegate,
+	}
+}
+
+// isRetryable reports whether err is transient and the call may be repeated as is
+func isRetryable(err error) bool {
+	return apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		utilnet.IsConnectionReset(err)
+}
+
+// do runs call until it succeeds, fails with a non-retryable error, the
+// attempts are used up or ctx is done
+func (c *RetryingDynamicClient) do(ctx context.Context, call func() error) error {
+	delay := c.baseDelay
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= c.maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		wait := delay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if wait > maxRetryDelay {
+			wait = maxRetryDelay
+		}
+		if sleepErr := c.sleep(ctx, wait); sleepErr != nil {
+			return err
+		}
+		delay *= 2
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryingResource retries calls on a (possibly namespaced) resource
+type retryingResource struct {
+	client        *RetryingDynamicClient
+	delegate      dynamic.ResourceInterface
+	namespaceable dynamic.NamespaceableResourceInterface
+}
+
+func (r *retryingResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &retryingResource{
+		client:        r.client,
+		delegate:      r.namespaceable.Namespace(ns),
+		namespaceable: r.namespaceable,
+	}
+}
+
+func (r *retryingResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.Create(ctx, obj, options, subresources...)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.Update(ctx, obj, options, subresources...)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.UpdateStatus(ctx, obj, options)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) Delete(ctx context.Context, name string, options metav1.DeleteOptions, subresources ...string) error {
+	return r.client.do(ctx, func() error {
+		return r.delegate.Delete(ctx, name, options, subresources...)
+	})
+}
+
+func (r *retryingResource) DeleteCollection(ctx context.Context, options metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	return r.client.do(ctx, func() error {
+		return r.delegate.DeleteCollection(ctx, options, listOptions)
+	})
+}
+
+func (r *retryingResource) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.Get(ctx, name, options, subresources...)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) List(ctx context.Context, opts metav1.ListOptions) (result *unstructured.UnstructuredList, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.List(ctx, opts)
+		return err
+	})
+	return result, err
+}
+
+// Watch is not retried: a watch is long-lived and its consumer handles restarts
+func (r *retryingResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return r.delegate.Watch(ctx, opts)
+}
+
+func (r *retryingResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.Patch(ctx, name, pt, data, options, subresources...)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) Apply(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions, subresources ...string) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.Apply(ctx, name, obj, options, subresources...)
+		return err
+	})
+	return result, err
+}
+
+func (r *retryingResource) ApplyStatus(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions) (result *unstructured.Unstructured, err error) {
+	err = r.client.do(ctx, func() error {
+		result, err = r.delegate.ApplyStatus(ctx, name, obj, options)
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,197 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// flakyClient fails the first failures calls to Get and Create with err
+type flakyClient struct {
+	dynamic.Interface
+	err      error
+	failures int
+	calls    int
+}
+
+func (c *flakyClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return flakyResource{NamespaceableResourceInterface: c.Interface.Resource(gvr), client: c}
+}
+
+type flakyResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *flakyClient
+}
+
+func (r flakyResource) Namespace(ns string) dynamic.ResourceInterface {
+	return flakyNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), client: r.client}
+}
+
+type flakyNamespacedResource struct {
+	dynamic.ResourceInterface
+	client *flakyClient
+}
+
+func (r flakyNamespacedResource) fail() error {
+	r.client.calls++
+	if r.client.calls <= r.client.failures {
+		return r.client.err
+	}
+	return nil
+}
+
+func (r flakyNamespacedResource) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return r.ResourceInterface.Get(ctx, name, options, subresources...)
+}
+
+func (r flakyNamespacedResource) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := r.fail(); err != nil {
+		return nil, err
+	}
+	return r.ResourceInterface.Create(ctx, obj, options, subresources...)
+}
+
+func TestRetryingDynamicClient(t *testing.T) {
+	gr := testutil.ProxyRulesGVR.GroupResource()
+
+	tests := []struct {
+		name          string
+		err           error
+		failures      int
+		expectedCalls int
+		expectedSleep []time.Duration
+		wantError     bool
+	}{
+		{
+			name:          "no error",
+			expectedCalls: 1,
+		},
+		{
+			name:          "throttled then succeeds",
+			err:           apierrors.NewTooManyRequests("slow down", 0),
+			failures:      2,
+			expectedCalls: 3,
+			expectedSleep: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:          "server timeout then succeeds",
+			err:           apierrors.NewServerTimeout(gr, "get", 0),
+			failures:      1,
+			expectedCalls: 2,
+			expectedSleep: []time.Duration{10 * time.Millisecond},
+		},
+		{
+			name:          "connection reset then succeeds",
+			err:           os.NewSyscallError("read", syscall.ECONNRESET),
+			failures:      1,
+			expectedCalls: 2,
+			expectedSleep: []time.Duration{10 * time.Millisecond},
+		},
+		{
+			name:          "server asks for a longer delay",
+			err:           apierrors.NewTooManyRequests("slow down", 2),
+			failures:      1,
+			expectedCalls: 2,
+			expectedSleep: []time.Duration{2 * time.Second},
+		},
+		{
+			name:          "attempts exhausted",
+			err:           apierrors.NewTooManyRequests("slow down", 0),
+			failures:      5,
+			expectedCalls: 3,
+			expectedSleep: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+			wantError:     true,
+		},
+		{
+			name:          "not found is not retried",
+			err:           apierrors.NewNotFound(gr, "rule"),
+			failures:      1,
+			expectedCalls: 1,
+			wantError:     true,
+		},
+		{
+			name:          "invalid is not retried",
+			err:           apierrors.NewBadRequest("invalid"),
+			failures:      1,
+			expectedCalls: 1,
+			wantError:     true,
+		},
+		{
+			name:          "conflict is not retried",
+			err:           apierrors.NewConflict(gr, "rule", errors.New("modified")),
+			failures:      1,
+			expectedCalls: 1,
+			wantError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := testutil.NewFakeDynamicClient()
+			fake.SeedProxyRule("rule", "proxy-rules", "rule.example.com", "10.0.0.50", 3000)
+			flaky := &flakyClient{Interface: fake, err: tt.err, failures: tt.failures}
+
+			client := NewRetryingDynamicClient(flaky, 3, 10*time.Millisecond)
+			var slept []time.Duration
+			client.sleep = func(ctx context.Context, d time.Duration) error {
+				slept = append(slept, d)
+				return nil
+			}
+
+			_, err := client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "rule", metav1.GetOptions{})
+
+			if (err != nil) != tt.wantError {
+				t.Fatalf("Get() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError && !errors.Is(err, tt.err) && err.Error() != tt.err.Error() {
+				t.Errorf("expected the last error to be returned, got %v", err)
+			}
+			if flaky.calls != tt.expectedCalls {
+				t.Errorf("expected %d calls, got %d", tt.expectedCalls, flaky.calls)
+			}
+			if len(slept) != len(tt.expectedSleep) {
+				t.Fatalf("expected waits %v, got %v", tt.expectedSleep, slept)
+			}
+			for i := range slept {
+				if slept[i] != tt.expectedSleep[i] {
+					t.Errorf("expected waits %v, got %v", tt.expectedSleep, slept)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRetryingDynamicClient_StopsOnContextCancel(t *testing.T) {
+	flaky := &flakyClient{
+		Interface: testutil.NewFakeDynamicClient(),
+		err:       apierrors.NewTooManyRequests("slow down", 0),
+		failures:  5,
+	}
+	client := NewRetryingDynamicClient(flaky, 5, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rule := testutil.NewProxyRule("rule", "rule.example.com", "10.0.0.50", 3000)
+	_, err := client.Resource(testutil.ProxyRulesGVR).Namespace("proxy-rules").Create(ctx, rule, metav1.CreateOptions{})
+	if !apierrors.IsTooManyRequests(err) {
+		t.Errorf("expected the API error after cancellation, got %v", err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("expected no retries after cancellation, got %d calls", flaky.calls)
+	}
+}
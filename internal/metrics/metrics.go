@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Collector is a metric that can write itself in the Prometheus text format
+type Collector interface {
+	Write(w io.Writer)
+}
+
+// Registry holds the collectors exposed on the metrics endpoint
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// DefaultRegistry is the registry served by Handler
+var DefaultRegistry = &Registry{}
+
+// Register adds collectors to the registry
+func (r *Registry) Register(collectors ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, collectors...)
+}
+
+// Write writes all registered collectors in the Prometheus text format
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.collectors {
+		c.Write(w)
+	}
+}
+
+// Handler returns an HTTP handler serving the default registry
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		DefaultRegistry.Write(w)
+	})
+}
+
+// CounterVec is a set of monotonically increasing counters partitioned by labels
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]uint64
+}
+
+// NewCounterVec creates a counter vector with the given label names
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]uint64),
+	}
+}
+
+// Inc increments the counter identified by labelValues
+func (c *CounterVec) Inc(labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key]++
+}
+
+// Value returns the current value of the counter identified by labelValues
+func (c *CounterVec) Value(labelValues ...string) uint64 {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.values[key]
+}
+
+// Write writes the counters in the Prometheus text format
+func (c *CounterVec) Write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", c.name)
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s{%s} %d\n", c.name, c.formatLabels(key), c.values[key])
+	}
+}
+
+// key joins label values into a map key
+func (c *CounterVec) key(labelValues []string) string {
+	return joinLabels(c.name, c.labelNames, labelValues)
+}
+
+// formatLabels renders a map key as name="value" pairs
+func (c *CounterVec) formatLabels(key string) string {
+	return formatLabels(c.labelNames, key)
+}
+
+// joinLabels joins label values into a map key, checking they match the label names
+func joinLabels(name string, labelNames, labelValues []string) string {
+	if len(labelValues) != len(labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", name, len(labelNames), len(labelValues)))
+	}
+	return strings.Join(labelValues, "\x00")
+}
+
+// formatLabels renders a map key as name="value" pairs
+func formatLabels(labelNames []string, key string) string {
+	values := strings.Split(key, "\x00")
+	pairs := make([]string, len(values))
+	for i, value := range values {
+		pairs[i] = fmt.Sprintf("%s=%q", labelNames[i], value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// SummaryVec tracks the count and sum of observations partitioned by labels,
+// e.g. call durations in seconds
+type SummaryVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	counts map[string]uint64
+	sums   map[string]float64
+}
+
+// NewSummaryVec creates a summary vector with the given label names
+func NewSummaryVec(name, help string, labelNames ...string) *SummaryVec {
+	return &SummaryVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		counts:     make(map[string]uint64),
+		sums:       make(map[string]float64),
+	}
+}
+
+// Observe records a single observation for the series identified by labelValues
+func (s *SummaryVec) Observe(value float64, labelValues ...string) {
+	key := joinLabels(s.name, s.labelNames, labelValues)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[key]++
+	s.sums[key] += value
+}
+
+// Count returns the number of observations for the series identified by labelValues
+func (s *SummaryVec) Count(labelValues ...string) uint64 {
+	key := joinLabels(s.name, s.labelNames, labelValues)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.counts[key]
+}
+
+// Sum returns the sum of observations for the series identified by labelValues
+func (s *SummaryVec) Sum(labelValues ...string) float64 {
+	key := joinLabels(s.name, s.labelNames, labelValues)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sums[key]
+}
+
+// Write writes the summary in the Prometheus text format
+func (s *SummaryVec) Write(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", s.name, s.help)
+	fmt.Fprintf(w, "# TYPE %s summary\n", s.name)
+
+	keys := make([]string, 0, len(s.counts))
+	for key := range s.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		labels := formatLabels(s.labelNames, key)
+		fmt.Fprintf(w, "%s_sum{%s} %g\n", s.name, labels, s.sums[key])
+		fmt.Fprintf(w, "%s_count{%s} %d\n", s.name, labels, s.counts[key])
+	}
+}
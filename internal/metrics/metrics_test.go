@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec_Write(t *testing.T) {
+	counter := NewCounterVec("test_calls_total", "Test calls.", "verb", "result")
+	counter.Inc("get", "success")
+	counter.Inc("get", "success")
+	counter.Inc("create", "conflict")
+
+	if got := counter.Value("get", "success"); got != 2 {
+		t.Errorf("expected get/success to be 2, got %d", got)
+	}
+
+	var buf bytes.Buffer
+	counter.Write(&buf)
+	output := buf.String()
+
+	expected := []string{
+		"# HELP test_calls_total Test calls.",
+		"# TYPE test_calls_total counter",
+		`test_calls_total{verb="create",result="conflict"} 1`,
+		`test_calls_total{verb="get",result="success"} 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("expected output to contain %q, got:\n%s", line, output)
+		}
+	}
+}
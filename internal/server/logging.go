@@ -0,0 +1,86 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/clientip"
+)
+
+// quietPaths are polled by probes and scrapers, so their requests are only
+// logged at debug level
+var quietPaths = map[string]bool{
+	"/health":  true,
+	"/status":  true,
+	"/metrics": true,
+}
+
+// logRequests logs a summary of every request once it has been served. Paths
+// are logged as requested, including basePath, which is ignored when matching
+// quietPaths.
+func logRequests(basePath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		level := slog.LevelInfo
+		if quietPaths[strings.TrimPrefix(r.URL.Path, basePath)] {
+			level = slog.LevelDebug
+		}
+		if !slog.Default().Enabled(r.Context(), level) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+
+		slog.Log(r.Context(), level, "Request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+			"remoteAddr", r.RemoteAddr,
+			"clientIp", clientip.FromContext(r.Context()),
+		)
+	})
+}
+
+// statusRecorder remembers the status code and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streamed responses such as the export streaming
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// methodOrder is the order in which methods are listed in Allow headers
+var methodOrder = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// methodHandlers maps the methods a route supports to their handlers
+type methodHandlers map[string]http.HandlerFunc
+
+// serve dispatches r by method, or responds 405 with an Allow header listing
+// the supported methods (RFC 7231, section 6.5.5)
+func (m methodHandlers) serve(w http.ResponseWriter, r *http.Request) {
+	if handler, ok := m[r.Method]; ok {
+		handler(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", m.allow())
+	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+}
+
+// allow returns the value of the Allow header for the route
+func (m methodHandlers) allow() string {
+	var methods []string
+	for _, method := range methodOrder {
+		if _, ok := m[method]; ok {
+			methods = append(methods, method)
+		}
+	}
+	return strings.Join(methods, ", ")
+}
@@ -0,0 +1,656 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/version"
+)
+
+// handleOpenAPI serves the OpenAPI 3 description of the API. Like /health it is
+// open even when authentication is enabled, so clients can discover the contract.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(openAPIDocument(s.basePath, s.validationOptions))
+}
+
+// openAPIDocument builds the OpenAPI document. Schemas of proxy rules come from
+// the validation package, so limits such as the port range and the configured
+// maximum number of destinations stay in sync. A base path is listed as the
+// server URL that the paths are relative to.
+func openAPIDocument(basePath string, validationOpts validation.Options) map[string]interface{} {
+	paths := map[string]interface{}{
+		"/health": map[string]interface{}{
+			"get": operation("Liveness check", nil, map[string]interface{}{
+				"200": jsonResponse("Server is alive", "HealthResponse"),
+			}),
+		},
+		"/status": map[string]interface{}{
+			"get": operation("Version, uptime and Kubernetes connectivity", nil, map[string]interface{}{
+				"200": jsonResponse("Server status", "StatusResponse"),
+			}),
+		},
+		"/version": map[string]interface{}{
+			"get": operation("Build information; never requires authentication", nil, map[string]interface{}{
+				"200": jsonResponse("Build of the running backend", "VersionResponse"),
+			}),
+		},
+		"/api/ingresses": map[string]interface{}{
+			"get": operation("List ingresses in all namespaces that were not generated for proxy rules", nil, map[string]interface{}{
+				"200": jsonResponse("Kubernetes IngressList", "KubernetesList"),
+				"500": errorResponse("Kubernetes API error"),
+			}),
+		},
+		"/api/domains/check": map[string]interface{}{
+			"post": withRequestBody(operation("Check whether a domain is valid and not yet routed by a proxy rule, without reserving it", nil, map[string]interface{}{
+				"200": jsonResponse("Availability of the domain", "DomainCheckResponse"),
+				"400": requestErrorResponse(),
+				"422": validationErrorResponse(),
+				"500": errorResponse("Kubernetes API error"),
+			}), "DomainCheckRequest", "application/json"),
+		},
+	}
+
+	// Proxy rule routes exist both in the default namespace and under /api/namespaces/{namespace}
+	namespaceParam := pathParameter("namespace", "Namespace of the proxy rules (DNS-1123 label)")
+	for path, item := range proxyRulePaths() {
+		paths[path] = item
+		paths["/api/namespaces/{namespace}"+strings.TrimPrefix(path, "/api")] = withParameter(item, namespaceParam)
+	}
+
+	document := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Mortar API",
+			"description": "Manage proxy rules. Paths without a namespace use the default proxy-rules namespace. Most errors are returned as plain text.",
+			"version":     version.Version,
+		},
+		"paths": paths,
+		// Tokens are only required when authentication is enabled
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{},
+		},
+		"components": map[string]interface{}{
+			"schemas": openAPISchemas(validationOpts),
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	if basePath != "" {
+		document["servers"] = []interface{}{map[string]interface{}{"url": basePath}}
+	}
+	return document
+}
+
+// proxyRulePaths describes the /api/proxyrules routes
+func proxyRulePaths() map[string]interface{} {
+	nameParam := pathParameter("name", "Name of the proxy rule")
+	labelSelector := queryParameter("labelSelector", "Kubernetes label selector", "string")
+	dryRun := queryParameter("dryRun", "All runs every check and returns the result with 200 without persisting it", "string")
+
+	return map[string]interface{}{
+		"/api/proxyrules": map[string]interface{}{
+			"get": operation("List proxy rules", []interface{}{
+				labelSelector,
+				queryParameter("destination", "Only return rules with this destination", "string"),
+				queryParameter("enabled", "Only return enabled (true) or disabled (false) rules", "boolean"),
+				queryParameter("spec.port", "Only return rules with this port (resolved, so 80 or 443 match rules without one)", "integer"),
+				queryParameter("spec.tls", "Only return rules with this tls setting (resolved, so true matches rules without one)", "boolean"),
+				queryParameter("spec.destination", "Only return rules whose spec.destination equals this", "string"),
+				queryParameter("view", "summary returns a flat SummaryView per rule (SummaryList)", "string"),
+				headerParameter("If-None-Match", "Answer 304 if the list still has this ETag"),
+				headerParameter("If-Modified-Since", "Answer 304 if no listed rule was written, and no rule deleted through this backend, since then; ignored with If-None-Match"),
+			}, map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Kubernetes list of proxy rules, or a SummaryList with ?view=summary, sorted by name; ETag is a weak hash of the body, Last-Modified the latest write",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]interface{}{
+							"oneOf": []interface{}{schemaRef("ProxyRuleList"), schemaRef("SummaryList")},
+						}},
+					},
+				},
+				"304": map[string]interface{}{"description": "The list still matches If-None-Match or If-Modified-Since"},
+				"400": errorResponse("Invalid query or list response too large"),
+				"503": errorResponse("Read cache is out of date"),
+			}),
+			"post": withRequestBody(operation("Create a proxy rule", []interface{}{dryRun}, map[string]interface{}{
+				"200": jsonResponse("Rule that would be created (dryRun)", "ProxyRule"),
+				"201": jsonResponse("Created rule; Location names its URL", "ProxyRule"),
+				"400": requestErrorResponse(),
+				"422": validationErrorResponse(),
+				"409": jsonResponse("A rule with the name or a domain already exists", "ErrorResponse"),
+				"503": readOnlyResponse(),
+			}), "ProxyRule", "application/json", "application/yaml", "text/yaml"),
+			"delete": operation("Delete all proxy rules matching a label selector", []interface{}{
+				required(labelSelector),
+				queryParameter("dryRun", "All only lists the rules that would be deleted", "string"),
+				headerParameter("X-Confirm-Delete", "Must be true unless dryRun is set"),
+			}, map[string]interface{}{
+				"200": jsonResponse("Deletion results", "BulkDeleteResponse"),
+				"207": jsonResponse("Some deletions failed", "BulkDeleteResponse"),
+				"400": errorResponse("Missing or invalid label selector"),
+				"428": errorResponse("Confirmation header missing"),
+				"503": readOnlyResponse(),
+			}),
+		},
+		"/api/proxyrules:delete": map[string]interface{}{
+			"post": withRequestBody(operation("Delete the named proxy rules, or those matching a label selector", []interface{}{
+				queryParameter("cascade", "Also delete the ingresses generated for each rule", "boolean"),
+				queryParameter("dryRun", "All only lists the rules that would be deleted", "string"),
+				headerParameter("X-Confirm-Delete", "Must be true unless dryRun is set"),
+			}, map[string]interface{}{
+				"200": jsonResponse("Deletion results", "BulkDeleteResponse"),
+				"207": jsonResponse("Some rules were missing or failed to delete", "BulkDeleteResponse"),
+				"400": requestErrorResponse(),
+				"428": errorResponse("Confirmation header missing"),
+				"503": readOnlyResponse(),
+			}), "BatchDeleteRequest", "application/json", "application/yaml", "text/yaml"),
+		},
+		"/api/proxyrules/export": map[string]interface{}{
+			"get": operation("Export all proxy rules", []interface{}{
+				queryParameter("format", "yaml (default) or csv", "string"),
+			}, map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Multi-document YAML stream of re-appliable rules (no uid, resourceVersion, managedFields or status), or CSV",
+					"content": map[string]interface{}{
+						"application/yaml": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+						"text/csv":         map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+					},
+				},
+			}),
+		},
+		"/api/proxyrules/import": map[string]interface{}{
+			"post": withImportBody(operation("Create (or with mode=upsert, update) the rules of a backup", []interface{}{
+				queryParameter("mode", "create (default) fails if a rule exists, upsert updates it", "string"),
+			}, map[string]interface{}{
+				"200": jsonResponse("Import results", "ImportResponse"),
+				"207": jsonResponse("Some writes failed", "ImportResponse"),
+				"422": jsonResponse("Some rules are invalid; nothing was written", "ImportResponse"),
+				"409": jsonResponse("Some names or domains conflict; nothing was written", "ImportResponse"),
+				"503": readOnlyResponse(),
+			})),
+		},
+		"/api/proxyrules/count": map[string]interface{}{
+			"get": operation("Count proxy rules", nil, map[string]interface{}{
+				"200": jsonResponse("Number of rules", "CountResponse"),
+			}),
+		},
+		"/api/proxyrules/health-summary": map[string]interface{}{
+			"get": operation("Re-validate all rules and report conflicts and orphans", nil, map[string]interface{}{
+				"200": jsonResponse("Summary", "HealthSummary"),
+			}),
+		},
+		"/api/proxyrules/by-uid/{uid}": map[string]interface{}{
+			"get": operation("Get a proxy rule by metadata.uid", []interface{}{
+				pathParameter("uid", "UID of the proxy rule"),
+			}, map[string]interface{}{
+				"200": jsonResponse("The rule; ETag carries its resourceVersion", "ProxyRule"),
+				"404": errorResponse("No rule has this UID"),
+			}),
+		},
+		"/api/proxyrules/{name}": map[string]interface{}{
+			"get": operation("Get a proxy rule", []interface{}{
+				nameParam,
+				queryParameter("view", "spec returns only the name and spec (SpecView)", "string"),
+				queryParameter("debug", "Also return the resolved form of the rule", "boolean"),
+			}, map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "The rule, or its SpecView with ?view=spec; ETag carries its resourceVersion",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]interface{}{
+							"oneOf": []interface{}{schemaRef("ProxyRule"), schemaRef("SpecView")},
+						}},
+					},
+				},
+				"404": map[string]interface{}{
+					"description": "Rule not found; JSON with suggestions when enabled, otherwise plain text",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": schemaRef("NotFoundResponse")},
+						"text/plain":       map[string]interface{}{"schema": schemaRef("Error")},
+					},
+				},
+				"503": errorResponse("Read cache is out of date"),
+			}),
+			"head": operation("Check whether a proxy rule exists", []interface{}{nameParam}, map[string]interface{}{
+				"200": map[string]interface{}{"description": "The rule exists; ETag carries its resourceVersion"},
+				"404": map[string]interface{}{"description": "Rule not found"},
+				"503": map[string]interface{}{"description": "Read cache is out of date"},
+			}),
+			"put": withRequestBody(operation("Replace the spec of a proxy rule", []interface{}{
+				nameParam,
+				headerParameter("If-Match", "Only update this resourceVersion (ETag)"),
+				dryRun,
+			}, map[string]interface{}{
+				"200": jsonResponse("Updated rule, or the rule as it would be stored (dryRun)", "ProxyRule"),
+				"400": requestErrorResponse(),
+				"422": validationErrorResponse(),
+				"404": errorResponse("Rule not found"),
+				"409": jsonResponse("A domain is used by another rule", "ErrorResponse"),
+				"412": errorResponse("If-Match precondition failed"),
+				"503": readOnlyResponse(),
+			}), "ProxyRule", "application/json", "application/yaml", "text/yaml"),
+			"patch": withJSONPatchBody(withRequestBody(operation("Patch a proxy rule", []interface{}{
+				nameParam,
+				headerParameter("If-Match", "Only apply the patch to this resourceVersion (ETag)"),
+			}, map[string]interface{}{
+				"200": jsonResponse("Patched rule", "ProxyRule"),
+				"400": requestErrorResponse(),
+				"422": structuredErrorResponse("Validation failed, or the patch changes an immutable field (plain text)"),
+				"404": errorResponse("Rule not found"),
+				"409": jsonResponse("A domain is used by another rule", "ErrorResponse"),
+				"412": errorResponse("If-Match precondition failed"),
+				"415": errorResponse("Unsupported patch content type"),
+				"503": readOnlyResponse(),
+			}), "ProxyRule", "application/merge-patch+json")),
+			"delete": operation("Delete a proxy rule", []interface{}{
+				nameParam,
+				queryParameter("cascade", "Also delete the ingresses generated for the rule", "boolean"),
+				dryRun,
+			}, map[string]interface{}{
+				"204": map[string]interface{}{"description": "Deleted"},
+				"200": map[string]interface{}{
+					"description": "Deleted with cascade, or what would be deleted (dryRun): the rule, or a CascadeDeleteResult with cascade",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": map[string]interface{}{
+							"oneOf": []interface{}{schemaRef("ProxyRule"), schemaRef("CascadeDeleteResult")},
+						}},
+					},
+				},
+				"207": jsonResponse("Deleted, but some ingresses could not be", "CascadeDeleteResult"),
+				"404": errorResponse("Rule not found"),
+				"503": readOnlyResponse(),
+			}),
+		},
+		"/api/proxyrules/{name}/ingress": map[string]interface{}{
+			"get": operation("Get the ingress generated for a proxy rule", []interface{}{nameParam}, map[string]interface{}{
+				"200": jsonResponse("Kubernetes Ingress", "KubernetesObject"),
+				"404": errorResponse("Rule not found, or no ingress generated yet"),
+			}),
+		},
+		"/api/proxyrules/{name}/history": map[string]interface{}{
+			"get": operation("Get the changes recorded for a proxy rule, oldest first", []interface{}{nameParam}, map[string]interface{}{
+				"200": jsonResponse("Change history", "HistoryResponse"),
+				"404": errorResponse("Rule not found"),
+			}),
+		},
+		"/api/proxyrules/{name}/rename": map[string]interface{}{
+			"post": withRequestBody(operation("Rename a proxy rule by copying it to the new name and deleting the old one", []interface{}{nameParam}, map[string]interface{}{
+				"201": jsonResponse("Renamed rule; Location names its URL", "ProxyRule"),
+				"400": requestErrorResponse(),
+				"422": validationErrorResponse(),
+				"404": errorResponse("Rule not found"),
+				"409": jsonResponse("A rule with the new name or a domain already exists", "ErrorResponse"),
+				"500": errorResponse("The old rule could not be deleted; the copy was removed again unless the message says otherwise"),
+				"503": readOnlyResponse(),
+			}), "RenameRequest", "application/json"),
+		},
+		"/api/proxyrules/{name}/tls": map[string]interface{}{
+			"post": withRequestBody(operation("Turn TLS of a proxy rule on or off, keeping the rest of its spec", []interface{}{nameParam}, map[string]interface{}{
+				"200": jsonResponse("Updated rule", "ProxyRule"),
+				"400": requestErrorResponse(),
+				"422": validationErrorResponse(),
+				"404": errorResponse("Rule not found"),
+				"409": errorResponse("The rule changed while TLS was set"),
+				"412": errorResponse("The rule changed since the version named by If-Match"),
+				"503": readOnlyResponse(),
+			}), "TLSRequest", "application/json"),
+		},
+		"/api/proxyrules/{name}/probe": map[string]interface{}{
+			"get": operation("Check which destinations of a rule accept TCP connections", []interface{}{nameParam}, map[string]interface{}{
+				"200": jsonResponse("Probe results", "ProbeResponse"),
+				"404": errorResponse("Rule not found"),
+				"503": errorResponse("No probe slot became free in time"),
+			}),
+		},
+	}
+}
+
+// openAPISchemas describes the bodies exchanged by the API
+func openAPISchemas(validationOpts validation.Options) map[string]interface{} {
+	proxyRule := validation.ProxyRuleSchema(validationOpts)
+
+	return map[string]interface{}{
+		"ProxyRule": proxyRule,
+		"ProxyRuleList": objectSchema(map[string]interface{}{
+			"apiVersion": stringSchema(),
+			"kind":       stringSchema(),
+			"metadata":   map[string]interface{}{"type": "object"},
+			"items":      map[string]interface{}{"type": "array", "items": schemaRef("ProxyRule")},
+		}),
+		"SpecView": objectSchema(map[string]interface{}{
+			"name": stringSchema(),
+			"spec": validation.ProxyRuleSpecSchema(validationOpts),
+		}),
+		"SummaryList": objectSchema(map[string]interface{}{
+			"items": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"name":        stringSchema(),
+				"domain":      stringSchema(),
+				"destination": stringSchema(),
+				"port":        integerSchema(),
+				"tls":         map[string]interface{}{"type": "boolean"},
+				"createdAt":   map[string]interface{}{"type": "string", "format": "date-time"},
+				"updatedAt":   map[string]interface{}{"type": "string", "format": "date-time"},
+			})},
+		}),
+		"KubernetesObject": objectSchema(map[string]interface{}{
+			"apiVersion": stringSchema(),
+			"kind":       stringSchema(),
+			"metadata":   map[string]interface{}{"type": "object"},
+			"spec":       map[string]interface{}{"type": "object"},
+			"status":     map[string]interface{}{"type": "object"},
+		}),
+		"KubernetesList": objectSchema(map[string]interface{}{
+			"apiVersion": stringSchema(),
+			"kind":       stringSchema(),
+			"items":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "object"}},
+		}),
+		"Error": map[string]interface{}{
+			"type":        "string",
+			"description": "Plain-text error message",
+		},
+		"ErrorResponse": objectSchema(map[string]interface{}{
+			"error": objectSchema(map[string]interface{}{
+				"code":             stringSchema(),
+				"message":          stringSchema(),
+				"conflictingRule":  stringSchema(),
+				"conflictingValue": stringSchema(),
+			}),
+		}),
+		"ValidationErrorResponse": objectSchema(map[string]interface{}{
+			"status":  integerSchema(),
+			"message": stringSchema(),
+			"errors": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"field":   stringSchema(),
+				"pointer": stringSchema(),
+				"message": stringSchema(),
+			})},
+		}),
+		"DomainCheckRequest": objectSchema(map[string]interface{}{
+			"domain": stringSchema(),
+		}),
+		"DomainCheckResponse": objectSchema(map[string]interface{}{
+			"domain":            stringSchema(),
+			"available":         map[string]interface{}{"type": "boolean"},
+			"conflictingRule":   stringSchema(),
+			"conflictingDomain": stringSchema(),
+		}),
+		"NotFoundResponse": objectSchema(map[string]interface{}{
+			"message":     stringSchema(),
+			"suggestions": map[string]interface{}{"type": "array", "items": stringSchema()},
+		}),
+		"CountResponse": objectSchema(map[string]interface{}{
+			"count": integerSchema(),
+		}),
+		"HealthSummary": objectSchema(map[string]interface{}{
+			"total":           integerSchema(),
+			"valid":           integerSchema(),
+			"invalid":         integerSchema(),
+			"domainConflicts": integerSchema(),
+			"orphaned":        integerSchema(),
+		}),
+		"BulkDeleteResponse": objectSchema(map[string]interface{}{
+			"dryRun": map[string]interface{}{"type": "boolean"},
+			"results": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"name":             stringSchema(),
+				"status":           map[string]interface{}{"type": "string", "enum": []string{"deleted", "notFound", "failed", "wouldDelete"}},
+				"error":            stringSchema(),
+				"deletedIngresses": map[string]interface{}{"type": "array", "items": stringSchema()},
+			})},
+		}),
+		"BatchDeleteRequest": objectSchema(map[string]interface{}{
+			"names":         map[string]interface{}{"type": "array", "items": stringSchema()},
+			"labelSelector": stringSchema(),
+		}),
+		"ImportResponse": objectSchema(map[string]interface{}{
+			"mode": map[string]interface{}{"type": "string", "enum": []string{"create", "upsert"}},
+			"results": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"name":   stringSchema(),
+				"status": map[string]interface{}{"type": "string", "enum": []string{"created", "updated", "skipped", "error"}},
+				"error":  stringSchema(),
+			})},
+		}),
+		"RenameRequest": objectSchema(map[string]interface{}{
+			"newName": stringSchema(),
+		}),
+		"TLSRequest": objectSchema(map[string]interface{}{
+			"enabled": map[string]interface{}{"type": "boolean"},
+		}),
+		"HistoryResponse": objectSchema(map[string]interface{}{
+			"name": stringSchema(),
+			"entries": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+				"operation": stringSchema(),
+				"subject":   stringSchema(),
+				"changes":   map[string]interface{}{"type": "array", "items": stringSchema()},
+			})},
+		}),
+		"CascadeDeleteResult": objectSchema(map[string]interface{}{
+			"name":             stringSchema(),
+			"deletedIngresses": map[string]interface{}{"type": "array", "items": stringSchema()},
+			"errors":           map[string]interface{}{"type": "array", "items": stringSchema()},
+		}),
+		"ProbeResponse": objectSchema(map[string]interface{}{
+			"name": stringSchema(),
+			"results": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+				"destination": stringSchema(),
+				"address":     stringSchema(),
+				"reachable":   map[string]interface{}{"type": "boolean"},
+				"latencyMs":   integerSchema(),
+				"error":       stringSchema(),
+			})},
+		}),
+		"HealthResponse": objectSchema(map[string]interface{}{
+			"status":          stringSchema(),
+			"cacheAgeSeconds": map[string]interface{}{"type": "number"},
+		}),
+		"VersionResponse": objectSchema(map[string]interface{}{
+			"version":   stringSchema(),
+			"commit":    stringSchema(),
+			"buildDate": stringSchema(),
+			"goVersion": stringSchema(),
+		}),
+		"StatusResponse": objectSchema(map[string]interface{}{
+			"version":       stringSchema(),
+			"uptime":        stringSchema(),
+			"uptimeSeconds": integerSchema(),
+			"namespace":     stringSchema(),
+			"readOnly":      map[string]interface{}{"type": "boolean"},
+			"rules": objectSchema(map[string]interface{}{
+				"count": integerSchema(),
+				"max":   integerSchema(),
+				"error": stringSchema(),
+			}),
+			"kubernetes": objectSchema(map[string]interface{}{
+				"status":      stringSchema(),
+				"lastError":   stringSchema(),
+				"lastChecked": map[string]interface{}{"type": "string", "format": "date-time"},
+			}),
+		}),
+	}
+}
+
+// operation describes one method on a path; every operation may also fail with
+// 401 when authentication is enabled, 405 for unsupported methods and 503 when
+// it exceeds REQUEST_TIMEOUT
+func operation(summary string, parameters []interface{}, responses map[string]interface{}) map[string]interface{} {
+	responses["401"] = errorResponse("Missing or invalid bearer token (only when authentication is enabled)")
+	responses["503"] = withTimeoutResponse(responses["503"])
+	op := map[string]interface{}{
+		"summary":   summary,
+		"responses": responses,
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// withRequestBody adds a required request body to op, accepting each of the
+// given content types with the named schema
+func withRequestBody(op map[string]interface{}, schema string, contentTypes ...string) map[string]interface{} {
+	content := map[string]interface{}{}
+	for _, contentType := range contentTypes {
+		content[contentType] = map[string]interface{}{"schema": schemaRef(schema)}
+	}
+	op["requestBody"] = map[string]interface{}{"required": true, "content": content}
+	return op
+}
+
+// withImportBody sets op's request body to several rules: a JSON array, or a
+// multi-document YAML stream such as an export
+func withImportBody(op map[string]interface{}) map[string]interface{} {
+	op["requestBody"] = map[string]interface{}{"required": true, "content": map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "array", "items": schemaRef("ProxyRule")}},
+		"application/yaml": map[string]interface{}{"schema": stringSchema()},
+		"text/yaml":        map[string]interface{}{"schema": stringSchema()},
+	}}
+	return op
+}
+
+// withJSONPatchBody lets op's request body also be an RFC 6902 JSON patch
+func withJSONPatchBody(op map[string]interface{}) map[string]interface{} {
+	content := op["requestBody"].(map[string]interface{})["content"].(map[string]interface{})
+	content["application/json-patch+json"] = map[string]interface{}{
+		"schema": map[string]interface{}{"type": "array", "items": objectSchema(map[string]interface{}{
+			"op":    map[string]interface{}{"type": "string", "enum": []string{"add", "remove", "replace", "move", "copy", "test"}},
+			"path":  stringSchema(),
+			"from":  stringSchema(),
+			"value": map[string]interface{}{},
+		})},
+	}
+	return op
+}
+
+// withParameter returns a copy of a path item whose operations take param as well
+func withParameter(item interface{}, param map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for method, value := range item.(map[string]interface{}) {
+		op := map[string]interface{}{}
+		for key, field := range value.(map[string]interface{}) {
+			op[key] = field
+		}
+		params, _ := op["parameters"].([]interface{})
+		op["parameters"] = append([]interface{}{param}, params...)
+		result[method] = op
+	}
+	return result
+}
+
+func jsonResponse(description, schema string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaRef(schema)},
+		},
+	}
+}
+
+func errorResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"text/plain": map[string]interface{}{"schema": schemaRef("Error")},
+		},
+	}
+}
+
+// readOnlyResponse describes the 503 every write gets while READ_ONLY is set
+func readOnlyResponse() map[string]interface{} {
+	response := errorResponse("Writes are disabled (READ_ONLY); Retry-After suggests when to try again")
+	response["headers"] = map[string]interface{}{
+		"Retry-After": map[string]interface{}{"schema": integerSchema()},
+	}
+	return response
+}
+
+// withTimeoutResponse adds the JSON error of a request exceeding REQUEST_TIMEOUT
+// to an operation's 503 response, which may be nil
+func withTimeoutResponse(response interface{}) map[string]interface{} {
+	timeout := map[string]interface{}{"schema": schemaRef("ErrorResponse")}
+	existing, ok := response.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{
+			"description": "Request exceeded REQUEST_TIMEOUT",
+			"content":     map[string]interface{}{"application/json": timeout},
+		}
+	}
+	existing["description"] = fmt.Sprintf("%s, or the request exceeded REQUEST_TIMEOUT", existing["description"])
+	content, ok := existing["content"].(map[string]interface{})
+	if !ok {
+		content = map[string]interface{}{}
+		existing["content"] = content
+	}
+	content["application/json"] = timeout
+	return existing
+}
+
+// validationErrorResponse describes the 422 for a well-formed body describing
+// an invalid rule, which is structured JSON unless the client accepts text/plain
+func validationErrorResponse() map[string]interface{} {
+	return structuredErrorResponse("Validation failed")
+}
+
+// requestErrorResponse describes the 400 for a body that cannot be read as a
+// rule; Content-Type and empty-body errors are structured like validation errors
+func requestErrorResponse() map[string]interface{} {
+	return structuredErrorResponse("Missing or unsupported Content-Type, empty body or unparseable JSON/YAML")
+}
+
+func structuredErrorResponse(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemaRef("ValidationErrorResponse")},
+			"text/plain":       map[string]interface{}{"schema": schemaRef("Error")},
+		},
+	}
+}
+
+func pathParameter(name, description string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "path", "required": true, "description": description, "schema": stringSchema()}
+}
+
+func queryParameter(name, description, typ string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "query", "description": description, "schema": map[string]interface{}{"type": typ}}
+}
+
+func headerParameter(name, description string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "in": "header", "description": description, "schema": stringSchema()}
+}
+
+// required returns a copy of param marked as required
+func required(param map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{"required": true}
+	for key, value := range param {
+		result[key] = value
+	}
+	return result
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func objectSchema(properties map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func stringSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "string"}
+}
+
+func integerSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "integer"}
+}
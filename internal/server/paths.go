@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/handlers"
+)
+
+// routeKeywords are the fixed segments of API paths, matched case-insensitively.
+// Rule names, namespaces and UIDs keep their case.
+var routeKeywords = map[string]bool{
+	"api":               true,
+	"proxyrules":        true,
+	"proxyrules:delete": true,
+	"namespaces":        true,
+	"ingresses":         true,
+	"domains":           true,
+	"check":             true,
+	"export":            true,
+	"import":            true,
+	"count":             true,
+	"health-summary":    true,
+	"by-uid":            true,
+	"ingress":           true,
+	"probe":             true,
+	"history":           true,
+	"rename":            true,
+	"tls":               true,
+}
+
+// normalizePaths rewrites API paths before routing: runs of slashes collapse
+// into one, a single trailing slash is dropped and route keywords are
+// lowercased, so /API/ProxyRules/ and /api//proxyrules reach the collection.
+// A path ending in more than one slash names an empty segment, e.g.
+// /api/proxyrules//, and is rejected with 400 instead of reaching a handler that
+// would answer with a confusing 404 or 405.
+func normalizePaths(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if !strings.EqualFold(path, "/api") && !strings.HasPrefix(strings.ToLower(path), "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasSuffix(path, "//") {
+			http.Error(w, fmt.Sprintf("Malformed path '%s': empty path segment", path), http.StatusBadRequest)
+			return
+		}
+
+		var segments []string
+		for _, segment := range strings.Split(path, "/") {
+			if segment == "" {
+				continue
+			}
+			if lower := strings.ToLower(segment); routeKeywords[lower] {
+				segment = lower
+			}
+			segments = append(segments, segment)
+		}
+
+		normalized := "/" + strings.Join(segments, "/")
+		if normalized != path {
+			r = r.Clone(r.Context())
+			r.URL.Path = normalized
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// stripBasePath serves requests below basePath with the prefix removed, so
+// routing and the handlers' path parsing see /api/... as without a base path.
+// Requests outside of basePath are answered with 404. An empty basePath
+// serves every request unchanged.
+func stripBasePath(basePath string, next http.Handler) http.Handler {
+	if basePath == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, basePath)
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			http.NotFound(w, r)
+			return
+		}
+		if rest == "" {
+			rest = "/"
+		}
+
+		r = r.Clone(handlers.WithBasePath(r.Context(), basePath))
+		r.URL.Path = rest
+		if rawRest, ok := strings.CutPrefix(r.URL.RawPath, basePath); ok && rawRest != "" {
+			r.URL.RawPath = rawRest
+		} else {
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,97 +1,408 @@
 package server
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
 
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/auth"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/clientip"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/config"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/handlers"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/k8s"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/metrics"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/validation"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/dynamic"
 )
 
 type Server struct {
 	port              string
+	basePath          string
+	validationOptions validation.Options
+	tlsCertFile       string
+	tlsKeyFile        string
 	proxyRulesHandler *handlers.ProxyRulesHandler
 	ingressHandler    *handlers.IngressHandler
+	startTime         time.Time
+	k8sProbe          *cachedProbe
+	httpServer        *http.Server
+	shutdownTimeout   time.Duration
+	inFlight          atomic.Int64
+	requireAuth       func(http.Handler) http.Handler
+	ruleCache         *k8s.ResourceCache
+	cacheCtx          context.Context
+	stopCache         context.CancelFunc
 }
 
+// cacheSyncTimeout bounds how long Start waits for the read cache's initial list
+const cacheSyncTimeout = 30 * time.Second
+
 func New(port string, dynamicClient dynamic.Interface) *Server {
-	return &Server{
-		port:              port,
-		proxyRulesHandler: handlers.NewProxyRulesHandler(dynamicClient),
+	cfg := config.Default()
+	cfg.Port = port
+	return NewWithConfig(cfg, dynamicClient)
+}
+
+// NewWithConfig creates a server whose handlers are configured from cfg
+func NewWithConfig(cfg config.Config, dynamicClient dynamic.Interface, extra ...handlers.Option) *Server {
+	opts := []handlers.Option{
+		handlers.WithAllowDuplicateDomains(cfg.AllowDuplicateDomains),
+		handlers.WithMaxListResponseBytes(cfg.MaxListResponseBytes),
+		handlers.WithMaxBodyBytes(cfg.MaxBodyBytes),
+		handlers.WithMaxConcurrentProbes(cfg.MaxConcurrentProbes),
+		handlers.WithNotFoundSuggestions(cfg.NotFoundSuggestions),
+		handlers.WithMaxCacheStaleness(cfg.MaxCacheStaleness),
+		handlers.WithReadOnly(cfg.ReadOnly),
+		handlers.WithDestinationLoopDetection(cfg.DetectDestinationLoops),
+		handlers.WithSharedDestinationWarnings(cfg.WarnSharedDestinations),
+		handlers.WithDomainLock(cfg.DomainLockConfigMap),
+		handlers.WithMaxRules(cfg.MaxRules),
+		handlers.WithDefaultDestinationPort(cfg.DefaultDestinationPort),
+		handlers.WithValidationOptions(validationOptions(cfg)),
+	}
+	var ruleCache *k8s.ResourceCache
+	if cfg.CacheReads {
+		ruleCache = k8s.NewResourceCache(dynamicClient, handlers.ProxyRulesGVR)
+		opts = append(opts, handlers.WithReadCache(ruleCache))
+	}
+	proxyRulesHandler := handlers.NewProxyRulesHandler(dynamicClient, append(opts, extra...)...)
+
+	s := &Server{
+		port:              cfg.Port,
+		basePath:          cfg.BasePath,
+		validationOptions: validationOptions(cfg),
+		tlsCertFile:       cfg.TLSCertFile,
+		tlsKeyFile:        cfg.TLSKeyFile,
+		proxyRulesHandler: proxyRulesHandler,
 		ingressHandler:    handlers.NewIngressHandler(dynamicClient),
+		startTime:         time.Now(),
+		k8sProbe:          newCachedProbe(proxyRulesHandler.CheckConnectivity, statusProbeTTL),
+		shutdownTimeout:   cfg.ShutdownTimeout,
+		requireAuth:       auth.Middleware(newVerifier(cfg), cfg.AuthPublicReads),
+		ruleCache:         ruleCache,
+	}
+	s.cacheCtx, s.stopCache = context.WithCancel(context.Background())
+	clientIPs := clientip.NewExtractor(cfg.TrustedProxyCIDRs)
+	s.httpServer = &http.Server{Handler: s.trackInFlight(clientIPs.Middleware(logRequests(cfg.BasePath, stripBasePath(cfg.BasePath, compressResponses(normalizePaths(timeoutRequests(cfg.RequestTimeout, s.routes())))))))}
+	if s.tlsCertFile != "" {
+		s.httpServer.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return s
+}
+
+// validationOptions returns the rule validation settings of cfg
+func validationOptions(cfg config.Config) validation.Options {
+	return validation.Options{
+		MaxDestinations:         cfg.MaxDestinations,
+		AllowedDestinationCIDRs: cfg.AllowedDestinationCIDRs,
+		RequireTLSPort:          cfg.RequireTLSPort,
 	}
 }
 
+// newVerifier returns the bearer-token verifier configured in cfg, or nil if
+// authentication is disabled
+func newVerifier(cfg config.Config) *auth.Verifier {
+	switch {
+	case cfg.JWTSecret != "":
+		return auth.NewHMACVerifier([]byte(cfg.JWTSecret))
+	case cfg.JWKSURL != "":
+		return auth.NewJWKSVerifier(cfg.JWKSURL, nil)
+	default:
+		return nil
+	}
+}
+
+// Handler returns the server's HTTP handler with all routes and middleware,
+// for serving it without Start, e.g. from an httptest.Server
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// routes registers all endpoints on a new mux. Health, status, version, the OpenAPI document and
+// metrics stay open for probes, scrapers and support; the API requires authentication when enabled.
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/version", s.handleVersion)
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/api/proxyrules", s.requireAuth(http.HandlerFunc(s.handleProxyRules)))
+	mux.Handle("/api/proxyrules/", s.requireAuth(http.HandlerFunc(s.handleProxyRules)))
+	mux.Handle("/api/proxyrules:delete", s.requireAuth(http.HandlerFunc(s.handleProxyRules)))
+	mux.Handle("/api/namespaces/", s.requireAuth(http.HandlerFunc(s.handleNamespacedProxyRules)))
+	mux.Handle("/api/ingresses", s.requireAuth(http.HandlerFunc(s.handleIngresses)))
+	mux.Handle("/api/domains/check", s.requireAuth(http.HandlerFunc(s.handleDomainCheck)))
+	return mux
+}
+
+// trackInFlight counts requests currently being served so shutdown can report them
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) Start() error {
-	// Register routes
-	http.HandleFunc("/health", s.handleHealth)
-	http.HandleFunc("/api/proxyrules", s.handleProxyRules)
-	http.HandleFunc("/api/proxyrules/", s.handleProxyRules)
-	http.HandleFunc("/api/ingresses", s.handleIngresses)
+	// Fail before touching the cluster if the certificate is unusable
+	if err := s.loadCertificate(); err != nil {
+		return err
+	}
+
+	s.checkCRD()
+	s.startCache()
+
+	listener, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return fmt.Errorf("error starting server: %w", err)
+	}
 
 	// Start server
-	fmt.Printf("Starting API server on port %s...\n", s.port)
-	if err := http.ListenAndServe(":"+s.port, nil); err != nil {
+	slog.Info("Starting API server", "port", s.port, "tls", s.httpServer.TLSConfig != nil)
+	return s.serve(listener)
+}
+
+// loadCertificate loads the TLS certificate and key, if configured, into the
+// server's TLS configuration
+func (s *Server) loadCertificate() error {
+	if s.httpServer.TLSConfig == nil {
+		return nil
+	}
+	certificate, err := tls.LoadX509KeyPair(s.tlsCertFile, s.tlsKeyFile)
+	if err != nil {
+		return fmt.Errorf("error loading TLS certificate %s and key %s: %w", s.tlsCertFile, s.tlsKeyFile, err)
+	}
+	s.httpServer.TLSConfig.Certificates = []tls.Certificate{certificate}
+	return nil
+}
+
+// checkCRD logs a prominent warning if the proxy rule CRD is not installed.
+// The server still starts; rule requests fail with 503 until it is installed.
+func (s *Server) checkCRD() {
+	if err := s.k8sProbe.Err(); handlers.IsCRDNotInstalled(err) {
+		slog.Warn(handlers.CRDNotInstalledMessage+"; /api/proxyrules requests will fail with 503 until it is applied", "error", err)
+	}
+}
+
+// startCache starts watching proxy rules for the read cache, if enabled, and
+// waits for the initial list so the first reads are already served from it
+func (s *Server) startCache() {
+	if s.ruleCache == nil {
+		return
+	}
+
+	if err := s.ruleCache.Start(s.cacheCtx, cacheSyncTimeout); err != nil {
+		// Reads fall back to the API server until the cache catches up
+		slog.Warn("Read cache not synced", "error", err)
+	}
+}
+
+// serve accepts connections on listener until the server is shut down,
+// terminating TLS if a certificate is configured
+func (s *Server) serve(listener net.Listener) error {
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		// The certificate is already in TLSConfig
+		err = s.httpServer.ServeTLS(listener, "", "")
+	} else {
+		err = s.httpServer.Serve(listener)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error starting server: %w", err)
 	}
 	return nil
 }
 
+// Shutdown stops accepting new connections and waits up to the configured shutdown
+// timeout for in-flight requests to finish before force-closing the remaining connections
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	defer s.stopCache()
+
+	err := s.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		inFlight := s.inFlight.Load()
+		if closeErr := s.httpServer.Close(); closeErr != nil {
+			return fmt.Errorf("error force-closing connections: %w", closeErr)
+		}
+		return fmt.Errorf("shutdown timeout of %v exceeded, force-closed connections with %d in-flight request(s)", s.shutdownTimeout, inFlight)
+	}
+	return err
+}
+
+// HealthResponse is the body of the liveness endpoint
+type HealthResponse struct {
+	Status string `json:"status"`
+	// CacheAgeSeconds is how far the read cache may lag behind the API server; omitted without a cache
+	CacheAgeSeconds *float64 `json:"cacheAgeSeconds,omitempty"`
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{Status: "ok"}
+	if staleness, cached := s.proxyRulesHandler.CacheStaleness(); cached {
+		age := staleness.Seconds()
+		response.CacheAgeSeconds = &age
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok"}`))
+	json.NewEncoder(w).Encode(response)
 }
 
 func (s *Server) handleProxyRules(w http.ResponseWriter, r *http.Request) {
 	path := strings.Trim(r.URL.Path, "/")
 	parts := strings.Split(path, "/")
+	h := s.proxyRulesHandler
 
-	// /api/proxyrules
-	if len(parts) == 2 && parts[1] == "proxyrules" {
-		switch r.Method {
-		case http.MethodGet:
-			s.proxyRulesHandler.GetProxyRules(w, r)
-		case http.MethodPost:
-			s.proxyRulesHandler.CreateProxyRule(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
+	// Without the CRD every API call fails with a confusing 404; the probe
+	// result is cached, so this costs at most one List per probe interval
+	if err := s.k8sProbe.Err(); handlers.IsCRDNotInstalled(err) {
+		http.Error(w, handlers.CRDNotInstalledMessage, http.StatusServiceUnavailable)
 		return
 	}
 
+	switch {
+	// /api/proxyrules
+	case len(parts) == 2 && parts[1] == "proxyrules":
+		methodHandlers{
+			http.MethodGet:    h.GetProxyRules,
+			http.MethodPost:   h.CreateProxyRule,
+			http.MethodDelete: h.DeleteProxyRules,
+		}.serve(w, r)
+
+	// /api/proxyrules:delete
+	case len(parts) == 2 && parts[1] == "proxyrules:delete":
+		methodHandlers{http.MethodPost: h.BatchDeleteProxyRules}.serve(w, r)
+
+	// /api/proxyrules/export
+	case len(parts) == 3 && parts[1] == "proxyrules" && parts[2] == "export":
+		methodHandlers{http.MethodGet: h.ExportProxyRules}.serve(w, r)
+
+	// /api/proxyrules/import
+	case len(parts) == 3 && parts[1] == "proxyrules" && parts[2] == "import":
+		methodHandlers{http.MethodPost: h.ImportProxyRules}.serve(w, r)
+
+	// /api/proxyrules/count
+	case len(parts) == 3 && parts[1] == "proxyrules" && parts[2] == "count":
+		methodHandlers{http.MethodGet: h.CountProxyRules}.serve(w, r)
+
+	// /api/proxyrules/health-summary
+	case len(parts) == 3 && parts[1] == "proxyrules" && parts[2] == "health-summary":
+		methodHandlers{http.MethodGet: h.GetHealthSummary}.serve(w, r)
+
+	// /api/proxyrules/by-uid/{uid}
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[2] == "by-uid":
+		methodHandlers{http.MethodGet: h.GetProxyRuleByUID}.serve(w, r)
+
+	// /api/proxyrules/{name}/ingress
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[3] == "ingress":
+		methodHandlers{http.MethodGet: h.GetProxyRuleIngress}.serve(w, r)
+
+	// /api/proxyrules/{name}/history
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[3] == "history":
+		methodHandlers{http.MethodGet: h.GetProxyRuleHistory}.serve(w, r)
+
+	// /api/proxyrules/{name}/rename
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[3] == "rename":
+		methodHandlers{http.MethodPost: h.RenameProxyRule}.serve(w, r)
+
+	// /api/proxyrules/{name}/tls
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[3] == "tls":
+		methodHandlers{http.MethodPost: h.SetProxyRuleTLS}.serve(w, r)
+
+	// /api/proxyrules/{name}/probe
+	case len(parts) == 4 && parts[1] == "proxyrules" && parts[3] == "probe":
+		methodHandlers{http.MethodGet: h.ProbeProxyRule}.serve(w, r)
+
 	// /api/proxyrules/{name}
-	if len(parts) == 3 && parts[1] == "proxyrules" {
-		switch r.Method {
-		case http.MethodGet:
-			s.proxyRulesHandler.GetProxyRule(w, r)
-		case http.MethodPut:
-			s.proxyRulesHandler.UpdateProxyRule(w, r)
-		case http.MethodDelete:
-			s.proxyRulesHandler.DeleteProxyRule(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
+	case len(parts) == 3 && parts[1] == "proxyrules":
+		methodHandlers{
+			http.MethodGet:    h.GetProxyRule,
+			http.MethodHead:   h.HeadProxyRule,
+			http.MethodPut:    h.UpdateProxyRule,
+			http.MethodPatch:  h.PatchProxyRule,
+			http.MethodDelete: h.DeleteProxyRule,
+		}.serve(w, r)
+
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+// handleNamespacedProxyRules serves /api/namespaces/{ns}/proxyrules[/...] by
+// dispatching to the proxy rule routes with the namespace taken from the path
+func (s *Server) handleNamespacedProxyRules(w http.ResponseWriter, r *http.Request) {
+	// api, namespaces, {ns}, proxyrules, ...
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || (parts[3] != "proxyrules" && parts[3] != "proxyrules:delete") {
+		http.NotFound(w, r)
 		return
 	}
 
-	http.Error(w, "Not found", http.StatusNotFound)
+	namespace := parts[2]
+	if errs := k8svalidation.IsDNS1123Label(namespace); len(errs) > 0 {
+		http.Error(w, fmt.Sprintf("Invalid namespace '%s': %s", namespace, strings.Join(errs, "; ")), http.StatusBadRequest)
+		return
+	}
+
+	// Rewrite to the legacy path so the handlers' path parsing applies unchanged
+	scoped := r.Clone(handlers.WithNamespace(r.Context(), namespace))
+	scoped.URL.Path = "/api/" + strings.Join(parts[3:], "/")
+	scoped.URL.RawPath = ""
+	s.handleProxyRules(w, scoped)
 }
 
 func (s *Server) handleIngresses(w http.ResponseWriter, r *http.Request) {
 	// Only GET method is allowed (read-only)
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	methodHandlers{http.MethodGet: s.ingressHandler.GetIngresses}.serve(w, r)
+}
+
+// handleDomainCheck serves /api/domains/check, which only reads the rules
+func (s *Server) handleDomainCheck(w http.ResponseWriter, r *http.Request) {
+	if err := s.k8sProbe.Err(); handlers.IsCRDNotInstalled(err) {
+		http.Error(w, handlers.CRDNotInstalledMessage, http.StatusServiceUnavailable)
 		return
 	}
-
-	s.ingressHandler.GetIngresses(w, r)
+	methodHandlers{http.MethodPost: s.proxyRulesHandler.CheckDomain}.serve(w, r)
 }
 
+// Run starts the server and shuts it down gracefully on SIGINT or SIGTERM
 func (s *Server) Run() {
-	if err := s.Start(); err != nil {
-		log.Fatal(err)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			slog.Error("Server failed", "error", err)
+			os.Exit(1)
+		}
+	case sig := <-stop:
+		slog.Info("Shutting down", "signal", sig.String(), "timeout", s.shutdownTimeout)
+		if err := s.Shutdown(); err != nil {
+			slog.Error("Error during shutdown", "error", err)
+			return
+		}
+		slog.Info("Server stopped")
 	}
 }
@@ -2,12 +2,44 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/config"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/handlers"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/version"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // TestE2E_ProxyRulesWorkflow tests a complete workflow of proxy rule operations
@@ -272,7 +304,7 @@ func TestE2E_ValidationErrors(t *testing.T) {
 					"destination": "10.0.0.50",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			errorContains:  "domain",
 		},
 		{
@@ -286,7 +318,7 @@ func TestE2E_ValidationErrors(t *testing.T) {
 					"destination": "300.400.500.600",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			errorContains:  "invalid",
 		},
 		{
@@ -301,9 +333,20 @@ func TestE2E_ValidationErrors(t *testing.T) {
 					"port":        99999,
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			errorContains:  "port",
 		},
+		{
+			name: "scalar spec",
+			rule: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": "scalar-spec",
+				},
+				"spec": "oops",
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			errorContains:  "spec must be an object, got a string",
+		},
 		{
 			name: "invalid name",
 			rule: map[string]interface{}{
@@ -315,7 +358,7 @@ func TestE2E_ValidationErrors(t *testing.T) {
 					"destination": "10.0.0.50",
 				},
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 			errorContains:  "name",
 		},
 	}
@@ -421,3 +464,1116 @@ func setupTestServer(fakeClient *testutil.FakeDynamicClient) *httptest.Server {
 		}
 	}))
 }
+
+// TestE2E_Status tests the operator-facing status endpoint
+func TestE2E_Status(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	srv := New("8080", fakeClient)
+	server := httptest.NewServer(http.HandlerFunc(srv.handleStatus))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+
+	var status StatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if status.Version == "" {
+		t.Error("expected version in status")
+	}
+	if status.Namespace != "proxy-rules" {
+		t.Errorf("expected namespace proxy-rules, got %q", status.Namespace)
+	}
+	if status.Kubernetes.Status != "ok" {
+		t.Errorf("expected kubernetes status ok, got %q (%s)", status.Kubernetes.Status, status.Kubernetes.LastError)
+	}
+	if status.Kubernetes.LastChecked.IsZero() {
+		t.Error("expected lastChecked timestamp")
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	previous := version.Get()
+	version.Version, version.Commit, version.BuildDate = "v1.2.3", "0123abcd", "2026-10-16T08:00:00Z"
+	defer func() {
+		version.Version, version.Commit, version.BuildDate = previous.Version, previous.Commit, previous.BuildDate
+	}()
+
+	handler := New("8080", testutil.NewFakeDynamicClient()).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var info version.Info
+	if err := json.Unmarshal(w.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	want := version.Info{Version: "v1.2.3", Commit: "0123abcd", BuildDate: "2026-10-16T08:00:00Z", GoVersion: runtime.Version()}
+	if info != want {
+		t.Errorf("expected %+v, got %+v", want, info)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for POST, got %d", w.Code)
+	}
+}
+
+func TestCachedProbe(t *testing.T) {
+	calls := 0
+	probeErr := errors.New("connection refused")
+	now := time.Now()
+
+	probe := newCachedProbe(func(ctx context.Context) error {
+		calls++
+		return probeErr
+	}, 5*time.Second)
+	probe.now = func() time.Time { return now }
+
+	first := probe.Result()
+	if first.Status != "failing" || first.LastError != "connection refused" {
+		t.Errorf("expected failing probe with error, got %+v", first)
+	}
+
+	// Within the TTL the cached result is reused
+	now = now.Add(2 * time.Second)
+	probe.Result()
+	if calls != 1 {
+		t.Errorf("expected 1 probe call within TTL, got %d", calls)
+	}
+
+	// After the TTL the probe runs again
+	probeErr = nil
+	now = now.Add(5 * time.Second)
+	second := probe.Result()
+	if calls != 2 {
+		t.Errorf("expected 2 probe calls after TTL, got %d", calls)
+	}
+	if second.Status != "ok" || second.LastError != "" {
+		t.Errorf("expected ok probe, got %+v", second)
+	}
+}
+
+func TestServerShutdown(t *testing.T) {
+	tests := []struct {
+		name            string
+		shutdownTimeout time.Duration
+		requestDuration time.Duration
+		wantForced      bool
+	}{
+		{
+			name:            "in-flight request drains within timeout",
+			shutdownTimeout: 2 * time.Second,
+			requestDuration: 100 * time.Millisecond,
+		},
+		{
+			name:            "in-flight request outlives timeout",
+			shutdownTimeout: 100 * time.Millisecond,
+			requestDuration: 5 * time.Second,
+			wantForced:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.ShutdownTimeout = tt.shutdownTimeout
+			srv := NewWithConfig(cfg, testutil.NewFakeDynamicClient())
+
+			started := make(chan struct{})
+			srv.httpServer.Handler = srv.trackInFlight(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.requestDuration):
+					w.WriteHeader(http.StatusOK)
+				case <-r.Context().Done():
+				}
+			}))
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			go srv.serve(listener)
+
+			requestErr := make(chan error, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+				if err == nil {
+					resp.Body.Close()
+				}
+				requestErr <- err
+			}()
+			<-started
+
+			begin := time.Now()
+			err = srv.Shutdown()
+			elapsed := time.Since(begin)
+
+			if tt.wantForced {
+				if err == nil || !strings.Contains(err.Error(), "1 in-flight request") {
+					t.Errorf("expected forced shutdown reporting 1 in-flight request, got %v", err)
+				}
+				if elapsed > tt.requestDuration {
+					t.Errorf("expected shutdown to stop waiting after %v, took %v", tt.shutdownTimeout, elapsed)
+				}
+				if err := <-requestErr; err == nil {
+					t.Error("expected in-flight request to fail after force-close")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("expected clean shutdown, got %v", err)
+			}
+			if err := <-requestErr; err != nil {
+				t.Errorf("expected in-flight request to complete, got %v", err)
+			}
+		})
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+	cfg := config.Default()
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	srv := NewWithConfig(cfg, testutil.NewFakeDynamicClient())
+	if err := srv.loadCertificate(); err != nil {
+		t.Fatalf("failed to load certificate: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go srv.serve(listener)
+	defer srv.Shutdown()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get("https://" + listener.Addr().String() + "/health")
+	if err != nil {
+		t.Fatalf("HTTPS request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+		t.Errorf("expected 200 over TLS 1.2 or later, got %d with %+v", resp.StatusCode, resp.TLS)
+	}
+
+	legacy := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS11}}}
+	if resp, err := legacy.Get("https://" + listener.Addr().String() + "/health"); err == nil {
+		resp.Body.Close()
+		t.Error("expected TLS 1.1 to be rejected")
+	}
+
+	cfg.TLSKeyFile = filepath.Join(t.TempDir(), "missing.key")
+	err = NewWithConfig(cfg, testutil.NewFakeDynamicClient()).loadCertificate()
+	if err == nil || !strings.Contains(err.Error(), "missing.key") {
+		t.Errorf("expected an error naming the missing key, got %v", err)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its
+// key to a temporary directory
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "mortar-backend"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestServerAuthentication(t *testing.T) {
+	cfg := config.Default()
+	cfg.JWTSecret = "test-secret"
+	srv := NewWithConfig(cfg, testutil.NewFakeDynamicClient())
+	server := httptest.NewServer(srv.httpServer.Handler)
+	defer server.Close()
+
+	token := testToken(cfg.JWTSecret)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "health stays open", method: http.MethodGet, path: "/health", expectedStatus: http.StatusOK},
+		{name: "version stays open", method: http.MethodGet, path: "/version", expectedStatus: http.StatusOK},
+		{name: "list without token", method: http.MethodGet, path: "/api/proxyrules", expectedStatus: http.StatusUnauthorized},
+		{name: "create without token", method: http.MethodPost, path: "/api/proxyrules", expectedStatus: http.StatusUnauthorized},
+		{name: "ingresses without token", method: http.MethodGet, path: "/api/ingresses", expectedStatus: http.StatusUnauthorized},
+		{name: "list with token", method: http.MethodGet, path: "/api/proxyrules", token: token, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestE2E_NamespacedProxyRules(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("legacy-rule", "proxy-rules", "legacy.example.com", "10.0.0.50", 3000)
+	srv := New("8080", fakeClient)
+	server := httptest.NewServer(srv.httpServer.Handler)
+	defer server.Close()
+
+	createRule := func(path, body string) *http.Response {
+		t.Helper()
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create rule: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	listNames := func(path string) []string {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("failed to list rules: %v", err)
+		}
+		defer resp.Body.Close()
+		var list struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			} `json:"items"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+			t.Fatalf("failed to decode list: %v", err)
+		}
+		var names []string
+		for _, item := range list.Items {
+			names = append(names, item.Metadata.Name)
+		}
+		return names
+	}
+
+	resp := createRule("/api/namespaces/team-a/proxyrules", `{"metadata":{"name":"team-rule"},"spec":{"domain":"team.example.com","destination":"10.0.0.60"}}`)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Location"); got != "/api/namespaces/team-a/proxyrules/team-rule" {
+		t.Errorf("expected namespaced Location, got %q", got)
+	}
+
+	stored, err := fakeClient.Resource(testutil.ProxyRulesGVR).Namespace("team-a").Get(context.Background(), "team-rule", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected rule in namespace team-a: %v", err)
+	}
+	if stored.GetNamespace() != "team-a" {
+		t.Errorf("expected metadata.namespace team-a, got %q", stored.GetNamespace())
+	}
+
+	if names := listNames("/api/namespaces/team-a/proxyrules"); fmt.Sprint(names) != "[team-rule]" {
+		t.Errorf("expected [team-rule] in team-a, got %v", names)
+	}
+	if names := listNames("/api/proxyrules"); fmt.Sprint(names) != "[legacy-rule]" {
+		t.Errorf("expected legacy route to list only the default namespace, got %v", names)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "get namespaced rule", method: http.MethodGet, path: "/api/namespaces/team-a/proxyrules/team-rule", expectedStatus: http.StatusOK},
+		{name: "rule not visible in other namespace", method: http.MethodGet, path: "/api/namespaces/team-b/proxyrules/team-rule", expectedStatus: http.StatusNotFound},
+		{name: "legacy route uses default namespace", method: http.MethodGet, path: "/api/proxyrules/legacy-rule", expectedStatus: http.StatusOK},
+		{name: "invalid namespace", method: http.MethodGet, path: "/api/namespaces/Team_A/proxyrules", expectedStatus: http.StatusBadRequest},
+		{name: "unknown resource", method: http.MethodGet, path: "/api/namespaces/team-a/widgets", expectedStatus: http.StatusNotFound},
+		{
+			name:           "body namespace must match path",
+			method:         http.MethodPost,
+			path:           "/api/namespaces/team-a/proxyrules",
+			body:           `{"metadata":{"name":"other-rule","namespace":"team-b"},"spec":{"domain":"other.example.com","destination":"10.0.0.61"}}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{name: "delete namespaced rule", method: http.MethodDelete, path: "/api/namespaces/team-a/proxyrules/team-rule", expectedStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, server.URL+tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+// fixedStalenessCache is a read cache reporting a fixed staleness
+type fixedStalenessCache time.Duration
+
+func (c fixedStalenessCache) Staleness() time.Duration { return time.Duration(c) }
+func (c fixedStalenessCache) HasSynced() bool          { return false }
+func (c fixedStalenessCache) List(string, labels.Selector) ([]*unstructured.Unstructured, error) {
+	return nil, nil
+}
+func (c fixedStalenessCache) Get(string, string) (*unstructured.Unstructured, bool) {
+	return nil, false
+}
+
+func TestHealthCacheAge(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []handlers.Option
+		expectedAge *float64
+	}{
+		{
+			name: "no cache",
+		},
+		{
+			name:        "stale cache",
+			opts:        []handlers.Option{handlers.WithReadCache(fixedStalenessCache(90 * time.Second))},
+			expectedAge: func() *float64 { age := 90.0; return &age }(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewWithConfig(config.Default(), testutil.NewFakeDynamicClient(), tt.opts...)
+			w := httptest.NewRecorder()
+
+			srv.handleHealth(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			var health HealthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &health); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if health.Status != "ok" {
+				t.Errorf("expected status ok, got %q", health.Status)
+			}
+			switch {
+			case tt.expectedAge == nil && health.CacheAgeSeconds != nil:
+				t.Errorf("expected no cache age, got %v", *health.CacheAgeSeconds)
+			case tt.expectedAge != nil && (health.CacheAgeSeconds == nil || *health.CacheAgeSeconds != *tt.expectedAge):
+				t.Errorf("expected cache age %v, got %v", *tt.expectedAge, health.CacheAgeSeconds)
+			}
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	// Probes of this destination are refused immediately
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "example.com", "127.0.0.1", 1)
+	cfg := config.Default()
+	cfg.JWTSecret = "test-secret"
+	srv := NewWithConfig(cfg, fakeClient)
+	handler := srv.httpServer.Handler
+
+	// The document is served without a token even when authentication is enabled
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage        `json:"paths"`
+		Components struct{ Schemas map[string]json.RawMessage } `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to decode document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("expected openapi 3.0.3, got %q", doc.OpenAPI)
+	}
+
+	// Every schema reference must resolve
+	for _, match := range regexp.MustCompile(`"#/components/schemas/([A-Za-z]+)"`).FindAllStringSubmatch(w.Body.String(), -1) {
+		if _, ok := doc.Components.Schemas[match[1]]; !ok {
+			t.Errorf("reference to undefined schema %s", match[1])
+		}
+	}
+
+	// The rule schema carries the limits enforced by validation
+	var ruleSchema struct {
+		Properties struct {
+			Metadata struct {
+				Properties struct {
+					Name struct {
+						Pattern string `json:"pattern"`
+					} `json:"name"`
+				} `json:"properties"`
+			} `json:"metadata"`
+			Spec struct {
+				Properties struct {
+					Port struct {
+						Minimum int `json:"minimum"`
+						Maximum int `json:"maximum"`
+					} `json:"port"`
+				} `json:"properties"`
+			} `json:"spec"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(doc.Components.Schemas["ProxyRule"], &ruleSchema); err != nil {
+		t.Fatalf("failed to decode ProxyRule schema: %v", err)
+	}
+	if port := ruleSchema.Properties.Spec.Properties.Port; port.Minimum != 1 || port.Maximum != 65535 {
+		t.Errorf("expected port range 1-65535, got %d-%d", port.Minimum, port.Maximum)
+	}
+	namePattern := regexp.MustCompile(ruleSchema.Properties.Metadata.Properties.Name.Pattern)
+	if !namePattern.MatchString("my-app") || namePattern.MatchString("My_App") {
+		t.Errorf("name pattern %q does not match the name rules", namePattern)
+	}
+
+	// Every documented operation is routed
+	token := testToken(cfg.JWTSecret)
+	replacer := strings.NewReplacer("{namespace}", "proxy-rules", "{name}", "test-rule", "{uid}", "unknown")
+	for path, item := range doc.Paths {
+		for method := range item {
+			t.Run(strings.ToUpper(method)+" "+path, func(t *testing.T) {
+				req := httptest.NewRequest(strings.ToUpper(method), replacer.Replace(path), nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				body := strings.TrimSpace(w.Body.String())
+				if w.Code == http.StatusMethodNotAllowed || body == "Not found" || body == "404 page not found" {
+					t.Errorf("documented route is not served: %d %s", w.Code, body)
+				}
+			})
+		}
+	}
+}
+
+// testToken returns an HS256 token for subject alice signed with secret
+func testToken(secret string) string {
+	input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestMethodNotAllowedAllowHeader(t *testing.T) {
+	srv := New("8080", testutil.NewFakeDynamicClient())
+	handler := srv.httpServer.Handler
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{method: http.MethodPut, path: "/api/proxyrules", allow: "GET, POST, DELETE"},
+		{method: http.MethodPost, path: "/api/proxyrules/test-rule", allow: "GET, HEAD, PUT, PATCH, DELETE"},
+		{method: http.MethodPost, path: "/api/namespaces/team-a/proxyrules/test-rule", allow: "GET, HEAD, PUT, PATCH, DELETE"},
+		{method: http.MethodDelete, path: "/api/proxyrules/export", allow: "GET"},
+		{method: http.MethodPost, path: "/api/proxyrules/test-rule/probe", allow: "GET"},
+		{method: http.MethodPost, path: "/api/ingresses", allow: "GET"},
+		{method: http.MethodPost, path: "/status", allow: "GET"},
+		{method: http.MethodPost, path: "/openapi.json", allow: "GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != http.StatusMethodNotAllowed {
+				t.Fatalf("expected status 405, got %d", w.Code)
+			}
+			if allow := w.Header().Get("Allow"); allow != tt.allow {
+				t.Errorf("expected Allow %q, got %q", tt.allow, allow)
+			}
+		})
+	}
+}
+
+func TestProxyRulesCRDNotInstalled(t *testing.T) {
+	srv := NewWithConfig(config.Default(), testutil.NewFakeDynamicClient())
+	crdInstalled := false
+	srv.k8sProbe = newCachedProbe(func(ctx context.Context) error {
+		if crdInstalled {
+			return nil
+		}
+		// What the client reports when the API server does not serve the resource
+		return apierrors.NewGenericServerResponse(http.StatusNotFound, http.MethodGet, handlers.ProxyRulesGVR.GroupResource(), "", "404 page not found", 0, true)
+	}, 0)
+
+	for _, path := range []string{"/api/proxyrules", "/api/proxyrules/test-rule", "/api/namespaces/team-a/proxyrules"} {
+		w := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "CRD") {
+			t.Errorf("GET %s: expected 503 naming the CRD, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /health to stay up without the CRD, got %d", w.Code)
+	}
+
+	crdInstalled = true
+	w = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 once the CRD is installed, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "test.example.com", "10.0.0.50", 3000)
+
+	cfg := config.Default()
+	cfg.ReadOnly = true
+	srv := NewWithConfig(cfg, fakeClient)
+
+	body := `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destination":"10.0.0.51"}}`
+	writes := []struct {
+		method, path, contentType, body string
+	}{
+		{http.MethodPost, "/api/proxyrules", "application/json", body},
+		{http.MethodPut, "/api/proxyrules/test-rule", "application/json", `{"spec":{"domain":"test.example.com","destination":"10.0.0.52"}}`},
+		{http.MethodPatch, "/api/proxyrules/test-rule", "application/merge-patch+json", `{"spec":{"destination":"10.0.0.52"}}`},
+		{http.MethodDelete, "/api/proxyrules/test-rule", "", ""},
+		{http.MethodDelete, "/api/proxyrules?labelSelector=app=test", "", ""},
+	}
+	for _, write := range writes {
+		req := httptest.NewRequest(write.method, write.path, strings.NewReader(write.body))
+		if write.contentType != "" {
+			req.Header.Set("Content-Type", write.contentType)
+		}
+		req.Header.Set("X-Confirm-Delete", "true")
+		w := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: expected 503, got %d: %s", write.method, write.path, w.Code, w.Body.String())
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s %s: expected a Retry-After header", write.method, write.path)
+		}
+	}
+
+	// Nothing reached the cluster
+	rule, err := fakeClient.Resource(handlers.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "test-rule", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected test-rule to survive: %v", err)
+	}
+	if destination, _, _ := unstructured.NestedString(rule.Object, "spec", "destination"); destination != "10.0.0.50" {
+		t.Errorf("expected test-rule to be unchanged, got destination %q", destination)
+	}
+	if _, err := fakeClient.Resource(handlers.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "new-rule", metav1.GetOptions{}); err == nil {
+		t.Error("expected new-rule not to be created")
+	}
+
+	for _, path := range []string{"/api/proxyrules", "/api/proxyrules/test-rule"} {
+		w := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("GET %s: expected reads to keep working, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	var status StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if !status.ReadOnly {
+		t.Error("expected /status to report readOnly")
+	}
+}
+
+func TestRuleQuota(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("rule-1", "proxy-rules", "one.example.com", "10.0.0.1", 80)
+	fakeClient.SeedProxyRule("rule-2", "proxy-rules", "two.example.com", "10.0.0.2", 80)
+
+	cfg := config.Default()
+	cfg.MaxRules = 3
+	srv := NewWithConfig(cfg, fakeClient)
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// Two new rules would make four
+	imported := `[{"metadata":{"name":"rule-1"},"spec":{"domain":"one.example.com","destination":"10.0.0.1"}},` +
+		`{"metadata":{"name":"rule-3"},"spec":{"domain":"three.example.com","destination":"10.0.0.3"}},` +
+		`{"metadata":{"name":"rule-4"},"spec":{"domain":"four.example.com","destination":"10.0.0.4"}}]`
+	if w := send(http.MethodPost, "/api/proxyrules/import?mode=upsert", imported); w.Code != http.StatusForbidden {
+		t.Errorf("expected import past the quota to be rejected with 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, err := fakeClient.Resource(handlers.ProxyRulesGVR).Namespace("proxy-rules").Get(context.Background(), "rule-3", metav1.GetOptions{}); err == nil {
+		t.Error("expected a rejected import not to create any rule")
+	}
+
+	if w := send(http.MethodPost, "/api/proxyrules", `{"metadata":{"name":"rule-3"},"spec":{"domain":"three.example.com","destination":"10.0.0.3"}}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected the third rule to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	w := send(http.MethodPost, "/api/proxyrules", `{"metadata":{"name":"rule-4"},"spec":{"domain":"four.example.com","destination":"10.0.0.4"}}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected the fourth rule to be rejected with 403, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "quota exceeded") {
+		t.Errorf("expected a quota error, got %q", w.Body.String())
+	}
+
+	// Updating stored rules does not add any
+	if w := send(http.MethodPost, "/api/proxyrules/import?mode=upsert", `[{"metadata":{"name":"rule-1"},"spec":{"domain":"one.example.com","destination":"10.0.0.9"}}]`); w.Code != http.StatusOK {
+		t.Errorf("expected an upsert of a stored rule at the quota to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var status StatusResponse
+	if err := json.NewDecoder(send(http.MethodGet, "/status", "").Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status.Rules.Count != 3 || status.Rules.Max != 3 || status.Rules.Error != "" {
+		t.Errorf("expected /status to report 3 of 3 rules, got %+v", status.Rules)
+	}
+}
+
+func TestExportPagination(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	// More than two export pages of 100 rules
+	const ruleCount = 250
+	for i := 0; i < ruleCount; i++ {
+		name := fmt.Sprintf("rule-%03d", i)
+		fakeClient.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.1", 80)
+	}
+
+	srv := NewWithConfig(config.Default(), fakeClient)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/proxyrules/export?format=csv", nil)
+	w := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV export: %v", err)
+	}
+
+	seen := make(map[string]int)
+	for _, record := range records[1:] {
+		seen[record[0]]++
+	}
+	if len(seen) != ruleCount {
+		t.Errorf("expected %d distinct rules in the export, got %d", ruleCount, len(seen))
+	}
+	for name, count := range seen {
+		if count != 1 {
+			t.Errorf("expected rule %s exactly once, got %d times", name, count)
+		}
+	}
+}
+
+func TestExportStreamsPastRequestTimeout(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	for i := 0; i < 150; i++ {
+		name := fmt.Sprintf("rule-%03d", i)
+		fakeClient.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.1", 80)
+	}
+
+	cfg := config.Default()
+	cfg.RequestTimeout = time.Minute
+	srv := NewWithConfig(cfg, fakeClient)
+
+	for _, path := range []string{
+		"/api/proxyrules/export",
+		"/API/ProxyRules/Export/",
+		"/api/namespaces/proxy-rules/proxyrules/export?format=csv",
+	} {
+		t.Run(path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if !w.Flushed {
+				t.Error("expected the export to be flushed while it is written, not buffered")
+			}
+			if !strings.Contains(w.Body.String(), "rule-149") {
+				t.Errorf("expected the whole export, got %d bytes", w.Body.Len())
+			}
+		})
+	}
+
+	// Other responses stay buffered by the timeout
+	w := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/proxyrules", nil))
+	if w.Code != http.StatusOK || w.Flushed {
+		t.Errorf("expected a buffered 200 for the list, got %d (flushed %v)", w.Code, w.Flushed)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	cancelled := make(chan struct{})
+	handler := timeoutRequests(20*time.Millisecond, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			// Like a Kubernetes API call, give up once the request context is cancelled
+			<-r.Context().Done()
+			close(cancelled)
+			w.Write([]byte("partial"))
+		case "/unavailable":
+			http.Error(w, "Read cache is out of date", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("fast"))
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON content type, got %q", ct)
+	}
+	var body map[string]map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("expected a JSON body: %v", err)
+	}
+	if body["error"]["code"] != "RequestTimeout" || body["error"]["message"] == "" {
+		t.Errorf("expected error.code RequestTimeout and error.message, got %v", body)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the request context to be cancelled")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "fast" {
+		t.Errorf("expected fast requests to pass through, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A handler's own 503 keeps its content type
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unavailable", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected the handler's content type, got %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	timeoutRequests(0, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, hasDeadline := r.Context().Deadline(); hasDeadline {
+			t.Error("expected no deadline with a zero timeout")
+		}
+	})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRequestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(previous)
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "test.example.com", "10.0.0.50", 3000)
+	srv := New("8080", fakeClient)
+
+	for _, path := range []string{"/health", "/api/proxyrules/test-rule", "/api/proxyrules/missing-rule"} {
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	output := logs.String()
+	for _, want := range []string{
+		"method=GET path=/api/proxyrules/test-rule status=200",
+		"method=GET path=/api/proxyrules/missing-rule status=404",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected a request summary containing %q, got:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "path=/health") {
+		t.Errorf("expected health probes to be logged at debug level only, got:\n%s", output)
+	}
+}
+
+func TestRequestLoggingBasePath(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(previous)
+
+	cfg := config.Default()
+	cfg.BasePath = "/mortar"
+	srv := NewWithConfig(cfg, testutil.NewFakeDynamicClient())
+
+	for _, path := range []string{"/mortar/health", "/mortar/api/proxyrules"} {
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "method=GET path=/mortar/api/proxyrules status=200") {
+		t.Errorf("expected the request summary to name the full path, got:\n%s", output)
+	}
+	if strings.Contains(output, "path=/mortar/health") {
+		t.Errorf("expected health probes below the base path to be logged at debug level only, got:\n%s", output)
+	}
+}
+
+func TestRequestLoggingKeepsFlusher(t *testing.T) {
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	defer slog.SetDefault(previous)
+
+	flushed := false
+	handler := logRequests("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flushed = w.(http.Flusher)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/proxyrules/export", nil))
+	if !flushed {
+		t.Error("expected the logged response writer to support flushing")
+	}
+}
+
+func TestResponseCompression(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(previous)
+
+	fakeClient := testutil.NewFakeDynamicClient()
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("rule-%02d", i)
+		fakeClient.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.1", 80)
+	}
+	handler := New("8080", fakeClient).Handler()
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		expectGzip     bool
+		expectVary     bool
+	}{
+		{name: "large list", path: "/api/proxyrules", acceptEncoding: "gzip, deflate", expectGzip: true, expectVary: true},
+		{name: "streamed export", path: "/api/proxyrules/export", acceptEncoding: "gzip", expectGzip: true, expectVary: true},
+		{name: "client without gzip", path: "/api/proxyrules", acceptEncoding: "", expectVary: true},
+		{name: "gzip refused", path: "/api/proxyrules", acceptEncoding: "gzip;q=0", expectVary: true},
+		{name: "small response", path: "/health", acceptEncoding: "gzip", expectVary: true},
+		{name: "small error", path: "/api/proxyrules/missing-rule", acceptEncoding: "gzip", expectVary: true},
+		{name: "metrics", path: "/metrics", acceptEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			gzipped := w.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.expectGzip {
+				t.Fatalf("expected gzip %v, got Content-Encoding %q", tt.expectGzip, w.Header().Get("Content-Encoding"))
+			}
+			if vary := w.Header().Get("Vary") == "Accept-Encoding"; vary != tt.expectVary {
+				t.Errorf("expected Vary: Accept-Encoding %v, got %q", tt.expectVary, w.Header().Values("Vary"))
+			}
+			if !gzipped {
+				return
+			}
+
+			reader, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("failed to open gzip body: %v", err)
+			}
+			body, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to decompress body: %v", err)
+			}
+			if !strings.Contains(string(body), "rule-49.example.com") {
+				t.Errorf("expected the decompressed body to hold every rule, got %d bytes", len(body))
+			}
+		})
+	}
+
+	// The request log sees the status once, as it was sent
+	if !strings.Contains(logs.String(), "path=/api/proxyrules/missing-rule status=404") {
+		t.Errorf("expected the 404 to be logged, got %s", logs.String())
+	}
+}
+
+func TestPathNormalization(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "test.example.com", "10.0.0.50", 3000)
+	handler := New("8080", fakeClient).Handler()
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{method: http.MethodGet, path: "/api/proxyrules/", expectedStatus: http.StatusOK, expectedBody: `"items"`},
+		{method: http.MethodGet, path: "/api//proxyrules", expectedStatus: http.StatusOK, expectedBody: `"items"`},
+		{method: http.MethodGet, path: "/API/ProxyRules", expectedStatus: http.StatusOK, expectedBody: `"items"`},
+		{method: http.MethodGet, path: "/api/proxyrules/test-rule/", expectedStatus: http.StatusOK, expectedBody: `"name":"test-rule"`},
+		{method: http.MethodGet, path: "/api/proxyrules//test-rule", expectedStatus: http.StatusOK, expectedBody: `"name":"test-rule"`},
+		{method: http.MethodGet, path: "/api/PROXYRULES/Count", expectedStatus: http.StatusOK, expectedBody: `"count":1`},
+		{method: http.MethodGet, path: "/api/proxyrules/test-rule/TLS", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/api/proxyrules/test-rule/tls/", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/API/Domains/Check", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/api/domains/check/", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/api/ProxyRules:Delete", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/api/namespaces/proxy-rules/proxyrules:delete", expectedStatus: http.StatusMethodNotAllowed, expectedBody: "Method not allowed"},
+		{method: http.MethodGet, path: "/api/namespaces/proxy-rules/proxyrules/test-rule/", expectedStatus: http.StatusOK, expectedBody: `"name":"test-rule"`},
+		{method: http.MethodGet, path: "/api/proxyrules//", expectedStatus: http.StatusBadRequest, expectedBody: "empty path segment"},
+		{method: http.MethodDelete, path: "/api/proxyrules/test-rule//", expectedStatus: http.StatusBadRequest, expectedBody: "empty path segment"},
+		{method: http.MethodGet, path: "/health", expectedStatus: http.StatusOK, expectedBody: `"ok"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body containing %q, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("test-rule", "proxy-rules", "test.example.com", "10.0.0.50", 3000)
+
+	cfg := config.Default()
+	cfg.BasePath = "/mortar"
+	handler := NewWithConfig(cfg, fakeClient).Handler()
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{method: http.MethodGet, path: "/mortar/api/proxyrules/test-rule", expectedStatus: http.StatusOK, expectedBody: `"name":"test-rule"`},
+		{method: http.MethodGet, path: "/mortar/API/ProxyRules/", expectedStatus: http.StatusOK, expectedBody: `"items"`},
+		{method: http.MethodGet, path: "/mortar/api/namespaces/proxy-rules/proxyrules/test-rule", expectedStatus: http.StatusOK, expectedBody: `"name":"test-rule"`},
+		{method: http.MethodGet, path: "/mortar/health", expectedStatus: http.StatusOK, expectedBody: `"ok"`},
+		{method: http.MethodGet, path: "/mortar/openapi.json", expectedStatus: http.StatusOK, expectedBody: `"servers":[{"url":"/mortar"}]`},
+		{method: http.MethodGet, path: "/mortar", expectedStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/api/proxyrules/test-rule", expectedStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/health", expectedStatus: http.StatusNotFound},
+		{method: http.MethodGet, path: "/mortarx/health", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := send(tt.method, tt.path, "")
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("expected body containing %q, got %s", tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	// Links in responses point below the base path
+	w := send(http.MethodPost, "/mortar/api/namespaces/proxy-rules/proxyrules", `{"metadata":{"name":"new-rule"},"spec":{"domain":"new.example.com","destination":"10.0.0.51"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if location := w.Header().Get("Location"); location != "/mortar/api/namespaces/proxy-rules/proxyrules/new-rule" {
+		t.Errorf("expected Location below the base path, got %q", location)
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/version"
+)
+
+const (
+	// statusProbeTTL is how long a Kubernetes connectivity probe result is reused
+	statusProbeTTL = 5 * time.Second
+	// statusProbeTimeout bounds a single connectivity probe
+	statusProbeTimeout = 3 * time.Second
+)
+
+// StatusResponse is the body returned by the /status endpoint
+type StatusResponse struct {
+	Version       string           `json:"version"`
+	Uptime        string           `json:"uptime"`
+	UptimeSeconds int64            `json:"uptimeSeconds"`
+	Namespace     string           `json:"namespace"`
+	Kubernetes    KubernetesStatus `json:"kubernetes"`
+	// ReadOnly is true while writes are rejected for maintenance
+	ReadOnly bool `json:"readOnly"`
+	// Rules is the number of rules in Namespace against the MAX_RULES quota
+	Rules RulesStatus `json:"rules"`
+}
+
+// RulesStatus is the rule count of the default namespace and the quota per namespace
+type RulesStatus struct {
+	Count int `json:"count"`
+	// Max is the quota, 0 if there is none
+	Max int `json:"max"`
+	// Error is set if the rules could not be counted
+	Error string `json:"error,omitempty"`
+}
+
+// KubernetesStatus is the result of the last Kubernetes connectivity probe
+type KubernetesStatus struct {
+	Status      string    `json:"status"`
+	LastError   string    `json:"lastError,omitempty"`
+	LastChecked time.Time `json:"lastChecked"`
+}
+
+// cachedProbe runs a connectivity check at most once per ttl and remembers the result
+type cachedProbe struct {
+	check func(ctx context.Context) error
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu          sync.Mutex
+	lastErr     error
+	lastChecked time.Time
+}
+
+func newCachedProbe(check func(ctx context.Context) error, ttl time.Duration) *cachedProbe {
+	return &cachedProbe{
+		check: check,
+		ttl:   ttl,
+		now:   time.Now,
+	}
+}
+
+// Result returns the cached probe result, re-running the check if it has expired
+func (p *cachedProbe) Result() KubernetesStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refresh()
+	status := KubernetesStatus{
+		Status:      "ok",
+		LastChecked: p.lastChecked.UTC(),
+	}
+	if p.lastErr != nil {
+		status.Status = "failing"
+		status.LastError = p.lastErr.Error()
+	}
+	return status
+}
+
+// Err returns the error of the cached probe result, re-running the check if it has expired
+func (p *cachedProbe) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refresh()
+	return p.lastErr
+}
+
+// refresh re-runs the check if the last result has expired (caller must hold the lock)
+func (p *cachedProbe) refresh() {
+	if !p.lastChecked.IsZero() && p.now().Sub(p.lastChecked) < p.ttl {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), statusProbeTimeout)
+	p.lastErr = p.check(ctx)
+	cancel()
+	p.lastChecked = p.now()
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uptime := time.Since(s.startTime)
+	response := StatusResponse{
+		Version:       version.Version,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: int64(uptime.Seconds()),
+		Namespace:     s.proxyRulesHandler.Namespace(),
+		Kubernetes:    s.k8sProbe.Result(),
+		ReadOnly:      s.proxyRulesHandler.ReadOnly(),
+		Rules:         RulesStatus{Max: s.proxyRulesHandler.MaxRules()},
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), statusProbeTimeout)
+	defer cancel()
+	if count, err := s.proxyRulesHandler.RuleCount(ctx); err != nil {
+		response.Rules.Error = err.Error()
+	} else {
+		response.Rules.Count = count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleVersion reports the build of the running backend. Like /health it
+// never requires authentication, so it works even when tokens do not.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(version.Get())
+}
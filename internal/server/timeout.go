@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/handlers"
+)
+
+// timeoutRequests answers requests that take longer than timeout with 503 and
+// a JSON error. The request context is cancelled at the same time, so pending
+// Kubernetes API calls of the handler return early. Responses are buffered
+// until the handler finishes, so a client gets either the whole response or the
+// timeout error, never a truncated body. Streamed responses such as the export
+// are passed through unbounded, since buffering would hold the whole response
+// in memory. A zero timeout disables the bound.
+func timeoutRequests(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+
+	body, _ := json.Marshal(handlers.ErrorResponse{Error: handlers.ErrorDetail{
+		Code:    "RequestTimeout",
+		Message: fmt.Sprintf("Request did not complete within %v", timeout),
+	}})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStreamedRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var finished atomic.Bool
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer finished.Store(true)
+			next.ServeHTTP(w, r)
+		})
+		http.TimeoutHandler(handler, timeout, string(body)).ServeHTTP(&timeoutWriter{ResponseWriter: w, finished: &finished}, r)
+	})
+}
+
+// isStreamedRequest reports whether r is for a route that flushes its response
+// as it is written: the export, below /api or a namespace. Paths are expected
+// to be normalized.
+func isStreamedRequest(r *http.Request) bool {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	n := len(parts)
+	return n >= 3 && parts[0] == "api" && parts[n-2] == "proxyrules" && parts[n-1] == "export"
+}
+
+// timeoutWriter marks the timeout error written by http.TimeoutHandler, which
+// sets no Content-Type, as JSON. Completed responses pass through unchanged.
+type timeoutWriter struct {
+	http.ResponseWriter
+	finished *atomic.Bool
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && !w.finished.Load() {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
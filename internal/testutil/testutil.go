@@ -2,11 +2,16 @@ package testutil
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"sort"
+	"strconv"
 	"sync"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
@@ -15,14 +20,42 @@ import (
 
 // FakeDynamicClient implements a fake Kubernetes dynamic client for testing
 type FakeDynamicClient struct {
-	resources map[string]map[string]*unstructured.Unstructured // namespace -> name -> resource
-	mu        sync.RWMutex
+	resources       map[schema.GroupVersionResource]map[string]map[string]*unstructured.Unstructured // gvr -> namespace -> name -> resource
+	resourceVersion int64
+	uidCounter      int64
+	mu              sync.RWMutex
+}
+
+// nextResourceVersion returns a new, increasing resourceVersion (caller must hold the lock)
+func (f *FakeDynamicClient) nextResourceVersion() string {
+	f.resourceVersion++
+	return strconv.FormatInt(f.resourceVersion, 10)
+}
+
+// assignUID gives obj a new, unique UID unless it already has one (caller must hold the lock)
+func (f *FakeDynamicClient) assignUID(obj *unstructured.Unstructured) {
+	if obj.GetUID() != "" {
+		return
+	}
+	f.uidCounter++
+	obj.SetUID(types.UID(fmt.Sprintf("00000000-0000-0000-0000-%012d", f.uidCounter)))
+}
+
+// isDryRun reports whether write options ask for a dry run, which the fake
+// validates like a real write but does not persist
+func isDryRun(dryRun []string) bool {
+	for _, value := range dryRun {
+		if value == metav1.DryRunAll {
+			return true
+		}
+	}
+	return false
 }
 
 // NewFakeDynamicClient creates a new fake dynamic client
 func NewFakeDynamicClient() *FakeDynamicClient {
 	return &FakeDynamicClient{
-		resources: make(map[string]map[string]*unstructured.Unstructured),
+		resources: make(map[schema.GroupVersionResource]map[string]map[string]*unstructured.Unstructured),
 	}
 }
 
@@ -40,6 +73,14 @@ type fakeNamespaceableResource struct {
 	namespace string
 }
 
+// store returns the namespace -> name -> resource map for this resource's GVR
+func (f *fakeNamespaceableResource) store() map[string]map[string]*unstructured.Unstructured {
+	if _, ok := f.client.resources[f.gvr]; !ok {
+		f.client.resources[f.gvr] = make(map[string]map[string]*unstructured.Unstructured)
+	}
+	return f.client.resources[f.gvr]
+}
+
 func (f *fakeNamespaceableResource) Namespace(ns string) dynamic.ResourceInterface {
 	return &fakeNamespaceableResource{
 		client:    f.client,
@@ -52,19 +93,25 @@ func (f *fakeNamespaceableResource) Create(ctx context.Context, obj *unstructure
 	f.client.mu.Lock()
 	defer f.client.mu.Unlock()
 
-	if _, ok := f.client.resources[f.namespace]; !ok {
-		f.client.resources[f.namespace] = make(map[string]*unstructured.Unstructured)
+	if _, ok := f.store()[f.namespace]; !ok {
+		f.store()[f.namespace] = make(map[string]*unstructured.Unstructured)
 	}
 
 	name := obj.GetName()
-	if _, exists := f.client.resources[f.namespace][name]; exists {
-		return nil, fmt.Errorf("resource %s already exists", name)
+	if _, exists := f.store()[f.namespace][name]; exists {
+		return nil, apierrors.NewAlreadyExists(f.gvr.GroupResource(), name)
 	}
 
 	// Clone the object
 	created := obj.DeepCopy()
-	f.client.resources[f.namespace][name] = created
-	return created, nil
+	created.SetUID("")
+	if isDryRun(options.DryRun) {
+		return created, nil
+	}
+	f.client.assignUID(created)
+	created.SetResourceVersion(f.client.nextResourceVersion())
+	f.store()[f.namespace][name] = created
+	return created.DeepCopy(), nil
 }
 
 func (f *fakeNamespaceableResource) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
@@ -72,16 +119,28 @@ func (f *fakeNamespaceableResource) Update(ctx context.Context, obj *unstructure
 	defer f.client.mu.Unlock()
 
 	name := obj.GetName()
-	if _, ok := f.client.resources[f.namespace]; !ok {
-		return nil, fmt.Errorf("resource %s not found", name)
+	if _, ok := f.store()[f.namespace]; !ok {
+		return nil, apierrors.NewNotFound(f.gvr.GroupResource(), name)
 	}
-	if _, exists := f.client.resources[f.namespace][name]; !exists {
-		return nil, fmt.Errorf("resource %s not found", name)
+	current, exists := f.store()[f.namespace][name]
+	if !exists {
+		return nil, apierrors.NewNotFound(f.gvr.GroupResource(), name)
+	}
+
+	// Like the API server, reject writes based on a stale resourceVersion
+	if rv := obj.GetResourceVersion(); rv != "" && rv != current.GetResourceVersion() {
+		return nil, apierrors.NewConflict(f.gvr.GroupResource(), name, fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
 	}
 
 	updated := obj.DeepCopy()
-	f.client.resources[f.namespace][name] = updated
-	return updated, nil
+	updated.SetUID(current.GetUID())
+	if isDryRun(options.DryRun) {
+		updated.SetResourceVersion(current.GetResourceVersion())
+		return updated, nil
+	}
+	updated.SetResourceVersion(f.client.nextResourceVersion())
+	f.store()[f.namespace][name] = updated
+	return updated.DeepCopy(), nil
 }
 
 func (f *fakeNamespaceableResource) UpdateStatus(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions) (*unstructured.Unstructured, error) {
@@ -92,14 +151,17 @@ func (f *fakeNamespaceableResource) Delete(ctx context.Context, name string, opt
 	f.client.mu.Lock()
 	defer f.client.mu.Unlock()
 
-	if _, ok := f.client.resources[f.namespace]; !ok {
-		return fmt.Errorf("resource %s not found", name)
+	if _, ok := f.store()[f.namespace]; !ok {
+		return apierrors.NewNotFound(f.gvr.GroupResource(), name)
+	}
+	if _, exists := f.store()[f.namespace][name]; !exists {
+		return apierrors.NewNotFound(f.gvr.GroupResource(), name)
 	}
-	if _, exists := f.client.resources[f.namespace][name]; !exists {
-		return fmt.Errorf("resource %s not found", name)
+	if isDryRun(options.DryRun) {
+		return nil
 	}
 
-	delete(f.client.resources[f.namespace], name)
+	delete(f.store()[f.namespace], name)
 	return nil
 }
 
@@ -107,7 +169,7 @@ func (f *fakeNamespaceableResource) DeleteCollection(ctx context.Context, option
 	f.client.mu.Lock()
 	defer f.client.mu.Unlock()
 
-	delete(f.client.resources, f.namespace)
+	delete(f.store(), f.namespace)
 	return nil
 }
 
@@ -115,12 +177,12 @@ func (f *fakeNamespaceableResource) Get(ctx context.Context, name string, option
 	f.client.mu.RLock()
 	defer f.client.mu.RUnlock()
 
-	if _, ok := f.client.resources[f.namespace]; !ok {
-		return nil, fmt.Errorf("resource %s not found", name)
+	if _, ok := f.client.resources[f.gvr][f.namespace]; !ok {
+		return nil, apierrors.NewNotFound(f.gvr.GroupResource(), name)
 	}
-	obj, exists := f.client.resources[f.namespace][name]
+	obj, exists := f.client.resources[f.gvr][f.namespace][name]
 	if !exists {
-		return nil, fmt.Errorf("resource %s not found", name)
+		return nil, apierrors.NewNotFound(f.gvr.GroupResource(), name)
 	}
 
 	return obj.DeepCopy(), nil
@@ -130,19 +192,61 @@ func (f *fakeNamespaceableResource) List(ctx context.Context, opts metav1.ListOp
 	f.client.mu.RLock()
 	defer f.client.mu.RUnlock()
 
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid label selector %q: %v", opts.LabelSelector, err))
+	}
+
 	list := &unstructured.UnstructuredList{
 		Items: []unstructured.Unstructured{},
 	}
 
-	if resources, ok := f.client.resources[f.namespace]; ok {
+	// Like the real client, an empty namespace lists across all namespaces
+	for namespace, resources := range f.client.resources[f.gvr] {
+		if f.namespace != "" && namespace != f.namespace {
+			continue
+		}
 		for _, obj := range resources {
-			list.Items = append(list.Items, *obj.DeepCopy())
+			if selector.Matches(labels.Set(obj.GetLabels())) {
+				list.Items = append(list.Items, *obj.DeepCopy())
+			}
+		}
+	}
+
+	// Sort by namespace and name so pages are stable across calls
+	sort.Slice(list.Items, func(i, j int) bool {
+		return listKey(&list.Items[i]) < listKey(&list.Items[j])
+	})
+
+	// Like the API server, the continue token holds the key of the last item
+	// returned, so rules created or deleted between pages neither repeat nor
+	// shift the following ones
+	if opts.Continue != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(opts.Continue)
+		if err != nil || len(decoded) == 0 {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid continue token %q", opts.Continue))
 		}
+		after := string(decoded)
+		start := sort.Search(len(list.Items), func(i int) bool {
+			return listKey(&list.Items[i]) > after
+		})
+		list.Items = list.Items[start:]
+	}
+
+	if opts.Limit > 0 && int64(len(list.Items)) > opts.Limit {
+		list.Items = list.Items[:opts.Limit]
+		last := listKey(&list.Items[len(list.Items)-1])
+		list.SetContinue(base64.RawURLEncoding.EncodeToString([]byte(last)))
 	}
 
 	return list, nil
 }
 
+// listKey orders list items by namespace, then name
+func listKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
 func (f *fakeNamespaceableResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
 	return nil, fmt.Errorf("watch not implemented")
 }
@@ -187,16 +291,71 @@ func NewProxyRule(name, domain, destination string, port int) *unstructured.Unst
 	return obj
 }
 
-// SeedProxyRule adds a proxy rule to the fake client
-func (f *FakeDynamicClient) SeedProxyRule(name, namespace, domain, destination string, port int) {
+// ProxyRulesGVR is the GroupVersionResource under which proxy rules are stored
+var ProxyRulesGVR = schema.GroupVersionResource{
+	Group:    "bausteln.io",
+	Version:  "v1",
+	Resource: "proxyrules",
+}
+
+// Seed adds an arbitrary object of the given resource type to the fake client
+func (f *FakeDynamicClient) Seed(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	f.SeedAt(gvr, obj.GetNamespace(), obj.GetName(), obj)
+}
+
+// SeedAt adds obj under namespace and name whatever its metadata says, e.g. to
+// store an object whose metadata has a shape the API server would not return
+func (f *FakeDynamicClient) SeedAt(gvr schema.GroupVersionResource, namespace, name string, obj *unstructured.Unstructured) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	if _, ok := f.resources[namespace]; !ok {
-		f.resources[namespace] = make(map[string]*unstructured.Unstructured)
+	if _, ok := f.resources[gvr]; !ok {
+		f.resources[gvr] = make(map[string]map[string]*unstructured.Unstructured)
 	}
+	if _, ok := f.resources[gvr][namespace]; !ok {
+		f.resources[gvr][namespace] = make(map[string]*unstructured.Unstructured)
+	}
+	seeded := obj.DeepCopy()
+	f.assignUID(seeded)
+	seeded.SetResourceVersion(f.nextResourceVersion())
+	f.resources[gvr][namespace][name] = seeded
+}
 
+// SeedProxyRule adds a proxy rule to the fake client
+func (f *FakeDynamicClient) SeedProxyRule(name, namespace, domain, destination string, port int) {
 	obj := NewProxyRule(name, domain, destination, port)
 	obj.SetNamespace(namespace)
-	f.resources[namespace][name] = obj
+	f.Seed(ProxyRulesGVR, obj)
+}
+
+// RecordedEvent is an event captured by FakeEventRecorder
+type RecordedEvent struct {
+	Name      string
+	EventType string
+	Reason    string
+	Message   string
+}
+
+// FakeEventRecorder captures events in memory instead of writing them to a cluster
+type FakeEventRecorder struct {
+	Events []RecordedEvent
+	mu     sync.Mutex
+}
+
+// NewFakeEventRecorder creates a new fake event recorder
+func NewFakeEventRecorder() *FakeEventRecorder {
+	return &FakeEventRecorder{}
+}
+
+// Event records an event about obj
+func (f *FakeEventRecorder) Event(obj *unstructured.Unstructured, eventType, reason, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Events = append(f.Events, RecordedEvent{
+		Name:      obj.GetName(),
+		EventType: eventType,
+		Reason:    reason,
+		Message:   message,
+	})
 }
@@ -0,0 +1,255 @@
+package testutil
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFakeDynamicClient_ListLabelSelector(t *testing.T) {
+	client := NewFakeDynamicClient()
+	for name, team := range map[string]string{"rule-a": "web", "rule-b": "api", "rule-c": "web"} {
+		rule := NewProxyRule(name, name+".example.com", "10.0.0.50", 3000)
+		rule.SetLabels(map[string]string{"team": team})
+		client.Seed(ProxyRulesGVR, rule)
+	}
+	client.SeedProxyRule("rule-d", "proxy-rules", "rule-d.example.com", "10.0.0.50", 3000)
+
+	tests := []struct {
+		name      string
+		selector  string
+		want      []string
+		wantError bool
+	}{
+		{
+			name:     "empty selector matches everything",
+			selector: "",
+			want:     []string{"rule-a", "rule-b", "rule-c", "rule-d"},
+		},
+		{
+			name:     "equality selector",
+			selector: "team=web",
+			want:     []string{"rule-a", "rule-c"},
+		},
+		{
+			name:     "inequality selector",
+			selector: "team!=web",
+			want:     []string{"rule-b", "rule-d"},
+		},
+		{
+			name:     "existence selector",
+			selector: "team",
+			want:     []string{"rule-a", "rule-b", "rule-c"},
+		},
+		{
+			name:      "invalid selector",
+			selector:  "team in (",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := client.Resource(ProxyRulesGVR).Namespace("proxy-rules").List(context.Background(), metav1.ListOptions{LabelSelector: tt.selector})
+			if (err != nil) != tt.wantError {
+				t.Fatalf("List() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+
+			var got []string
+			for _, item := range list.Items {
+				got = append(got, item.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeDynamicClient_ListPagination(t *testing.T) {
+	client := NewFakeDynamicClient()
+	for _, name := range []string{"rule-e", "rule-c", "rule-a", "rule-d", "rule-b"} {
+		client.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.50", 3000)
+	}
+
+	resource := client.Resource(ProxyRulesGVR).Namespace("proxy-rules")
+
+	var pages [][]string
+	opts := metav1.ListOptions{Limit: 2}
+	for {
+		list, err := resource.List(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+
+		var page []string
+		for _, item := range list.Items {
+			page = append(page, item.GetName())
+		}
+		pages = append(pages, page)
+
+		if list.GetContinue() == "" {
+			break
+		}
+		opts.Continue = list.GetContinue()
+	}
+
+	want := [][]string{{"rule-a", "rule-b"}, {"rule-c", "rule-d"}, {"rule-e"}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+
+	if _, err := resource.List(context.Background(), metav1.ListOptions{Continue: "bogus"}); err == nil {
+		t.Error("expected error for invalid continue token")
+	}
+}
+
+func TestFakeDynamicClient_ListPaginationConcurrentChanges(t *testing.T) {
+	client := NewFakeDynamicClient()
+	for _, name := range []string{"rule-b", "rule-d", "rule-f"} {
+		client.SeedProxyRule(name, "proxy-rules", name+".example.com", "10.0.0.50", 3000)
+	}
+
+	resource := client.Resource(ProxyRulesGVR).Namespace("proxy-rules")
+
+	first, err := resource.List(context.Background(), metav1.ListOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	// A rule sorting before the token and the first rule of the page are
+	// replaced by one sorting after it; the next page resumes after rule-d
+	client.SeedProxyRule("rule-a", "proxy-rules", "a.example.com", "10.0.0.50", 3000)
+	if err := resource.Delete(context.Background(), "rule-b", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	client.SeedProxyRule("rule-e", "proxy-rules", "e.example.com", "10.0.0.50", 3000)
+
+	second, err := resource.List(context.Background(), metav1.ListOptions{Limit: 2, Continue: first.GetContinue()})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	var names []string
+	for _, item := range second.Items {
+		names = append(names, item.GetName())
+	}
+	if want := []string{"rule-e", "rule-f"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("second page = %v, want %v", names, want)
+	}
+	if second.GetContinue() != "" {
+		t.Errorf("expected the second page to be the last, got continue token %q", second.GetContinue())
+	}
+}
+
+func TestFakeDynamicClient_AssignsUIDs(t *testing.T) {
+	client := NewFakeDynamicClient()
+	resource := client.Resource(ProxyRulesGVR).Namespace("proxy-rules")
+
+	created, err := resource.Create(context.Background(), NewProxyRule("rule-a", "a.example.com", "10.0.0.50", 3000), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.GetUID() == "" {
+		t.Fatal("expected Create to assign a UID")
+	}
+
+	updated, err := resource.Update(context.Background(), created, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.GetUID() != created.GetUID() {
+		t.Errorf("expected Update to keep UID %s, got %s", created.GetUID(), updated.GetUID())
+	}
+
+	// A rule recreated under the same name gets a new UID
+	if err := resource.Delete(context.Background(), "rule-a", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	recreated, err := resource.Create(context.Background(), created, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if recreated.GetUID() == created.GetUID() {
+		t.Errorf("expected recreated rule to get a new UID, got %s again", recreated.GetUID())
+	}
+}
+
+func TestFakeDynamicClient_DryRun(t *testing.T) {
+	client := NewFakeDynamicClient()
+	client.SeedProxyRule("rule-a", "proxy-rules", "a.example.com", "10.0.0.50", 3000)
+	resource := client.Resource(ProxyRulesGVR).Namespace("proxy-rules")
+	dryRun := []string{metav1.DryRunAll}
+
+	if _, err := resource.Create(context.Background(), NewProxyRule("rule-b", "b.example.com", "10.0.0.51", 3000), metav1.CreateOptions{DryRun: dryRun}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := resource.Get(context.Background(), "rule-b", metav1.GetOptions{}); err == nil {
+		t.Error("expected a dry-run Create not to persist the rule")
+	}
+	if _, err := resource.Create(context.Background(), NewProxyRule("rule-a", "a.example.com", "10.0.0.50", 3000), metav1.CreateOptions{DryRun: dryRun}); err == nil {
+		t.Error("expected a dry-run Create of an existing rule to fail")
+	}
+
+	existing, _ := resource.Get(context.Background(), "rule-a", metav1.GetOptions{})
+	changed := existing.DeepCopy()
+	unstructured.SetNestedField(changed.Object, "10.0.0.99", "spec", "destination")
+	updated, err := resource.Update(context.Background(), changed, metav1.UpdateOptions{DryRun: dryRun})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if destination, _, _ := unstructured.NestedString(updated.Object, "spec", "destination"); destination != "10.0.0.99" {
+		t.Errorf("expected the dry-run result to carry the change, got %s", destination)
+	}
+	stored, _ := resource.Get(context.Background(), "rule-a", metav1.GetOptions{})
+	if stored.GetResourceVersion() != existing.GetResourceVersion() {
+		t.Error("expected a dry-run Update not to persist the change")
+	}
+
+	if err := resource.Delete(context.Background(), "rule-a", metav1.DeleteOptions{DryRun: dryRun}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := resource.Get(context.Background(), "rule-a", metav1.GetOptions{}); err != nil {
+		t.Error("expected a dry-run Delete to keep the rule")
+	}
+}
+
+func TestFakeDynamicClient_ListAllNamespaces(t *testing.T) {
+	client := NewFakeDynamicClient()
+	client.SeedProxyRule("rule-b", "team-b", "b.example.com", "10.0.0.50", 3000)
+	client.SeedProxyRule("rule-a", "team-a", "a.example.com", "10.0.0.50", 3000)
+	client.SeedProxyRule("rule-c", "team-a", "c.example.com", "10.0.0.50", 3000)
+
+	tests := []struct {
+		name      string
+		namespace string
+		want      []string
+	}{
+		{name: "single namespace", namespace: "team-a", want: []string{"team-a/rule-a", "team-a/rule-c"}},
+		{name: "all namespaces", namespace: "", want: []string{"team-a/rule-a", "team-a/rule-c", "team-b/rule-b"}},
+		{name: "unknown namespace", namespace: "team-c", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := client.Resource(ProxyRulesGVR).Namespace(tt.namespace).List(context.Background(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			var got []string
+			for _, item := range list.Items {
+				got = append(got, item.GetNamespace()+"/"+item.GetName())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
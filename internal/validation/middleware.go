@@ -1,18 +1,35 @@
 package validation
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
 )
 
 const (
-	// MaxRequestBodySize is the maximum allowed request body size (1MB)
-	MaxRequestBodySize = 1 * 1024 * 1024 // 1MB
+	// DefaultMaxRequestBodySize is the request body limit used unless MAX_BODY_BYTES is set (1MB)
+	DefaultMaxRequestBodySize = 1 * 1024 * 1024 // 1MB
 )
 
-// ValidateJSONRequest validates that the request has appropriate JSON content type and size
-func ValidateJSONRequest(w http.ResponseWriter, r *http.Request) error {
+// requestMediaTypes are the accepted request body types, mapped to whether they are YAML
+var requestMediaTypes = map[string]bool{
+	"application/json": false,
+	"application/yaml": true,
+	"text/yaml":        true,
+}
+
+// ValidateJSONRequest validates that the request has a JSON or YAML content type
+// and limits the body to maxBytes
+func ValidateJSONRequest(w http.ResponseWriter, r *http.Request, maxBytes int64) error {
 	// Check Content-Type header for POST/PUT/PATCH requests
 	if r.Method == http.MethodPost || r.Method == http.MethodPut || r.Method == http.MethodPatch {
 		contentType := r.Header.Get("Content-Type")
@@ -23,23 +40,96 @@ func ValidateJSONRequest(w http.ResponseWriter, r *http.Request) error {
 			}
 		}
 
-		// Check if Content-Type is application/json (allow charset parameter)
-		if contentType != "application/json" && contentType != "application/json; charset=utf-8" {
+		// Check if Content-Type is JSON or YAML (allow a UTF-8 charset parameter)
+		if _, ok := requestMediaType(contentType); !ok {
 			return &ValidationError{
 				Field:   "Content-Type",
-				Message: fmt.Sprintf("Content-Type must be 'application/json', got '%s'", contentType),
+				Message: fmt.Sprintf("Content-Type must be 'application/json', 'application/yaml' or 'text/yaml', got '%s'", contentType),
 			}
 		}
 	}
 
 	// Limit request body size
-	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestBodySize)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
 	return nil
 }
 
-// ValidateRequestBody validates that the request body is not empty and not too large
-func ValidateRequestBody(body []byte) error {
+// requestMediaType returns whether contentType is YAML, and false as second
+// value if it is not an accepted request body type
+func requestMediaType(contentType string) (isYAML bool, ok bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, false
+	}
+	for key, value := range params {
+		if key != "charset" || !strings.EqualFold(value, "utf-8") {
+			return false, false
+		}
+	}
+	isYAML, ok = requestMediaTypes[mediaType]
+	return isYAML, ok
+}
+
+// DecodeRequestBody decodes a JSON or YAML object body according to the
+// request's Content-Type. YAML is converted to JSON first, so both yield the
+// same types (numbers as float64) for validation.
+func DecodeRequestBody(r *http.Request, body []byte) (map[string]interface{}, error) {
+	var obj map[string]interface{}
+	if isYAML, _ := requestMediaType(r.Header.Get("Content-Type")); isYAML {
+		if err := yaml.Unmarshal(body, &obj); err != nil {
+			return nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+	} else if err := json.Unmarshal(body, &obj); err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+
+	if obj == nil {
+		return nil, fmt.Errorf("parsing request body: must be an object")
+	}
+	return obj, nil
+}
+
+// DecodeRequestDocuments decodes a body holding several objects according to
+// the request's Content-Type: a multi-document YAML stream, or a JSON array.
+// Empty YAML documents are skipped.
+func DecodeRequestDocuments(r *http.Request, body []byte) ([]map[string]interface{}, error) {
+	if isYAML, _ := requestMediaType(r.Header.Get("Content-Type")); !isYAML {
+		var objs []map[string]interface{}
+		if err := json.Unmarshal(body, &objs); err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w (expected an array of objects)", err)
+		}
+		for i, obj := range objs {
+			if obj == nil {
+				return nil, fmt.Errorf("parsing JSON: item %d must be an object", i)
+			}
+		}
+		return objs, nil
+	}
+
+	var objs []map[string]interface{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(body)))
+	for document := 1; ; document++ {
+		data, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing YAML document %d: %w", document, err)
+		}
+
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return nil, fmt.Errorf("parsing YAML document %d: %w", document, err)
+		}
+		if obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+}
+
+// ValidateRequestBody validates that the request body is not empty and not larger than maxBytes
+func ValidateRequestBody(body []byte, maxBytes int64) error {
 	if len(body) == 0 {
 		return &ValidationError{
 			Field:   "body",
@@ -47,36 +137,92 @@ func ValidateRequestBody(body []byte) error {
 		}
 	}
 
-	if len(body) > MaxRequestBodySize {
+	if int64(len(body)) > maxBytes {
 		return &ValidationError{
 			Field:   "body",
-			Message: fmt.Sprintf("request body size exceeds maximum of %d bytes", MaxRequestBodySize),
+			Message: fmt.Sprintf("request body size exceeds maximum of %d bytes", maxBytes),
 		}
 	}
 
 	return nil
 }
 
-// HandleValidationError sends an appropriate error response for validation errors
-func HandleValidationError(w http.ResponseWriter, err error) {
+// ValidationErrorResponse is the JSON body of 400 responses for malformed
+// requests and 422 responses for rules that fail validation
+type ValidationErrorResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Errors  ValidationErrors `json:"errors"`
+}
+
+// HandleValidationError sends an appropriate error response for validation errors.
+// A single *ValidationError is a problem with the request itself, such as its
+// Content-Type or an empty body, and is answered with 400. ValidationErrors are
+// what rule validation returns for a well-formed body that describes an invalid
+// rule, and are answered with 422. Both are written as a ValidationErrorResponse,
+// or as plain text for clients that ask for text/plain in their Accept header.
+func HandleValidationError(w http.ResponseWriter, r *http.Request, err error) {
 	if validationErr, ok := err.(*ValidationError); ok {
-		http.Error(w, validationErr.Error(), http.StatusBadRequest)
+		writeValidationErrors(w, r, http.StatusBadRequest, ValidationErrors{*validationErr})
 		return
 	}
 
 	if validationErrs, ok := err.(ValidationErrors); ok {
 		if len(validationErrs) > 0 {
-			http.Error(w, validationErrs.Error(), http.StatusBadRequest)
+			writeValidationErrors(w, r, http.StatusUnprocessableEntity, validationErrs)
 			return
 		}
 	}
 
 	// Check for MaxBytesReader error
-	if err == io.ErrUnexpectedEOF || err.Error() == "http: request body too large" {
-		http.Error(w, fmt.Sprintf("request body too large (max %d bytes)", MaxRequestBodySize), http.StatusRequestEntityTooLarge)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("request body too large (max %d bytes)", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err == io.ErrUnexpectedEOF {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
 		return
 	}
 
 	// Generic error
 	http.Error(w, fmt.Sprintf("validation error: %v", err), http.StatusBadRequest)
 }
+
+// writeValidationErrors responds with status and errs in the format the client accepts
+func writeValidationErrors(w http.ResponseWriter, r *http.Request, status int, errs ValidationErrors) {
+	if prefersPlainText(r) {
+		http.Error(w, errs.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	// Keep messages verbatim; they may quote values such as <, > or &
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(ValidationErrorResponse{
+		Status:  status,
+		Message: errs.Error(),
+		Errors:  errs,
+	})
+}
+
+// prefersPlainText reports whether the Accept header lists text/plain before
+// any JSON type. Without an Accept header, or with only wildcards, JSON is used.
+func prefersPlainText(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case "text/plain":
+			return true
+		case "application/json", "application/*":
+			return false
+		}
+	}
+	return false
+}
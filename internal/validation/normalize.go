@@ -0,0 +1,120 @@
+package validation
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NormalizeProxyRule rewrites the domains, DNS-name destinations and upstream server name of a ProxyRule
+// into their canonical form (lowercase, no trailing dot) so that equivalent names
+// are stored identically. It must run before validation: a single trailing dot
+// (fully-qualified form) is accepted and stripped, anything else is left for the
+// validators to reject. Values of the wrong type are left untouched.
+func NormalizeProxyRule(obj *unstructured.Unstructured) {
+	spec, ok := obj.Object["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	normalizeStringField(spec, "domain", normalizeDNSName)
+	normalizeStringSliceField(spec, "domains", normalizeDNSName)
+	normalizeDestinationScheme(spec)
+	normalizeBracketedDestination(spec)
+	normalizeStringField(spec, "destination", normalizeDestination)
+	normalizeStringSliceField(spec, "destinations", normalizeDestination)
+	normalizeStringField(spec, "upstreamServerName", normalizeDNSName)
+}
+
+// normalizeDestinationScheme moves an http:// or https:// prefix of spec.destination
+// into spec.destinationScheme, defaulting the scheme to http for rules with destinations.
+// Unknown schemes are left in place for validation to reject.
+func normalizeDestinationScheme(spec map[string]interface{}) {
+	if destination, ok := spec["destination"].(string); ok {
+		if scheme, host, found := strings.Cut(destination, "://"); found && isSupportedScheme(strings.ToLower(scheme)) {
+			spec["destination"] = host
+			spec["destinationScheme"] = strings.ToLower(scheme)
+		}
+	}
+
+	_, hasDestination := spec["destination"]
+	_, hasDestinations := spec["destinations"]
+	if scheme, ok := spec["destinationScheme"].(string); ok {
+		spec["destinationScheme"] = strings.ToLower(scheme)
+	} else if _, found := spec["destinationScheme"]; !found && (hasDestination || hasDestinations) {
+		spec["destinationScheme"] = DefaultDestinationScheme
+	}
+}
+
+// normalizeBracketedDestination splits a bracketed IPv6 spec.destination as
+// written in URLs, "[2001:db8::1]" or "[2001:db8::1]:8080", into the address and
+// spec.port. A port contradicting spec.port is left in place for validation to reject.
+func normalizeBracketedDestination(spec map[string]interface{}) {
+	destination, ok := spec["destination"].(string)
+	if !ok || !strings.HasPrefix(destination, "[") {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(destination)
+	if err != nil {
+		if !strings.HasSuffix(destination, "]") {
+			return
+		}
+		host, port = destination[1:len(destination)-1], ""
+	}
+
+	if port != "" {
+		number, err := strconv.ParseInt(port, 10, 64)
+		if err != nil {
+			return
+		}
+		if existing, found := spec["port"]; !found {
+			spec["port"] = number
+		} else if value, ok := integer(existing); !ok || value != number {
+			return
+		}
+	}
+	spec["destination"] = host
+}
+
+// normalizeDNSName lowercases name and strips a single trailing dot
+func normalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// normalizeDestination stores IPv4 addresses in canonical form and normalizes
+// DNS-name destinations, leaving IPv6 addresses as submitted
+func normalizeDestination(destination string) string {
+	if addr, err := netip.ParseAddr(destination); err == nil && addr.Is4() {
+		return addr.String()
+	}
+	if net.ParseIP(destination) != nil {
+		return destination
+	}
+	return normalizeDNSName(destination)
+}
+
+// normalizeStringField applies normalize to spec[field] if it is a string
+func normalizeStringField(spec map[string]interface{}, field string, normalize func(string) string) {
+	if value, ok := spec[field].(string); ok {
+		spec[field] = normalize(value)
+	}
+}
+
+// normalizeStringSliceField applies normalize to each string element of spec[field].
+// Elements are rewritten in place and never reordered, since the order of
+// destinations is significant for the failover strategy.
+func normalizeStringSliceField(spec map[string]interface{}, field string, normalize func(string) string) {
+	values, ok := spec[field].([]interface{})
+	if !ok {
+		return
+	}
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			values[i] = normalize(s)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNormalizeProxyRule(t *testing.T) {
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		want map[string]interface{}
+	}{
+		{
+			name: "uppercase domain with trailing dot",
+			spec: map[string]interface{}{"domain": "Example.COM."},
+			want: map[string]interface{}{"domain": "example.com"},
+		},
+		{
+			name: "domains array",
+			spec: map[string]interface{}{"domains": []interface{}{"A.Example.com.", "b.example.com"}},
+			want: map[string]interface{}{"domains": []interface{}{"a.example.com", "b.example.com"}},
+		},
+		{
+			name: "wildcard domain",
+			spec: map[string]interface{}{"domain": "*.Example.com"},
+			want: map[string]interface{}{"domain": "*.example.com"},
+		},
+		{
+			name: "dns destinations",
+			spec: map[string]interface{}{
+				"destination":  "Backend.Local.",
+				"destinations": []interface{}{"Backend-1.Local.", "10.0.0.50"},
+			},
+			want: map[string]interface{}{
+				"destination":       "backend.local",
+				"destinations":      []interface{}{"backend-1.local", "10.0.0.50"},
+				"destinationScheme": "http",
+			},
+		},
+		{
+			name: "ipv4 destination with trailing dot canonicalized",
+			spec: map[string]interface{}{"destination": "10.0.0.50.", "destinations": []interface{}{"10.0.0.51."}},
+			want: map[string]interface{}{"destination": "10.0.0.50", "destinations": []interface{}{"10.0.0.51"}, "destinationScheme": "http"},
+		},
+		{
+			name: "ipv4 destination with leading zeros left for validation",
+			spec: map[string]interface{}{"destination": "010.0.0.50"},
+			want: map[string]interface{}{"destination": "010.0.0.50", "destinationScheme": "http"},
+		},
+		{
+			name: "ipv6 destination untouched",
+			spec: map[string]interface{}{"destination": "2001:DB8::1"},
+			want: map[string]interface{}{"destination": "2001:DB8::1", "destinationScheme": "http"},
+		},
+		{
+			name: "https scheme moved to destinationScheme",
+			spec: map[string]interface{}{"destination": "HTTPS://Backend.Local"},
+			want: map[string]interface{}{"destination": "backend.local", "destinationScheme": "https"},
+		},
+		{
+			name: "bracketed ipv6 destination with scheme",
+			spec: map[string]interface{}{"destination": "http://[2001:db8::1]"},
+			want: map[string]interface{}{"destination": "2001:db8::1", "destinationScheme": "http"},
+		},
+		{
+			name: "bracketed ipv6 destination with port",
+			spec: map[string]interface{}{"destination": "[2001:db8::1]:8443"},
+			want: map[string]interface{}{"destination": "2001:db8::1", "port": int64(8443), "destinationScheme": "http"},
+		},
+		{
+			name: "bracketed ipv6 destination with scheme and matching port",
+			spec: map[string]interface{}{"destination": "https://[::1]:8443", "port": float64(8443)},
+			want: map[string]interface{}{"destination": "::1", "port": float64(8443), "destinationScheme": "https"},
+		},
+		{
+			name: "bracketed ipv6 destination with conflicting port left for validation",
+			spec: map[string]interface{}{"destination": "[::1]:8443", "port": float64(80)},
+			want: map[string]interface{}{"destination": "[::1]:8443", "port": float64(80), "destinationScheme": "http"},
+		},
+		{
+			name: "explicit destinationScheme kept",
+			spec: map[string]interface{}{"destination": "10.0.0.50", "destinationScheme": "HTTPS"},
+			want: map[string]interface{}{"destination": "10.0.0.50", "destinationScheme": "https"},
+		},
+		{
+			name: "unknown scheme left for validation",
+			spec: map[string]interface{}{"destination": "ftp://backend.local"},
+			want: map[string]interface{}{"destination": "ftp://backend.local", "destinationScheme": "http"},
+		},
+		{
+			name: "only one trailing dot stripped",
+			spec: map[string]interface{}{"domain": "example.com.."},
+			want: map[string]interface{}{"domain": "example.com."},
+		},
+		{
+			name: "wrong types untouched",
+			spec: map[string]interface{}{"domain": int64(1), "domains": "example.com"},
+			want: map[string]interface{}{"domain": int64(1), "domains": "example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			NormalizeProxyRule(obj)
+
+			if !reflect.DeepEqual(obj.Object["spec"], tt.want) {
+				t.Errorf("NormalizeProxyRule() spec = %v, want %v", obj.Object["spec"], tt.want)
+			}
+		})
+	}
+}
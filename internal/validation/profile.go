@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+const (
+	// ValidationProfileAnnotation selects the validation profile of a rule in spec.annotations
+	ValidationProfileAnnotation = "mortar.io/validation-profile"
+	// ValidationProfileDefault applies the standard checks (used when no profile is set)
+	ValidationProfileDefault = "default"
+	// ValidationProfilePublic is for internet-facing rules: fully qualified domains and TLS are
+	// required, and TLS rules with only IP destinations need spec.upstreamServerName
+	ValidationProfilePublic = "public"
+	// ValidationProfileInternal is for cluster-internal rules: destination host names may contain underscores
+	ValidationProfileInternal = "internal"
+)
+
+// validationProfile is the set of checks applied on top of, or instead of, the default ones
+type validationProfile struct {
+	// requireFQDN rejects single-label domains such as "intranet"
+	requireFQDN bool
+	// requireTLS rejects rules that do not set spec.tls to true
+	requireTLS bool
+	// requireUpstreamServerName rejects TLS rules with only IP destinations and no
+	// spec.upstreamServerName, which other profiles merely warn about
+	requireUpstreamServerName bool
+	// relaxedDestinations accepts legacy host names with underscores as destinations
+	relaxedDestinations bool
+}
+
+var validationProfiles = map[string]validationProfile{
+	ValidationProfileDefault:  {},
+	ValidationProfilePublic:   {requireFQDN: true, requireTLS: true, requireUpstreamServerName: true},
+	ValidationProfileInternal: {relaxedDestinations: true},
+}
+
+// relaxedHostRegex is dnsNameRegex with underscores allowed, as found in older internal host names
+var relaxedHostRegex = regexp.MustCompile(`^[a-z0-9_]([-a-z0-9_]*[a-z0-9_])?(\.[a-z0-9_]([-a-z0-9_]*[a-z0-9_])?)*$`)
+
+// ruleProfile returns the validation profile declared in spec.annotations.
+// Rules without the annotation use the default profile.
+func ruleProfile(spec map[string]interface{}) (validationProfile, ValidationErrors) {
+	annotations, _ := spec["annotations"].(map[string]interface{})
+	name, _ := annotations[ValidationProfileAnnotation].(string)
+	if name == "" {
+		return validationProfiles[ValidationProfileDefault], nil
+	}
+
+	profile, ok := validationProfiles[name]
+	if !ok {
+		return validationProfiles[ValidationProfileDefault], ValidationErrors{{
+			Field:   fmt.Sprintf("spec.annotations.%s", ValidationProfileAnnotation),
+			Message: fmt.Sprintf("unknown validation profile '%s' (must be %s, %s or %s)", name, ValidationProfileDefault, ValidationProfilePublic, ValidationProfileInternal),
+		}}
+	}
+	return profile, nil
+}
+
+// validate applies the profile's extra requirements to the spec
+func (p validationProfile) validate(spec map[string]interface{}, domains []string) ValidationErrors {
+	var errors ValidationErrors
+
+	if p.requireFQDN {
+		for _, domain := range domains {
+			if !strings.Contains(strings.TrimPrefix(domain, "*."), ".") {
+				errors = append(errors, ValidationError{
+					Field:   "spec.domain",
+					Message: fmt.Sprintf("domain '%s' must be fully qualified for the %s validation profile", domain, ValidationProfilePublic),
+				})
+			}
+		}
+	}
+
+	if p.requireTLS {
+		if tls, _ := spec["tls"].(bool); !tls {
+			errors = append(errors, ValidationError{
+				Field:   "spec.tls",
+				Message: fmt.Sprintf("tls must be true for the %s validation profile", ValidationProfilePublic),
+			})
+		}
+	}
+
+	if p.requireUpstreamServerName && lacksUpstreamServerName(spec) {
+		errors = append(errors, ValidationError{
+			Field:   "spec.upstreamServerName",
+			Message: fmt.Sprintf("%s (required for the %s validation profile)", missingUpstreamServerNameMessage, ValidationProfilePublic),
+		})
+	}
+
+	return errors
+}
+
+// validateDestination validates a destination under the profile's rules
+func (p validationProfile) validateDestination(destination string, opts Options) ValidationErrors {
+	if p.relaxedDestinations && net.ParseIP(destination) == nil && !ipv4Pattern.MatchString(destination) &&
+		relaxedHostRegex.MatchString(strings.ToLower(destination)) {
+		return nil
+	}
+	return validateDestination(destination, opts)
+}
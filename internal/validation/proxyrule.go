@@ -1,24 +1,95 @@
 package validation
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 // ValidationError represents a validation error with details
 type ValidationError struct {
-	Field   string
-	Message string
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error on field '%s': %s", e.Field, e.Message)
 }
 
+// requestFields name parts of a request rather than fields of its body
+var requestFields = map[string]bool{
+	"body":         true,
+	"Content-Type": true,
+}
+
+// JSONPointer returns the RFC 6901 pointer to the field in the request body,
+// e.g. /spec/destinations/0 for spec.destinations[0]. A choice between two
+// fields such as spec.domain/domains points at their parent, and problems with
+// the request as a whole point at the whole body ("").
+func (e *ValidationError) JSONPointer() string {
+	if requestFields[e.Field] || e.Field == "" {
+		return ""
+	}
+
+	// Label and annotation keys may contain dots and slashes themselves
+	for _, keyed := range []string{"spec.annotations.", "metadata.labels.", "metadata.annotations."} {
+		if key, ok := strings.CutPrefix(e.Field, keyed); ok {
+			return "/" + strings.ReplaceAll(keyed, ".", "/") + escapeJSONPointerToken(key)
+		}
+	}
+
+	field := e.Field
+	if choice := strings.Index(field, "/"); choice >= 0 {
+		field = field[:max(strings.LastIndex(field[:choice], "."), 0)]
+	}
+
+	var pointer strings.Builder
+	for _, segment := range strings.Split(field, ".") {
+		if segment == "" {
+			continue
+		}
+		// destinations[0] addresses element 0 of destinations
+		name, indexes, _ := strings.Cut(segment, "[")
+		pointer.WriteString("/" + escapeJSONPointerToken(name))
+		for _, index := range strings.Split(indexes, "[") {
+			if index = strings.TrimSuffix(index, "]"); index != "" {
+				pointer.WriteString("/" + index)
+			}
+		}
+	}
+	return pointer.String()
+}
+
+// escapeJSONPointerToken escapes ~ and / in a reference token (RFC 6901, section 3)
+func escapeJSONPointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// MarshalJSON adds the field's JSONPointer as "pointer", so clients can map
+// errors to inputs without parsing Field
+func (e ValidationError) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	// Keep messages verbatim; they may quote values such as <, > or &
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	err := encoder.Encode(struct {
+		Field   string `json:"field"`
+		Pointer string `json:"pointer"`
+		Message string `json:"message"`
+	}{e.Field, e.JSONPointer(), e.Message})
+	return bytes.TrimRight(buf.Bytes(), "\n"), err
+}
+
 // ValidationErrors is a collection of validation errors
 type ValidationErrors []ValidationError
 
@@ -37,11 +108,37 @@ func (e ValidationErrors) Error() string {
 // ProxyRuleSpec represents the expected structure of a ProxyRule spec
 type ProxyRuleSpec struct {
 	Domain       string
+	Domains      []string
 	Destination  string
 	Destinations []string
-	Port         int
-	TLS          bool
-	Annotations  map[string]string
+	// DestinationScheme is the protocol spoken to the destinations (http or https)
+	DestinationScheme string
+	// DestinationStrategy is how requests are spread over destinations (roundrobin or failover)
+	DestinationStrategy string
+	Port                int
+	TLS                 bool
+	Annotations         map[string]string
+	// MaxRequestBodyBytes is the data plane's per-rule request body limit, unrelated
+	// to the size limit of requests to this API (MAX_BODY_BYTES)
+	MaxRequestBodyBytes int64
+	// UpstreamServerName is the SNI and certificate name used for TLS to the destinations
+	UpstreamServerName string
+	// Enabled is false for rules that are kept but should not route traffic
+	Enabled bool
+	// Description is a free-text note for people, shown in the UI
+	Description string
+	// HealthCheck is how the proxy checks the destinations; nil leaves it to the proxy
+	HealthCheck *HealthCheck
+}
+
+// HealthCheck describes the HTTP request the proxy uses to check a destination's health
+type HealthCheck struct {
+	// Path is requested on each destination, starting with /
+	Path string
+	// IntervalSeconds is the time between checks; zero uses the proxy's default
+	IntervalSeconds int
+	// ExpectedStatus is the status of a healthy response; zero uses the proxy's default
+	ExpectedStatus int
 }
 
 const (
@@ -49,39 +146,99 @@ const (
 	maxNameLength = 253
 	// maxDomainLength is the maximum length for a domain name
 	maxDomainLength = 253
+	// maxDomainLabelLength is the maximum length of one dot-separated label of a domain
+	maxDomainLabelLength = 63
 	// minPort and maxPort define valid port range
 	minPort = 1
 	maxPort = 65535
+	// DefaultDestinationScheme is the scheme used to reach destinations when none is given
+	DefaultDestinationScheme = "http"
+	// DefaultHTTPPort and DefaultHTTPSPort are the destination ports used when spec.port is omitted
+	DefaultHTTPPort  = 80
+	DefaultHTTPSPort = 443
+	// DestinationStrategyRoundRobin spreads requests across all destinations (the default)
+	DestinationStrategyRoundRobin = "roundrobin"
+	// DestinationStrategyFailover sends requests to the first healthy destination, in list order
+	DestinationStrategyFailover = "failover"
+	// maxRuleRequestBodyBytes is the largest per-rule request body limit accepted (1GiB)
+	maxRuleRequestBodyBytes = 1 << 30
+	// DefaultMaxDestinations is the default limit on the length of spec.destinations
+	DefaultMaxDestinations = 64
+	// maxDescriptionLength is the maximum length of spec.description in characters
+	maxDescriptionLength = 1024
+	// minHealthCheckStatus and maxHealthCheckStatus bound spec.healthCheck.expectedStatus
+	minHealthCheckStatus = 100
+	maxHealthCheckStatus = 599
 )
 
+// Options are the server-wide settings of rule validation. The zero value
+// applies the defaults.
+type Options struct {
+	// MaxDestinations is the largest number of entries accepted in
+	// spec.destinations. It bounds validation work and object size; zero uses
+	// DefaultMaxDestinations.
+	MaxDestinations int
+	// AllowedDestinationCIDRs restricts IP destinations to these ranges; DNS name
+	// destinations are not checked. Empty allows any address.
+	AllowedDestinationCIDRs []netip.Prefix
+	// RequireTLSPort rejects TLS rules without spec.port, which are otherwise only
+	// warned about
+	RequireTLSPort bool
+}
+
+// maxDestinations returns the limit on spec.destinations
+func (o Options) maxDestinations() int {
+	if o.MaxDestinations <= 0 {
+		return DefaultMaxDestinations
+	}
+	return o.MaxDestinations
+}
+
 var (
 	// dnsNameRegex validates DNS names (RFC 1123)
 	dnsNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
 	// k8sNameRegex validates Kubernetes resource names (RFC 1123 subdomain)
 	k8sNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
-	// ipv4Pattern matches strings that look like IPv4 addresses (digits and dots)
-	ipv4Pattern = regexp.MustCompile(`^\d+\.\d+\.\d+\.\d+$`)
+	// ipv4Pattern matches strings that look like IPv4 addresses: only digits and dots.
+	// No DNS name has an all-numeric top-level label, so these are never host names.
+	ipv4Pattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*\.?$`)
 )
 
+// reservedNames are fixed segments of /api/proxyrules/... routes. A rule with
+// one of these names could be created but never addressed by name, because the
+// fixed route is matched first.
+var reservedNames = map[string]bool{
+	"by-uid":         true,
+	"count":          true,
+	"export":         true,
+	"health-summary": true,
+	"import":         true,
+}
+
 // ValidateProxyRuleCreate validates a ProxyRule object for creation
-func ValidateProxyRuleCreate(obj *unstructured.Unstructured) ValidationErrors {
+func ValidateProxyRuleCreate(obj *unstructured.Unstructured, opts Options) ValidationErrors {
 	var errors ValidationErrors
 
 	// Validate metadata
 	errors = append(errors, validateMetadata(obj)...)
 
 	// Validate spec
-	errors = append(errors, validateSpec(obj)...)
+	errors = append(errors, validateSpec(obj, opts)...)
 
 	return errors
 }
 
 // ValidateProxyRuleUpdate validates a ProxyRule object for update
-func ValidateProxyRuleUpdate(obj *unstructured.Unstructured) ValidationErrors {
+func ValidateProxyRuleUpdate(obj *unstructured.Unstructured, opts Options) ValidationErrors {
 	var errors ValidationErrors
 
 	// Validate spec (metadata name cannot be changed in updates)
-	errors = append(errors, validateSpec(obj)...)
+	errors = append(errors, validateSpec(obj, opts)...)
+
+	// Labels and annotations can be replaced in updates
+	metadata, _ := obj.Object["metadata"].(map[string]interface{})
+	errors = append(errors, validateStringMap(metadata, "labels")...)
+	errors = append(errors, validateStringMap(metadata, "annotations")...)
 
 	return errors
 }
@@ -110,45 +267,169 @@ func validateMetadata(obj *unstructured.Unstructured) ValidationErrors {
 				Message: "name must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character",
 			})
 		}
+		if reservedNames[name] {
+			errors = append(errors, ValidationError{
+				Field:   "metadata.name",
+				Message: fmt.Sprintf("name '%s' is reserved for an API route", name),
+			})
+		}
+	}
+
+	metadata, _ := obj.Object["metadata"].(map[string]interface{})
+	errors = append(errors, validateStringMap(metadata, "labels")...)
+	errors = append(errors, validateStringMap(metadata, "annotations")...)
+
+	return errors
+}
+
+// maxAnnotationsBytes is the limit Kubernetes puts on all annotations of an
+// object together, keys and values
+const maxAnnotationsBytes = 256 << 10
+
+// validateStringMap checks metadata.labels or metadata.annotations against the
+// rules of the Kubernetes API server, so that bad keys or values are reported
+// per entry instead of failing the write as a whole
+func validateStringMap(metadata map[string]interface{}, field string) ValidationErrors {
+	var errors ValidationErrors
+
+	raw, found := metadata[field]
+	if !found || raw == nil {
+		return nil
+	}
+	values, ok := raw.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{
+			Field:   "metadata." + field,
+			Message: field + " must be a map of strings",
+		}}
+	}
+
+	// Report entries in a stable order
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kind := strings.TrimSuffix(field, "s")
+	size := 0
+	for _, key := range keys {
+		entryField := fmt.Sprintf("metadata.%s.%s", field, key)
+		for _, msg := range k8svalidation.IsQualifiedName(key) {
+			errors = append(errors, ValidationError{
+				Field:   entryField,
+				Message: fmt.Sprintf("invalid %s key: %s", kind, msg),
+			})
+		}
+
+		value, ok := values[key].(string)
+		if !ok {
+			errors = append(errors, ValidationError{
+				Field:   entryField,
+				Message: kind + " value must be a string",
+			})
+			continue
+		}
+		if field == "labels" {
+			for _, msg := range k8svalidation.IsValidLabelValue(value) {
+				errors = append(errors, ValidationError{
+					Field:   entryField,
+					Message: fmt.Sprintf("invalid label value: %s", msg),
+				})
+			}
+		}
+		size += len(key) + len(value)
+	}
+
+	if field == "annotations" && size > maxAnnotationsBytes {
+		errors = append(errors, ValidationError{
+			Field:   "metadata.annotations",
+			Message: fmt.Sprintf("annotations must not exceed %d bytes in total, got %d", maxAnnotationsBytes, size),
+		})
 	}
 
 	return errors
 }
 
 // validateSpec validates the spec section
-func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
+func validateSpec(obj *unstructured.Unstructured, opts Options) ValidationErrors {
 	var errors ValidationErrors
 
-	spec, found, err := unstructured.NestedMap(obj.Object, "spec")
-	if err != nil {
+	rawSpec, found := obj.Object["spec"]
+	if !found {
 		errors = append(errors, ValidationError{
 			Field:   "spec",
-			Message: fmt.Sprintf("invalid spec structure: %v", err),
+			Message: "spec is required",
 		})
 		return errors
 	}
-	if !found {
+	if _, ok := rawSpec.(map[string]interface{}); !ok {
 		errors = append(errors, ValidationError{
 			Field:   "spec",
-			Message: "spec is required",
+			Message: fmt.Sprintf("spec must be an object, got %s", jsonTypeName(rawSpec)),
 		})
 		return errors
 	}
 
-	// Validate domain (required)
-	domain, found, err := unstructured.NestedString(spec, "domain")
+	spec, _, err := unstructured.NestedMap(obj.Object, "spec")
 	if err != nil {
 		errors = append(errors, ValidationError{
-			Field:   "spec.domain",
-			Message: fmt.Sprintf("invalid domain type: %v", err),
+			Field:   "spec",
+			Message: fmt.Sprintf("invalid spec structure: %v", err),
+		})
+		return errors
+	}
+
+	// Rules may opt into a stricter or more lenient validation profile
+	profile, profileErrs := ruleProfile(spec)
+	errors = append(errors, profileErrs...)
+
+	// Validate domain/domains (at least one is required)
+	domain, domainFound, domainErr := unstructured.NestedString(spec, "domain")
+	domains, domainsFound, domainsErr := unstructured.NestedStringSlice(spec, "domains")
+
+	// Check if at least one is provided
+	if (!domainFound || domain == "") && (!domainsFound || len(domains) == 0) {
+		errors = append(errors, ValidationError{
+			Field:   "spec.domain/domains",
+			Message: "either domain or domains is required",
 		})
-	} else if !found || domain == "" {
+	}
+
+	// Validate single domain if provided
+	if domainErr != nil {
 		errors = append(errors, ValidationError{
 			Field:   "spec.domain",
-			Message: "domain is required",
+			Message: fmt.Sprintf("invalid domain type: %v", domainErr),
 		})
-	} else {
-		errors = append(errors, validateDomain(domain)...)
+	} else if domainFound && domain != "" {
+		errors = append(errors, ValidateDomain(domain)...)
+	}
+
+	// Validate domains array if provided
+	if domainsErr != nil {
+		errors = append(errors, ValidationError{
+			Field:   "spec.domains",
+			Message: fmt.Sprintf("invalid domains type: %v", domainsErr),
+		})
+	} else if domainsFound && len(domains) > 0 {
+		for i, d := range domains {
+			if d == "" {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("spec.domains[%d]", i),
+					Message: "domain cannot be empty",
+				})
+			} else {
+				// Validate each domain and prefix field name with index
+				domainErrors := ValidateDomain(d)
+				for _, e := range domainErrors {
+					errors = append(errors, ValidationError{
+						Field:   fmt.Sprintf("spec.domains[%d]", i),
+						Message: e.Message,
+					})
+				}
+			}
+		}
 	}
 
 	// Validate destination/destinations (at least one is required)
@@ -163,6 +444,14 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 		})
 	}
 
+	// Which of the two the data plane honors is undefined, so only one may be set
+	if destFound && destination != "" && destsFound && len(destinations) > 0 {
+		errors = append(errors, ValidationError{
+			Field:   "spec.destination/destinations",
+			Message: "destination and destinations are mutually exclusive; list every destination in destinations",
+		})
+	}
+
 	// Validate single destination if provided
 	if destErr != nil {
 		errors = append(errors, ValidationError{
@@ -170,7 +459,14 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 			Message: fmt.Sprintf("invalid destination type: %v", destErr),
 		})
 	} else if destFound && destination != "" {
-		errors = append(errors, validateDestination(destination)...)
+		if scheme, _, found := strings.Cut(destination, "://"); found {
+			errors = append(errors, ValidationError{
+				Field:   "spec.destination",
+				Message: fmt.Sprintf("unsupported destination scheme '%s' (must be http or https)", scheme),
+			})
+		} else {
+			errors = append(errors, profile.validateDestination(destination, opts)...)
+		}
 	}
 
 	// Validate destinations array if provided
@@ -179,16 +475,35 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 			Field:   "spec.destinations",
 			Message: fmt.Sprintf("invalid destinations type: %v", destsErr),
 		})
+	} else if len(destinations) > opts.maxDestinations() {
+		// Reject oversized lists before validating each entry
+		errors = append(errors, ValidationError{
+			Field:   "spec.destinations",
+			Message: fmt.Sprintf("destinations must not have more than %d entries, got %d", opts.maxDestinations(), len(destinations)),
+		})
 	} else if destsFound && len(destinations) > 0 {
+		seen := make(map[string]int, len(destinations))
 		for i, dest := range destinations {
+			first, duplicate := seen[destinationKey(dest)]
 			if dest == "" {
 				errors = append(errors, ValidationError{
 					Field:   fmt.Sprintf("spec.destinations[%d]", i),
 					Message: "destination cannot be empty",
 				})
+			} else if duplicate {
+				// Flag the later occurrence of a destination listed twice
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("spec.destinations[%d]", i),
+					Message: fmt.Sprintf("duplicate destination '%s' (same as spec.destinations[%d])", dest, first),
+				})
+			} else if strings.Contains(dest, "://") {
+				errors = append(errors, ValidationError{
+					Field:   fmt.Sprintf("spec.destinations[%d]", i),
+					Message: "destinations must not carry a scheme; set spec.destinationScheme instead",
+				})
 			} else {
 				// Validate each destination and prefix field name with index
-				destErrors := validateDestination(dest)
+				destErrors := profile.validateDestination(dest, opts)
 				for _, e := range destErrors {
 					errors = append(errors, ValidationError{
 						Field:   fmt.Sprintf("spec.destinations[%d]", i),
@@ -196,6 +511,29 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 					})
 				}
 			}
+			if !duplicate {
+				seen[destinationKey(dest)] = i
+			}
+		}
+	}
+
+	// Validate destinationScheme (optional)
+	if schemeVal, found := spec["destinationScheme"]; found {
+		if scheme, ok := schemeVal.(string); !ok || !isSupportedScheme(scheme) {
+			errors = append(errors, ValidationError{
+				Field:   "spec.destinationScheme",
+				Message: "destinationScheme must be http or https",
+			})
+		}
+	}
+
+	// Validate destinationStrategy (optional); with failover the order of destinations is significant
+	if strategyVal, found := spec["destinationStrategy"]; found {
+		if strategy, ok := strategyVal.(string); !ok || (strategy != DestinationStrategyRoundRobin && strategy != DestinationStrategyFailover) {
+			errors = append(errors, ValidationError{
+				Field:   "spec.destinationStrategy",
+				Message: fmt.Sprintf("destinationStrategy must be %s or %s", DestinationStrategyRoundRobin, DestinationStrategyFailover),
+			})
 		}
 	}
 
@@ -218,6 +556,29 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 		}
 	}
 
+	// Validate maxRequestBodyBytes (optional, omit for no per-rule limit)
+	if limitVal, found := spec["maxRequestBodyBytes"]; found {
+		switch limit := limitVal.(type) {
+		case int64:
+			errors = append(errors, validateMaxRequestBodyBytes(limit)...)
+		case float64:
+			// JSON numbers unmarshal as float64; only whole numbers are accepted
+			if limit != float64(int64(limit)) {
+				errors = append(errors, ValidationError{
+					Field:   "spec.maxRequestBodyBytes",
+					Message: "maxRequestBodyBytes must be an integer",
+				})
+			} else {
+				errors = append(errors, validateMaxRequestBodyBytes(int64(limit))...)
+			}
+		default:
+			errors = append(errors, ValidationError{
+				Field:   "spec.maxRequestBodyBytes",
+				Message: "maxRequestBodyBytes must be an integer",
+			})
+		}
+	}
+
 	// Validate TLS (optional)
 	if tlsVal, found := spec["tls"]; found {
 		if _, ok := tlsVal.(bool); !ok {
@@ -228,6 +589,62 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 		}
 	}
 
+	// TLS backends rarely listen on the port the proxy falls back to
+	if opts.RequireTLSPort && lacksTLSPort(spec) {
+		errors = append(errors, ValidationError{
+			Field:   "spec.port",
+			Message: missingTLSPortMessage(spec),
+		})
+	}
+
+	// Validate enabled (optional)
+	if enabledVal, found := spec["enabled"]; found {
+		if _, ok := enabledVal.(bool); !ok {
+			errors = append(errors, ValidationError{
+				Field:   "spec.enabled",
+				Message: "enabled must be a boolean",
+			})
+		}
+	}
+
+	// Validate description (optional)
+	if descriptionVal, found := spec["description"]; found {
+		if description, ok := descriptionVal.(string); !ok {
+			errors = append(errors, ValidationError{
+				Field:   "spec.description",
+				Message: "description must be a string",
+			})
+		} else if utf8.RuneCountInString(description) > maxDescriptionLength {
+			errors = append(errors, ValidationError{
+				Field:   "spec.description",
+				Message: fmt.Sprintf("description must not exceed %d characters", maxDescriptionLength),
+			})
+		}
+	}
+
+	// Validate upstreamServerName (optional)
+	if nameVal, found := spec["upstreamServerName"]; found {
+		if name, ok := nameVal.(string); !ok {
+			errors = append(errors, ValidationError{
+				Field:   "spec.upstreamServerName",
+				Message: "upstreamServerName must be a string",
+			})
+		} else {
+			errors = append(errors, validateUpstreamServerName(name)...)
+		}
+	}
+
+	// Validate healthCheck (optional); there must be something to check
+	if healthCheckVal, found := spec["healthCheck"]; found {
+		errors = append(errors, validateHealthCheck(healthCheckVal)...)
+		if (!destFound || destination == "") && (!destsFound || len(destinations) == 0) {
+			errors = append(errors, ValidationError{
+				Field:   "spec.healthCheck",
+				Message: "healthCheck needs at least one destination to check",
+			})
+		}
+	}
+
 	// Validate annotations (optional)
 	if annotationsVal, found := spec["annotations"]; found {
 		annotations, ok := annotationsVal.(map[string]interface{})
@@ -248,11 +665,85 @@ func validateSpec(obj *unstructured.Unstructured) ValidationErrors {
 		}
 	}
 
+	// Apply the profile's extra requirements
+	var allDomains []string
+	if domainFound && domainErr == nil && domain != "" {
+		allDomains = append(allDomains, domain)
+	}
+	if domainsErr == nil {
+		for _, d := range domains {
+			if d != "" {
+				allDomains = append(allDomains, d)
+			}
+		}
+	}
+
+	// A destination that is one of the rule's own domains sends requests back into the proxy
+	if destErr == nil && destFound && destination != "" {
+		errors = append(errors, validateNoSelfLoop("spec.destination", destination, allDomains)...)
+	}
+	if destsErr == nil {
+		for i, dest := range destinations {
+			errors = append(errors, validateNoSelfLoop(fmt.Sprintf("spec.destinations[%d]", i), dest, allDomains)...)
+		}
+	}
+
+	errors = append(errors, profile.validate(spec, allDomains)...)
+
 	return errors
 }
 
-// validateDomain validates a domain name (including wildcard domains)
-func validateDomain(domain string) ValidationErrors {
+// jsonTypeName names the JSON type of a decoded value for error messages
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return "a number"
+	}
+}
+
+// validateNoSelfLoop rejects a destination that one of the rule's domains routes
+func validateNoSelfLoop(field, destination string, domains []string) ValidationErrors {
+	for _, domain := range domains {
+		if DestinationLoopsTo(destination, domain) {
+			return ValidationErrors{{
+				Field:   field,
+				Message: fmt.Sprintf("destination '%s' is routed by the rule's own domain '%s'; the proxy would forward requests to itself", destination, domain),
+			}}
+		}
+	}
+	return nil
+}
+
+// DestinationLoopsTo reports whether requests to destination would be routed by
+// domain again, i.e. destination is domain or matched by it as a wildcard.
+// Names are compared case-insensitively without trailing dots.
+func DestinationLoopsTo(destination, domain string) bool {
+	destination = strings.ToLower(strings.TrimSuffix(destination, "."))
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if destination == domain {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(domain, "*.")
+	if !ok {
+		return false
+	}
+	label, rest, found := strings.Cut(destination, ".")
+	return found && label != "" && rest == suffix
+}
+
+// ValidateDomain validates a domain name (including wildcard domains) as it
+// would be stored in spec.domain
+func ValidateDomain(domain string) ValidationErrors {
 	var errors ValidationErrors
 
 	if len(domain) > maxDomainLength {
@@ -295,6 +786,17 @@ func validateDomain(domain string) ValidationErrors {
 		return errors
 	}
 
+	// DNS limits every label, not just the whole name; empty labels are
+	// reported by the dot checks above
+	for _, label := range strings.Split(domainToValidate, ".") {
+		if len(label) > maxDomainLabelLength {
+			errors = append(errors, ValidationError{
+				Field:   "spec.domain",
+				Message: fmt.Sprintf("domain label '%s' must not exceed %d characters", label, maxDomainLabelLength),
+			})
+		}
+	}
+
 	// Check if it's a valid DNS name
 	if !dnsNameRegex.MatchString(strings.ToLower(domainToValidate)) {
 		errors = append(errors, ValidationError{
@@ -307,24 +809,39 @@ func validateDomain(domain string) ValidationErrors {
 }
 
 // validateDestination validates a destination (IP address or DNS name)
-func validateDestination(destination string) ValidationErrors {
+func validateDestination(destination string, opts Options) ValidationErrors {
 	var errors ValidationErrors
 
-	// Check if it looks like an IPv4 address
-	if ipv4Pattern.MatchString(destination) {
-		// If it matches the IPv4 pattern, it must be a valid IP
-		if net.ParseIP(destination) == nil {
-			errors = append(errors, ValidationError{
-				Field:   "spec.destination",
-				Message: "destination appears to be an IPv4 address but is invalid (octets must be 0-255)",
-			})
-		}
+	// DNS names never contain colons, brackets or percent signs, so these are
+	// IPv6 addresses or attempts at one
+	switch {
+	case strings.HasPrefix(destination, "["):
+		errors = append(errors, ValidationError{
+			Field:   "spec.destination",
+			Message: "destination must not be bracketed; write IPv6 addresses without brackets and set spec.port for the port",
+		})
+		return errors
+	case strings.Contains(destination, "%"):
+		errors = append(errors, ValidationError{
+			Field:   "spec.destination",
+			Message: fmt.Sprintf("destination must not contain an IPv6 zone id ('%s'); the proxy cannot reach link-local addresses", destination[strings.Index(destination, "%"):]),
+		})
+		return errors
+	case strings.Count(destination, ":") == 1:
+		// IPv6 addresses have at least two colons
+		errors = append(errors, ValidationError{
+			Field:   "spec.destination",
+			Message: "destination must not include a port; set spec.port instead",
+		})
 		return errors
+	case strings.Contains(destination, ":"):
+		return validateIPv6Destination(destination, opts)
 	}
 
-	// Check if it's a valid IPv6 address
-	if net.ParseIP(destination) != nil {
-		return errors // Valid IPv6 address
+	// Check if it looks like an IPv4 address
+	if ipv4Pattern.MatchString(destination) {
+		// If it matches the IPv4 pattern, it must be a valid IP
+		return validateIPv4Destination(destination, opts)
 	}
 
 	// Otherwise, validate as DNS name
@@ -354,6 +871,256 @@ func validateDestination(destination string) ValidationErrors {
 	return errors
 }
 
+// validateIPv4Destination validates a destination of only digits and dots, which
+// must be an IPv4 address in canonical dotted-decimal form. Octets with leading
+// zeros are rejected rather than guessed at: some resolvers read 010 as octal 8.
+func validateIPv4Destination(destination string, opts Options) ValidationErrors {
+	invalid := func(reason string) ValidationErrors {
+		return ValidationErrors{{
+			Field:   "spec.destination",
+			Message: "destination appears to be an IPv4 address but is invalid: " + reason,
+		}}
+	}
+
+	if strings.HasSuffix(destination, ".") {
+		return invalid("it ends with a dot")
+	}
+	// The pattern guarantees non-empty octets of digits
+	octets := strings.Split(destination, ".")
+	if len(octets) != 4 {
+		return invalid(fmt.Sprintf("it has %d octets instead of four", len(octets)))
+	}
+	decimal := make([]string, len(octets))
+	leadingZero := ""
+	for i, octet := range octets {
+		value, err := strconv.Atoi(octet)
+		if err != nil || value > 255 {
+			return invalid(fmt.Sprintf("octet '%s' is out of range 0-255", octet))
+		}
+		if len(octet) > 1 && octet[0] == '0' && leadingZero == "" {
+			leadingZero = octet
+		}
+		decimal[i] = strconv.Itoa(value)
+	}
+	if leadingZero != "" {
+		return invalid(fmt.Sprintf("octet '%s' has a leading zero, which is ambiguous (octal or decimal); write %s if the octets are decimal", leadingZero, strings.Join(decimal, ".")))
+	}
+
+	addr, err := netip.ParseAddr(destination)
+	if err != nil || !addr.Is4() {
+		return invalid("must be four octets of 0-255 without leading zeros")
+	}
+	return validateDestinationAllowed(addr, opts)
+}
+
+// validateIPv6Destination validates a destination containing a colon, which
+// must be a plain IPv6 address the proxy can connect to
+func validateIPv6Destination(destination string, opts Options) ValidationErrors {
+	addr, err := netip.ParseAddr(destination)
+	if err != nil || !addr.Is6() {
+		return ValidationErrors{{
+			Field:   "spec.destination",
+			Message: "destination appears to be an IPv6 address but is invalid",
+		}}
+	}
+	if addr.Is4In6() {
+		return ValidationErrors{{
+			Field:   "spec.destination",
+			Message: fmt.Sprintf("destination must not be an IPv4-mapped IPv6 address; use %s instead", addr.Unmap()),
+		}}
+	}
+	return validateDestinationAllowed(addr, opts)
+}
+
+// validateDestinationAllowed checks an IP destination against opts.AllowedDestinationCIDRs
+func validateDestinationAllowed(addr netip.Addr, opts Options) ValidationErrors {
+	if len(opts.AllowedDestinationCIDRs) == 0 {
+		return nil
+	}
+	for _, prefix := range opts.AllowedDestinationCIDRs {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	ranges := make([]string, len(opts.AllowedDestinationCIDRs))
+	for i, prefix := range opts.AllowedDestinationCIDRs {
+		ranges[i] = prefix.String()
+	}
+	return ValidationErrors{{
+		Field:   "spec.destination",
+		Message: fmt.Sprintf("destination %s is outside the allowed ranges (%s)", addr, strings.Join(ranges, ", ")),
+	}}
+}
+
+// validateUpstreamServerName validates the TLS server name sent to destinations
+func validateUpstreamServerName(name string) ValidationErrors {
+	var errors ValidationErrors
+
+	if len(name) > maxDomainLength || net.ParseIP(name) != nil || !dnsNameRegex.MatchString(name) {
+		errors = append(errors, ValidationError{
+			Field:   "spec.upstreamServerName",
+			Message: "upstreamServerName must be a valid DNS name",
+		})
+	}
+
+	return errors
+}
+
+// ProxyRuleWarnings returns problems with a valid rule that the data plane can
+// work around but that are probably mistakes. Profiles that are strict about a
+// problem report it from validation instead.
+func ProxyRuleWarnings(obj *unstructured.Unstructured, opts Options) []string {
+	spec, ok := obj.Object["spec"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	profile, _ := ruleProfile(spec)
+
+	var warnings []string
+	if !profile.requireUpstreamServerName && lacksUpstreamServerName(spec) {
+		warnings = append(warnings, missingUpstreamServerNameMessage)
+	}
+	if !opts.RequireTLSPort && lacksTLSPort(spec) {
+		warnings = append(warnings, missingTLSPortMessage(spec))
+	}
+	return warnings
+}
+
+// missingTLSPortMessage explains why TLS rules should name their backend port
+func missingTLSPortMessage(spec map[string]interface{}) string {
+	scheme, _ := spec["destinationScheme"].(string)
+	return fmt.Sprintf("tls is enabled but spec.port is not set; the destination port defaults to %d, set spec.port to the port the backend serves TLS on", DefaultDestinationPort(scheme))
+}
+
+// DefaultDestinationPort returns the destination port used for scheme when
+// spec.port is omitted
+func DefaultDestinationPort(scheme string) int {
+	if scheme == "https" {
+		return DefaultHTTPSPort
+	}
+	return DefaultHTTPPort
+}
+
+// lacksTLSPort reports whether a TLS rule leaves the backend port to the
+// default. Destinations cannot carry a port, so spec.port is the only place
+// one can be set.
+func lacksTLSPort(spec map[string]interface{}) bool {
+	if tls, _ := spec["tls"].(bool); !tls {
+		return false
+	}
+	_, found := spec["port"]
+	return !found
+}
+
+// missingUpstreamServerNameMessage explains why TLS rules with IP destinations need a server name
+const missingUpstreamServerNameMessage = "tls is enabled but all destinations are IP addresses; set spec.upstreamServerName so upstream TLS can send SNI and verify the certificate"
+
+// lacksUpstreamServerName reports whether a TLS rule only has IP destinations
+// and no spec.upstreamServerName, leaving the proxy without a name for SNI
+func lacksUpstreamServerName(spec map[string]interface{}) bool {
+	if tls, _ := spec["tls"].(bool); !tls {
+		return false
+	}
+	if name, _ := spec["upstreamServerName"].(string); name != "" {
+		return false
+	}
+
+	var destinations []string
+	if destination, _ := spec["destination"].(string); destination != "" {
+		destinations = append(destinations, destination)
+	}
+	destinations = append(destinations, stringSlice(spec["destinations"])...)
+	if len(destinations) == 0 {
+		return false
+	}
+	for _, destination := range destinations {
+		if net.ParseIP(destination) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validateMaxRequestBodyBytes validates a per-rule request body limit
+func validateMaxRequestBodyBytes(limit int64) ValidationErrors {
+	var errors ValidationErrors
+
+	if limit < 1 || limit > maxRuleRequestBodyBytes {
+		errors = append(errors, ValidationError{
+			Field:   "spec.maxRequestBodyBytes",
+			Message: fmt.Sprintf("maxRequestBodyBytes must be between 1 and %d (omit the field for no limit)", maxRuleRequestBodyBytes),
+		})
+	}
+
+	return errors
+}
+
+// validateHealthCheck validates spec.healthCheck: a path starting with /, and
+// optionally a positive interval and an HTTP status between 100 and 599
+func validateHealthCheck(value interface{}) ValidationErrors {
+	healthCheck, ok := value.(map[string]interface{})
+	if !ok {
+		return ValidationErrors{{
+			Field:   "spec.healthCheck",
+			Message: fmt.Sprintf("healthCheck must be an object, got %s", jsonTypeName(value)),
+		}}
+	}
+
+	var errors ValidationErrors
+
+	if pathVal, found := healthCheck["path"]; !found {
+		errors = append(errors, ValidationError{
+			Field:   "spec.healthCheck.path",
+			Message: "path is required",
+		})
+	} else if path, ok := pathVal.(string); !ok {
+		errors = append(errors, ValidationError{
+			Field:   "spec.healthCheck.path",
+			Message: "path must be a string",
+		})
+	} else if !strings.HasPrefix(path, "/") {
+		errors = append(errors, ValidationError{
+			Field:   "spec.healthCheck.path",
+			Message: fmt.Sprintf("path '%s' must start with /", path),
+		})
+	}
+
+	if intervalVal, found := healthCheck["intervalSeconds"]; found {
+		if interval, ok := integer(intervalVal); !ok || interval < 1 {
+			errors = append(errors, ValidationError{
+				Field:   "spec.healthCheck.intervalSeconds",
+				Message: "intervalSeconds must be a positive integer",
+			})
+		}
+	}
+
+	if statusVal, found := healthCheck["expectedStatus"]; found {
+		if status, ok := integer(statusVal); !ok || status < minHealthCheckStatus || status > maxHealthCheckStatus {
+			errors = append(errors, ValidationError{
+				Field:   "spec.healthCheck.expectedStatus",
+				Message: fmt.Sprintf("expectedStatus must be an HTTP status code between %d and %d", minHealthCheckStatus, maxHealthCheckStatus),
+			})
+		}
+	}
+
+	return errors
+}
+
+// destinationKey returns the form of a destination used to detect duplicates:
+// IP addresses in canonical notation, DNS names lowercased
+func destinationKey(destination string) string {
+	if ip := net.ParseIP(destination); ip != nil {
+		return ip.String()
+	}
+	return strings.ToLower(strings.TrimSuffix(destination, "."))
+}
+
+// isSupportedScheme reports whether scheme can be used to reach a destination
+func isSupportedScheme(scheme string) bool {
+	return scheme == "http" || scheme == "https"
+}
+
 // validatePort validates a port number
 func validatePort(port int) ValidationErrors {
 	var errors ValidationErrors
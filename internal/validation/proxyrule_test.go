@@ -1,11 +1,124 @@
 package validation
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+func TestValidationErrorJSONPointer(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "spec.port", want: "/spec/port"},
+		{field: "metadata.name", want: "/metadata/name"},
+		{field: "spec.destinations[0]", want: "/spec/destinations/0"},
+		{field: "spec.domains[12]", want: "/spec/domains/12"},
+		{field: "spec.healthCheck.expectedStatus", want: "/spec/healthCheck/expectedStatus"},
+		{field: "spec.annotations.mortar.io/validation-profile", want: "/spec/annotations/mortar.io~1validation-profile"},
+		{field: "spec.annotations.a~b", want: "/spec/annotations/a~0b"},
+		{field: "metadata.labels.app.kubernetes.io/env", want: "/metadata/labels/app.kubernetes.io~1env"},
+		{field: "metadata.annotations.example.com/owner", want: "/metadata/annotations/example.com~1owner"},
+		{field: "spec.destination/destinations", want: "/spec"},
+		{field: "spec", want: "/spec"},
+		{field: "domain", want: "/domain"},
+		{field: "body", want: ""},
+		{field: "Content-Type", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			e := &ValidationError{Field: tt.field, Message: "invalid"}
+			if got := e.JSONPointer(); got != tt.want {
+				t.Errorf("JSONPointer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ValidationErrors{{Field: "spec.destinations[1]", Message: "port must be < 65536"}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `[{"field":"spec.destinations[1]","pointer":"/spec/destinations/1","message":"port must be \u003c 65536"}]`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var decoded ValidationErrors
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded[0].Field != "spec.destinations[1]" || decoded[0].Message != "port must be < 65536" {
+		t.Errorf("expected the error to round-trip, got %+v", decoded[0])
+	}
+}
+
+func TestValidateMetadataLabelsAndAnnotations(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     []string
+	}{
+		{
+			name: "valid",
+			metadata: map[string]interface{}{
+				"labels":      map[string]interface{}{"team": "web", "app.kubernetes.io/env": "", "tier": "a.b_c-1"},
+				"annotations": map[string]interface{}{"example.com/notes": "anything goes, even spaces"},
+			},
+		},
+		{
+			name:     "labels not a map",
+			metadata: map[string]interface{}{"labels": []interface{}{"team=web"}},
+			want:     []string{"metadata.labels"},
+		},
+		{
+			name:     "bad label keys",
+			metadata: map[string]interface{}{"labels": map[string]interface{}{"-team": "web", "example.com/": "web", "Team": "web"}},
+			want:     []string{"metadata.labels.-team", "metadata.labels.example.com/", "metadata.labels.example.com/"},
+		},
+		{
+			name:     "bad label values",
+			metadata: map[string]interface{}{"labels": map[string]interface{}{"team": "web/api", "owner": strings.Repeat("a", 64), "count": 3.0}},
+			want:     []string{"metadata.labels.count", "metadata.labels.owner", "metadata.labels.team"},
+		},
+		{
+			name:     "bad annotation key",
+			metadata: map[string]interface{}{"annotations": map[string]interface{}{"notes about it": "x"}},
+			want:     []string{"metadata.annotations.notes about it"},
+		},
+		{
+			name:     "annotations too large",
+			metadata: map[string]interface{}{"annotations": map[string]interface{}{"example.com/blob": strings.Repeat("x", maxAnnotationsBytes)}},
+			want:     []string{"metadata.annotations"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := map[string]interface{}{"name": "test-rule"}
+			for key, value := range tt.metadata {
+				metadata[key] = value
+			}
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"metadata": metadata}}
+
+			var fields []string
+			for _, err := range validateMetadata(obj) {
+				fields = append(fields, err.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.want) {
+				t.Errorf("validateMetadata() errors on %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
+
 func TestValidateName(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -52,6 +165,31 @@ func TestValidateName(t *testing.T) {
 			inputName: string(make([]byte, 254)),
 			wantError: true,
 		},
+		{
+			name:      "reserved health-summary",
+			inputName: "health-summary",
+			wantError: true,
+		},
+		{
+			name:      "reserved import",
+			inputName: "import",
+			wantError: true,
+		},
+		{
+			name:      "reserved count",
+			inputName: "count",
+			wantError: true,
+		},
+		{
+			name:      "reserved export",
+			inputName: "export",
+			wantError: true,
+		},
+		{
+			name:      "reserved by-uid",
+			inputName: "by-uid",
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -123,6 +261,26 @@ func TestValidateDomain(t *testing.T) {
 			domain:    string(make([]byte, 254)) + ".com",
 			wantError: true,
 		},
+		{
+			name:      "label of 63 characters",
+			domain:    strings.Repeat("a", 63) + ".example.com",
+			wantError: false,
+		},
+		{
+			name:      "label too long",
+			domain:    strings.Repeat("a", 64) + ".example.com",
+			wantError: true,
+		},
+		{
+			name:      "inner label too long",
+			domain:    "api." + strings.Repeat("b", 64) + ".example.com",
+			wantError: true,
+		},
+		{
+			name:      "wildcard with label too long",
+			domain:    "*." + strings.Repeat("a", 64) + ".com",
+			wantError: true,
+		},
 		{
 			name:      "special characters",
 			domain:    "example_test.com",
@@ -152,10 +310,10 @@ func TestValidateDomain(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := validateDomain(tt.domain)
+			errors := ValidateDomain(tt.domain)
 			hasError := len(errors) > 0
 			if hasError != tt.wantError {
-				t.Errorf("validateDomain() error = %v, wantError %v", errors, tt.wantError)
+				t.Errorf("ValidateDomain() error = %v, wantError %v", errors, tt.wantError)
 			}
 		})
 	}
@@ -166,6 +324,7 @@ func TestValidateDestination(t *testing.T) {
 		name        string
 		destination string
 		wantError   bool
+		wantMessage string
 	}{
 		{
 			name:        "valid IPv4",
@@ -237,15 +396,116 @@ func TestValidateDestination(t *testing.T) {
 			destination: "::1",
 			wantError:   false,
 		},
+		{
+			name:        "valid IPv6 canonical form",
+			destination: "2001:db8::8a2e:370:7334",
+			wantError:   false,
+		},
+		{
+			name:        "valid IPv6 with embedded IPv4 suffix",
+			destination: "64:ff9b::10.0.0.50",
+			wantError:   false,
+		},
+		{
+			name:        "IPv6 with zone id",
+			destination: "fe80::1%eth0",
+			wantError:   true,
+			wantMessage: "zone id ('%eth0')",
+		},
+		{
+			name:        "IPv4-mapped IPv6",
+			destination: "::ffff:10.0.0.50",
+			wantError:   true,
+			wantMessage: "use 10.0.0.50 instead",
+		},
+		{
+			name:        "bracketed IPv6 with port",
+			destination: "[::1]:8080",
+			wantError:   true,
+			wantMessage: "must not be bracketed",
+		},
+		{
+			name:        "invalid IPv6 - too many groups",
+			destination: "2001:db8:0:0:0:0:0:0:1",
+			wantError:   true,
+			wantMessage: "appears to be an IPv6 address",
+		},
+		{
+			name:        "host with port",
+			destination: "backend.local:8080",
+			wantError:   true,
+			wantMessage: "must not include a port",
+		},
+		{
+			name:        "IPv4 with five octets is not a DNS name",
+			destination: "10.0.0.0.1",
+			wantError:   true,
+			wantMessage: "appears to be an IPv4 address",
+		},
+		{
+			name:        "IPv4 with three octets",
+			destination: "10.0.1",
+			wantError:   true,
+			wantMessage: "appears to be an IPv4 address",
+		},
+		{
+			name:        "IPv4 with leading zeros",
+			destination: "010.000.000.050",
+			wantError:   true,
+			wantMessage: "octet '010' has a leading zero",
+		},
+		{
+			name:        "IPv4 with leading zero in the last octet",
+			destination: "10.0.0.01",
+			wantError:   true,
+			wantMessage: "write 10.0.0.1 if the octets are decimal",
+		},
+		{
+			name:        "IPv4 with over-range octet",
+			destination: "10.0.0.256",
+			wantError:   true,
+			wantMessage: "octet '256' is out of range 0-255",
+		},
+		{
+			name:        "IPv4 with over-range octet and leading zero",
+			destination: "10.0300.0.1",
+			wantError:   true,
+			wantMessage: "octet '0300' is out of range 0-255",
+		},
+		{
+			name:        "IPv4 with huge octet",
+			destination: "10.0.0.99999999999999999999",
+			wantError:   true,
+			wantMessage: "is out of range 0-255",
+		},
+		{
+			name:        "IPv4 with trailing dot",
+			destination: "10.0.0.1.",
+			wantError:   true,
+			wantMessage: "ends with a dot",
+		},
+		{
+			name:        "single zero octets are not leading zeros",
+			destination: "10.0.0.0",
+			wantError:   false,
+		},
+		{
+			name:        "DNS name with numeric labels",
+			destination: "10.0.0.50.nip.io",
+			wantError:   false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := validateDestination(tt.destination)
+			errors := validateDestination(tt.destination, Options{})
 			hasError := len(errors) > 0
 			if hasError != tt.wantError {
 				t.Errorf("validateDestination(%s) error = %v, wantError %v", tt.destination, errors, tt.wantError)
 			}
+			if tt.wantMessage != "" && !strings.Contains(errors.Error(), tt.wantMessage) {
+				t.Errorf("validateDestination(%s) error = %v, want message containing %q", tt.destination, errors, tt.wantMessage)
+			}
 		})
 	}
 }
@@ -309,166 +569,779 @@ func TestValidatePort(t *testing.T) {
 	}
 }
 
-func TestValidateProxyRuleCreate(t *testing.T) {
+func TestValidateMaxRequestBodyBytes(t *testing.T) {
 	tests := []struct {
 		name      string
-		obj       *unstructured.Unstructured
+		value     interface{}
 		wantError bool
 	}{
 		{
-			name: "valid proxy rule",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain":      "example.com",
-						"destination": "10.0.0.50",
-						"port":        int64(3000),
-						"tls":         true,
-					},
-				},
-			},
+			name:      "valid limit",
+			value:     int64(10 * 1024 * 1024),
 			wantError: false,
 		},
 		{
-			name: "missing name",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{},
-					"spec": map[string]interface{}{
-						"domain":      "example.com",
-						"destination": "10.0.0.50",
-					},
-				},
-			},
-			wantError: true,
+			name:      "valid limit from JSON number",
+			value:     float64(1024),
+			wantError: false,
 		},
 		{
-			name: "missing domain",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"destination": "10.0.0.50",
-					},
-				},
-			},
-			wantError: true,
+			name:      "maximum limit",
+			value:     int64(maxRuleRequestBodyBytes),
+			wantError: false,
 		},
 		{
-			name: "missing destination",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain": "example.com",
-					},
-				},
-			},
+			name:      "zero rejected",
+			value:     int64(0),
 			wantError: true,
 		},
 		{
-			name: "invalid port",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain":      "example.com",
-						"destination": "10.0.0.50",
-						"port":        int64(70000),
-					},
-				},
-			},
+			name:      "negative limit",
+			value:     int64(-1),
 			wantError: true,
 		},
 		{
-			name: "invalid IP destination",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain":      "example.com",
-						"destination": "10.300.500.400",
-					},
-				},
-			},
+			name:      "over maximum",
+			value:     int64(maxRuleRequestBodyBytes + 1),
 			wantError: true,
 		},
 		{
-			name: "optional port not provided",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "test-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain":      "example.com",
-						"destination": "10.0.0.50",
-					},
-				},
-			},
-			wantError: false,
+			name:      "fractional number",
+			value:     float64(1.5),
+			wantError: true,
 		},
 		{
-			name: "valid wildcard domain",
-			obj: &unstructured.Unstructured{
-				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "wildcard-rule",
-					},
-					"spec": map[string]interface{}{
-						"domain":      "*.example.com",
-						"destination": "10.0.0.50",
-						"port":        int64(3000),
-					},
-				},
-			},
-			wantError: false,
+			name:      "string value",
+			value:     "10MB",
+			wantError: true,
 		},
-		{
-			name: "valid proxy rule with destinations array",
-			obj: &unstructured.Unstructured{
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
 				Object: map[string]interface{}{
-					"metadata": map[string]interface{}{
-						"name": "load-balanced-rule",
-					},
 					"spec": map[string]interface{}{
-						"domain":       "example.com",
-						"destinations": []interface{}{"10.0.0.50", "10.0.0.51", "backend.local"},
-						"port":         int64(3000),
+						"domain":              "example.com",
+						"destination":         "10.0.0.50",
+						"maxRequestBodyBytes": tt.value,
 					},
 				},
-			},
-			wantError: false,
-		},
-	}
+			}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			errors := ValidateProxyRuleCreate(tt.obj)
+			errors := ValidateProxyRuleUpdate(obj, Options{})
 			hasError := len(errors) > 0
 			if hasError != tt.wantError {
-				t.Errorf("ValidateProxyRuleCreate() error = %v, wantError %v", errors, tt.wantError)
+				t.Errorf("ValidateProxyRuleUpdate() with maxRequestBodyBytes %v error = %v, wantError %v", tt.value, errors, tt.wantError)
+			}
+			if hasError && errors[0].Field != "spec.maxRequestBodyBytes" {
+				t.Errorf("expected error on spec.maxRequestBodyBytes, got %s", errors[0].Field)
 			}
 		})
 	}
 }
 
-func TestValidateProxyRuleUpdate(t *testing.T) {
+func TestValidateDuplicateDestinations(t *testing.T) {
 	tests := []struct {
-		name      string
-		obj       *unstructured.Unstructured
-		wantError bool
+		name         string
+		destinations []interface{}
+		wantFields   []string
+	}{
+		{
+			name:         "distinct destinations",
+			destinations: []interface{}{"10.0.0.1", "10.0.0.2", "backend.local"},
+		},
+		{
+			name:         "duplicate ip",
+			destinations: []interface{}{"10.0.0.1", "10.0.0.2", "10.0.0.1"},
+			wantFields:   []string{"spec.destinations[2]"},
+		},
+		{
+			name:         "duplicate dns name differing in case",
+			destinations: []interface{}{"Backend.Local", "backend.local"},
+			wantFields:   []string{"spec.destinations[1]"},
+		},
+		{
+			name:         "duplicate ipv6 in different notation",
+			destinations: []interface{}{"2001:db8::1", "2001:0db8:0:0::1"},
+			wantFields:   []string{"spec.destinations[1]"},
+		},
+		{
+			name:         "every later duplicate flagged",
+			destinations: []interface{}{"10.0.0.1", "10.0.0.1", "10.0.0.1"},
+			wantFields:   []string{"spec.destinations[1]", "spec.destinations[2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"domain":       "example.com",
+						"destinations": tt.destinations,
+					},
+				},
+			}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			var fields []string
+			for _, e := range errors {
+				fields = append(fields, e.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errors)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationExclusive(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       map[string]interface{}
+		wantFields []string
+	}{
+		{
+			name: "destination only",
+			spec: map[string]interface{}{"destination": "10.0.0.1"},
+		},
+		{
+			name: "destinations only",
+			spec: map[string]interface{}{"destinations": []interface{}{"10.0.0.1", "10.0.0.2"}},
+		},
+		{
+			name:       "both set",
+			spec:       map[string]interface{}{"destination": "10.0.0.1", "destinations": []interface{}{"10.0.0.2"}},
+			wantFields: []string{"spec.destination/destinations"},
+		},
+		{
+			name: "empty destinations beside destination",
+			spec: map[string]interface{}{"destination": "10.0.0.1", "destinations": []interface{}{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec["domain"] = "example.com"
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			var fields []string
+			for _, e := range errors {
+				fields = append(fields, e.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errors)
+			}
+		})
+	}
+}
+
+func TestValidateHealthCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		healthCheck interface{}
+		noDest      bool
+		wantFields  []string
+	}{
+		{
+			name:        "full health check",
+			healthCheck: map[string]interface{}{"path": "/healthz", "intervalSeconds": float64(10), "expectedStatus": float64(204)},
+		},
+		{
+			name:        "path only",
+			healthCheck: map[string]interface{}{"path": "/"},
+		},
+		{
+			name:        "path without leading slash",
+			healthCheck: map[string]interface{}{"path": "healthz"},
+			wantFields:  []string{"spec.healthCheck.path"},
+		},
+		{
+			name:        "missing path",
+			healthCheck: map[string]interface{}{"intervalSeconds": float64(10)},
+			wantFields:  []string{"spec.healthCheck.path"},
+		},
+		{
+			name:        "zero interval",
+			healthCheck: map[string]interface{}{"path": "/healthz", "intervalSeconds": float64(0)},
+			wantFields:  []string{"spec.healthCheck.intervalSeconds"},
+		},
+		{
+			name:        "fractional interval",
+			healthCheck: map[string]interface{}{"path": "/healthz", "intervalSeconds": 1.5},
+			wantFields:  []string{"spec.healthCheck.intervalSeconds"},
+		},
+		{
+			name:        "status out of range",
+			healthCheck: map[string]interface{}{"path": "/healthz", "expectedStatus": float64(600)},
+			wantFields:  []string{"spec.healthCheck.expectedStatus"},
+		},
+		{
+			name:        "status not a number",
+			healthCheck: map[string]interface{}{"path": "/healthz", "expectedStatus": "200"},
+			wantFields:  []string{"spec.healthCheck.expectedStatus"},
+		},
+		{
+			name:        "every field invalid",
+			healthCheck: map[string]interface{}{"path": "healthz", "intervalSeconds": float64(-5), "expectedStatus": float64(99)},
+			wantFields:  []string{"spec.healthCheck.path", "spec.healthCheck.intervalSeconds", "spec.healthCheck.expectedStatus"},
+		},
+		{
+			name:        "not an object",
+			healthCheck: "/healthz",
+			wantFields:  []string{"spec.healthCheck"},
+		},
+		{
+			name:        "no destination",
+			healthCheck: map[string]interface{}{"path": "/healthz"},
+			noDest:      true,
+			wantFields:  []string{"spec.destination/destinations", "spec.healthCheck"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := map[string]interface{}{"domain": "example.com", "healthCheck": tt.healthCheck}
+			if !tt.noDest {
+				spec["destination"] = "10.0.0.1"
+			}
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			var fields []string
+			for _, e := range errors {
+				fields = append(fields, e.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errors)
+			}
+		})
+	}
+}
+
+func TestValidateSelfLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       map[string]interface{}
+		wantFields []string
+	}{
+		{
+			name: "destination is another host",
+			spec: map[string]interface{}{"domain": "app.example.com", "destination": "backend.example.com"},
+		},
+		{
+			name:       "destination is the domain",
+			spec:       map[string]interface{}{"domain": "app.example.com", "destination": "app.example.com"},
+			wantFields: []string{"spec.destination"},
+		},
+		{
+			name:       "destination differs only in case",
+			spec:       map[string]interface{}{"domain": "app.example.com", "destination": "App.Example.COM"},
+			wantFields: []string{"spec.destination"},
+		},
+		{
+			name:       "destination is one of several domains",
+			spec:       map[string]interface{}{"domains": []interface{}{"www.example.com", "app.example.com"}, "destination": "app.example.com"},
+			wantFields: []string{"spec.destination"},
+		},
+		{
+			name:       "destination matched by the rule's wildcard",
+			spec:       map[string]interface{}{"domain": "*.example.com", "destination": "backend.example.com"},
+			wantFields: []string{"spec.destination"},
+		},
+		{
+			name: "wildcard matches only one label",
+			spec: map[string]interface{}{"domain": "*.example.com", "destination": "backend.internal.example.com"},
+		},
+		{
+			name:       "one of several destinations loops",
+			spec:       map[string]interface{}{"domain": "app.example.com", "destinations": []interface{}{"10.0.0.1", "app.example.com"}},
+			wantFields: []string{"spec.destinations[1]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			var fields []string
+			for _, e := range errors {
+				fields = append(fields, e.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errors)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationsLimit(t *testing.T) {
+	// destinations returns n distinct IPv4 destinations, all invalid when invalid is set
+	destinations := func(n int, invalid bool) []interface{} {
+		result := make([]interface{}, n)
+		for i := range result {
+			if invalid {
+				result[i] = fmt.Sprintf("10.0.%d.256", i)
+			} else {
+				result[i] = fmt.Sprintf("10.0.%d.%d", i/250, i%250+1)
+			}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		opts         Options
+		destinations []interface{}
+		wantFields   []string
+	}{
+		{
+			name:         "at the limit",
+			destinations: destinations(DefaultMaxDestinations, false),
+		},
+		{
+			name:         "over the limit",
+			destinations: destinations(DefaultMaxDestinations+1, false),
+			wantFields:   []string{"spec.destinations"},
+		},
+		{
+			name:         "entries are not validated when over the limit",
+			destinations: destinations(10000, true),
+			wantFields:   []string{"spec.destinations"},
+		},
+		{
+			name:         "over a configured limit",
+			opts:         Options{MaxDestinations: 2},
+			destinations: destinations(3, false),
+			wantFields:   []string{"spec.destinations"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"domain":       "example.com",
+						"destinations": tt.destinations,
+					},
+				},
+			}
+
+			errors := ValidateProxyRuleUpdate(obj, tt.opts)
+			var fields []string
+			for _, e := range errors {
+				fields = append(fields, e.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errors)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationAllowedCIDRs(t *testing.T) {
+	opts := Options{AllowedDestinationCIDRs: []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}}
+
+	tests := []struct {
+		destination string
+		wantError   bool
+	}{
+		{destination: "10.1.2.3"},
+		{destination: "fd00::1"},
+		{destination: "backend.internal"},
+		{destination: "192.168.1.10", wantError: true},
+		{destination: "2001:db8::1", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			errors := validateDestination(tt.destination, opts)
+			if (len(errors) > 0) != tt.wantError {
+				t.Errorf("validateDestination(%q) errors = %v, wantError %v", tt.destination, errors, tt.wantError)
+			}
+			if tt.wantError && !strings.Contains(errors.Error(), "outside the allowed ranges (10.0.0.0/8, fd00::/8)") {
+				t.Errorf("expected the allowed ranges in the error, got %v", errors)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  interface{}
+		wantError bool
+	}{
+		{name: "roundrobin", strategy: "roundrobin"},
+		{name: "failover", strategy: "failover"},
+		{name: "unknown strategy", strategy: "random", wantError: true},
+		{name: "wrong case", strategy: "Failover", wantError: true},
+		{name: "wrong type", strategy: int64(1), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"domain":              "example.com",
+						"destinations":        []interface{}{"10.0.0.1", "10.0.0.2"},
+						"destinationStrategy": tt.strategy,
+					},
+				},
+			}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			hasError := len(errors) > 0
+			if hasError != tt.wantError {
+				t.Errorf("ValidateProxyRuleUpdate() with destinationStrategy %v error = %v, wantError %v", tt.strategy, errors, tt.wantError)
+			}
+			if hasError && errors[0].Field != "spec.destinationStrategy" {
+				t.Errorf("expected error on spec.destinationStrategy, got %s", errors[0].Field)
+			}
+		})
+	}
+}
+
+func TestValidateDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description interface{}
+		wantError   bool
+	}{
+		{name: "plain text", description: "Customer portal, owned by team web"},
+		{name: "at the limit", description: strings.Repeat("ä", maxDescriptionLength)},
+		{name: "empty", description: ""},
+		{name: "too long", description: strings.Repeat("a", maxDescriptionLength+1), wantError: true},
+		{name: "wrong type", description: int64(1), wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.0.0.1",
+						"description": tt.description,
+					},
+				},
+			}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			hasError := len(errors) > 0
+			if hasError != tt.wantError {
+				t.Errorf("ValidateProxyRuleUpdate() with description %v error = %v, wantError %v", tt.description, errors, tt.wantError)
+			}
+			if hasError && errors[0].Field != "spec.description" {
+				t.Errorf("expected error on spec.description, got %s", errors[0].Field)
+			}
+		})
+	}
+}
+
+func TestValidateDestinationScheme(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      map[string]interface{}
+		wantField string
+	}{
+		{
+			name: "https scheme",
+			spec: map[string]interface{}{"destination": "10.0.0.50", "destinationScheme": "https"},
+		},
+		{
+			name: "no scheme",
+			spec: map[string]interface{}{"destination": "10.0.0.50"},
+		},
+		{
+			name:      "unknown destinationScheme",
+			spec:      map[string]interface{}{"destination": "10.0.0.50", "destinationScheme": "ftp"},
+			wantField: "spec.destinationScheme",
+		},
+		{
+			name:      "unknown scheme prefix on destination",
+			spec:      map[string]interface{}{"destination": "ftp://backend.local"},
+			wantField: "spec.destination",
+		},
+		{
+			name:      "scheme prefix in destinations array",
+			spec:      map[string]interface{}{"destinations": []interface{}{"https://backend.local"}},
+			wantField: "spec.destinations[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec["domain"] = "example.com"
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			if tt.wantField == "" {
+				if len(errors) > 0 {
+					t.Errorf("unexpected errors: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Field != tt.wantField {
+				t.Errorf("expected one error on %s, got %v", tt.wantField, errors)
+			}
+		})
+	}
+}
+
+func TestValidateSpecType(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        interface{}
+		wantMessage string
+	}{
+		{name: "string", spec: "oops", wantMessage: "spec must be an object, got a string"},
+		{name: "number", spec: float64(42), wantMessage: "spec must be an object, got a number"},
+		{name: "array", spec: []interface{}{"example.com"}, wantMessage: "spec must be an object, got an array"},
+		{name: "null", spec: nil, wantMessage: "spec must be an object, got null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			if len(errors) != 1 || errors[0].Field != "spec" || errors[0].Message != tt.wantMessage {
+				t.Errorf("expected one error on spec saying %q, got %v", tt.wantMessage, errors)
+			}
+		})
+	}
+}
+
+func TestValidateProxyRuleCreate(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		wantError bool
+	}{
+		{
+			name: "valid proxy rule",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.0.0.50",
+						"port":        int64(3000),
+						"tls":         true,
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "missing name",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "missing domain",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "missing destination",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain": "example.com",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid port",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.0.0.50",
+						"port":        int64(70000),
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid IP destination",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.300.500.400",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "optional port not provided",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "test-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "valid wildcard domain",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "wildcard-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "*.example.com",
+						"destination": "10.0.0.50",
+						"port":        int64(3000),
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "valid proxy rule with destinations array",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "load-balanced-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":       "example.com",
+						"destinations": []interface{}{"10.0.0.50", "10.0.0.51", "backend.local"},
+						"port":         int64(3000),
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "valid proxy rule with domains array",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "multi-domain-rule",
+					},
+					"spec": map[string]interface{}{
+						"domains":     []interface{}{"example.com", "www.example.com"},
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "valid proxy rule with domain and domains",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "multi-domain-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":      "example.com",
+						"domains":     []interface{}{"www.example.com"},
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid entry in domains array",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "multi-domain-rule",
+					},
+					"spec": map[string]interface{}{
+						"domains":     []interface{}{"example.com", "invalid..com"},
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "empty domains array without domain",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "multi-domain-rule",
+					},
+					"spec": map[string]interface{}{
+						"domains":     []interface{}{},
+						"destination": "10.0.0.50",
+					},
+				},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := ValidateProxyRuleCreate(tt.obj, Options{})
+			hasError := len(errors) > 0
+			if hasError != tt.wantError {
+				t.Errorf("ValidateProxyRuleCreate() error = %v, wantError %v", errors, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestValidateProxyRuleUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		wantError bool
 	}{
 		{
 			name: "valid update",
@@ -501,11 +1374,72 @@ func TestValidateProxyRuleUpdate(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name: "update removes destination and destinations",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "existing-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain": "updated.example.com",
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "update sets empty destinations without destination",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "existing-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":       "updated.example.com",
+						"destinations": []interface{}{},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "update clears destination and empties destinations",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "existing-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":       "updated.example.com",
+						"destination":  "",
+						"destinations": []interface{}{},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "update sets empty destinations but keeps destination",
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "existing-rule",
+					},
+					"spec": map[string]interface{}{
+						"domain":       "updated.example.com",
+						"destination":  "10.0.0.60",
+						"destinations": []interface{}{},
+					},
+				},
+			},
+			wantError: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			errors := ValidateProxyRuleUpdate(tt.obj)
+			errors := ValidateProxyRuleUpdate(tt.obj, Options{})
 			hasError := len(errors) > 0
 			if hasError != tt.wantError {
 				t.Errorf("ValidateProxyRuleUpdate() error = %v, wantError %v", errors, tt.wantError)
@@ -513,3 +1447,258 @@ func TestValidateProxyRuleUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   string
+		spec      map[string]interface{}
+		wantField string
+	}{
+		{
+			name:    "public rule with tls",
+			profile: "public",
+			spec:    map[string]interface{}{"domain": "example.com", "destination": "backend.internal", "tls": true},
+		},
+		{
+			name:    "public rule with tls to an IP and a server name",
+			profile: "public",
+			spec:    map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50", "tls": true, "upstreamServerName": "backend.example.com"},
+		},
+		{
+			name:      "public rule with tls to an IP without a server name",
+			profile:   "public",
+			spec:      map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50", "tls": true},
+			wantField: "spec.upstreamServerName",
+		},
+		{
+			name:      "public rule missing tls",
+			profile:   "public",
+			spec:      map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50"},
+			wantField: "spec.tls",
+		},
+		{
+			name:      "public rule with tls disabled",
+			profile:   "public",
+			spec:      map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50", "tls": false},
+			wantField: "spec.tls",
+		},
+		{
+			name:      "public rule with single-label domain",
+			profile:   "public",
+			spec:      map[string]interface{}{"domain": "intranet", "destination": "backend.internal", "tls": true},
+			wantField: "spec.domain",
+		},
+		{
+			name:    "internal rule with underscore destination",
+			profile: "internal",
+			spec:    map[string]interface{}{"domain": "intranet", "destination": "legacy_host.corp.local"},
+		},
+		{
+			name:      "internal rule still rejects invalid IPv4",
+			profile:   "internal",
+			spec:      map[string]interface{}{"domain": "intranet", "destinations": []interface{}{"10.0.0.256"}},
+			wantField: "spec.destinations[0]",
+		},
+		{
+			name:      "default profile rejects underscore destination",
+			spec:      map[string]interface{}{"domain": "intranet", "destination": "legacy_host.corp.local"},
+			wantField: "spec.destination",
+		},
+		{
+			name:      "unknown profile",
+			profile:   "strict",
+			spec:      map[string]interface{}{"domain": "example.com", "destination": "10.0.0.50"},
+			wantField: "spec.annotations.mortar.io/validation-profile",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.profile != "" {
+				tt.spec["annotations"] = map[string]interface{}{ValidationProfileAnnotation: tt.profile}
+			}
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			if tt.wantField == "" {
+				if len(errors) > 0 {
+					t.Errorf("unexpected errors: %v", errors)
+				}
+				return
+			}
+			if len(errors) != 1 || errors[0].Field != tt.wantField {
+				t.Errorf("expected one error on %s, got %v", tt.wantField, errors)
+			}
+		})
+	}
+}
+
+func TestValidateUpstreamServerName(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverName  interface{}
+		expectError bool
+	}{
+		{name: "dns name", serverName: "backend.example.com"},
+		{name: "single label", serverName: "backend"},
+		{name: "ip address", serverName: "10.0.0.50", expectError: true},
+		{name: "wildcard", serverName: "*.example.com", expectError: true},
+		{name: "invalid characters", serverName: "back_end.example.com", expectError: true},
+		{name: "empty", serverName: "", expectError: true},
+		{name: "not a string", serverName: int64(42), expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"domain":             "example.com",
+					"destination":        "10.0.0.50",
+					"upstreamServerName": tt.serverName,
+				},
+			}}
+
+			errors := ValidateProxyRuleUpdate(obj, Options{})
+			if tt.expectError && (len(errors) != 1 || errors[0].Field != "spec.upstreamServerName") {
+				t.Errorf("expected one error on spec.upstreamServerName, got %v", errors)
+			}
+			if !tt.expectError && len(errors) > 0 {
+				t.Errorf("unexpected errors: %v", errors)
+			}
+		})
+	}
+}
+
+func TestProxyRuleWarnings(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        map[string]interface{}
+		wantWarning bool
+	}{
+		{
+			name:        "tls to an IP without a server name",
+			spec:        map[string]interface{}{"destination": "10.0.0.50", "tls": true, "port": int64(8443)},
+			wantWarning: true,
+		},
+		{
+			name:        "tls to IPv4 and IPv6 destinations without a server name",
+			spec:        map[string]interface{}{"destinations": []interface{}{"10.0.0.50", "fd00::1"}, "tls": true, "port": int64(8443)},
+			wantWarning: true,
+		},
+		{
+			name: "tls to an IP with a server name",
+			spec: map[string]interface{}{"destination": "10.0.0.50", "tls": true, "port": int64(8443), "upstreamServerName": "backend.example.com"},
+		},
+		{
+			name: "tls with a DNS destination among IPs",
+			spec: map[string]interface{}{"destinations": []interface{}{"10.0.0.50", "backend.internal"}, "tls": true, "port": int64(8443)},
+		},
+		{
+			name: "no tls",
+			spec: map[string]interface{}{"destination": "10.0.0.50"},
+		},
+		{
+			name: "public profile reports it as an error instead",
+			spec: map[string]interface{}{
+				"destination": "10.0.0.50",
+				"tls":         true,
+				"port":        int64(8443),
+				"annotations": map[string]interface{}{ValidationProfileAnnotation: ValidationProfilePublic},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec["domain"] = "example.com"
+			warnings := ProxyRuleWarnings(&unstructured.Unstructured{Object: map[string]interface{}{"spec": tt.spec}}, Options{})
+			if tt.wantWarning && (len(warnings) != 1 || !strings.Contains(warnings[0], "upstreamServerName")) {
+				t.Errorf("expected an upstreamServerName warning, got %v", warnings)
+			}
+			if !tt.wantWarning && len(warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+		})
+	}
+}
+
+func TestTLSWithoutPort(t *testing.T) {
+	tests := []struct {
+		name string
+		spec map[string]interface{}
+		want bool
+	}{
+		{name: "tls without port", spec: map[string]interface{}{"destination": "backend.internal", "tls": true}, want: true},
+		{name: "tls with port", spec: map[string]interface{}{"destination": "backend.internal", "tls": true, "port": int64(8443)}},
+		{name: "no tls without port", spec: map[string]interface{}{"destination": "backend.internal"}},
+		{name: "tls false without port", spec: map[string]interface{}{"destination": "backend.internal", "tls": false}},
+	}
+
+	for _, strict := range []bool{false, true} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/strict=%v", tt.name, strict), func(t *testing.T) {
+				opts := Options{RequireTLSPort: strict}
+
+				spec := map[string]interface{}{"domain": "example.com"}
+				for key, value := range tt.spec {
+					spec[key] = value
+				}
+				obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+
+				var portErrors ValidationErrors
+				for _, e := range validateSpec(obj, opts) {
+					if e.Field == "spec.port" {
+						portErrors = append(portErrors, e)
+					}
+				}
+				warnings := ProxyRuleWarnings(obj, opts)
+
+				switch {
+				case !tt.want:
+					if len(portErrors) > 0 || len(warnings) > 0 {
+						t.Errorf("expected neither errors nor warnings, got %v and %v", portErrors, warnings)
+					}
+				case strict:
+					if len(portErrors) != 1 || !strings.Contains(portErrors[0].Message, "spec.port is not set") {
+						t.Errorf("expected a spec.port error, got %v", portErrors)
+					}
+					if len(warnings) > 0 {
+						t.Errorf("expected the error to replace the warning, got %v", warnings)
+					}
+				default:
+					if len(portErrors) > 0 {
+						t.Errorf("expected no error when not strict, got %v", portErrors)
+					}
+					if len(warnings) != 1 || !strings.Contains(warnings[0], "spec.port is not set") {
+						t.Errorf("expected a spec.port warning, got %v", warnings)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMissingTLSPortMessageNamesDefaultPort(t *testing.T) {
+	tests := []struct {
+		scheme string
+		want   string
+	}{
+		{scheme: "", want: "defaults to 80,"},
+		{scheme: "http", want: "defaults to 80,"},
+		{scheme: "https", want: "defaults to 443,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			spec := map[string]interface{}{"domain": "example.com", "destination": "backend.internal", "tls": true}
+			if tt.scheme != "" {
+				spec["destinationScheme"] = tt.scheme
+			}
+			warnings := ProxyRuleWarnings(&unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}, Options{})
+			if len(warnings) != 1 || !strings.Contains(warnings[0], tt.want) {
+				t.Errorf("expected a warning containing %q, got %v", tt.want, warnings)
+			}
+		})
+	}
+}
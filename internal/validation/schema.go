@@ -0,0 +1,159 @@
+package validation
+
+// ProxyRuleSchema returns an OpenAPI 3 schema of a ProxyRule as accepted by
+// ValidateProxyRuleCreate. It is built from the same constants the validators
+// use so that the published contract cannot drift from the enforced one.
+// Domains and destinations are lowercased before validation, so the schema
+// does not restrict their case.
+func ProxyRuleSchema(opts Options) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"metadata", "spec"},
+		"properties": map[string]interface{}{
+			"apiVersion": map[string]interface{}{"type": "string", "enum": []string{"bausteln.io/v1"}},
+			"kind":       map[string]interface{}{"type": "string", "enum": []string{"Proxyrule"}},
+			"metadata": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"name"},
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":      "string",
+						"maxLength": maxNameLength,
+						"pattern":   k8sNameRegex.String(),
+					},
+					"namespace":       map[string]interface{}{"type": "string"},
+					"uid":             map[string]interface{}{"type": "string", "readOnly": true},
+					"resourceVersion": map[string]interface{}{"type": "string"},
+					"labels":          stringMapSchema(),
+					"annotations":     stringMapSchema(),
+				},
+			},
+			"spec": ProxyRuleSpecSchema(opts),
+		},
+	}
+}
+
+// ProxyRuleSpecSchema returns an OpenAPI 3 schema of a ProxyRule spec
+func ProxyRuleSpecSchema(opts Options) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"allOf": []interface{}{
+			map[string]interface{}{"anyOf": []interface{}{
+				map[string]interface{}{"required": []string{"domain"}},
+				map[string]interface{}{"required": []string{"domains"}},
+			}},
+			map[string]interface{}{"anyOf": []interface{}{
+				map[string]interface{}{"required": []string{"destination"}},
+				map[string]interface{}{"required": []string{"destinations"}},
+			}},
+		},
+		"properties": map[string]interface{}{
+			"domain": domainSchema(),
+			"domains": map[string]interface{}{
+				"type":  "array",
+				"items": domainSchema(),
+			},
+			"destination": destinationSchema("May be prefixed with http:// or https://, which sets destinationScheme."),
+			"destinations": map[string]interface{}{
+				"type":     "array",
+				"maxItems": opts.maxDestinations(),
+				"items":    destinationSchema("Entries must be distinct and carry no scheme."),
+			},
+			"destinationScheme": map[string]interface{}{
+				"type":    "string",
+				"enum":    []string{"http", "https"},
+				"default": DefaultDestinationScheme,
+			},
+			"destinationStrategy": map[string]interface{}{
+				"type":    "string",
+				"enum":    []string{DestinationStrategyRoundRobin, DestinationStrategyFailover},
+				"default": DestinationStrategyRoundRobin,
+			},
+			"port": map[string]interface{}{
+				"type":    "integer",
+				"minimum": minPort,
+				"maximum": maxPort,
+			},
+			"tls": map[string]interface{}{"type": "boolean"},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"maxLength":   maxDescriptionLength,
+				"description": "Free-text note on why the rule exists, for display in the UI.",
+			},
+			"enabled": map[string]interface{}{
+				"type":        "boolean",
+				"default":     true,
+				"description": "Set to false to keep the rule without routing traffic; it still reserves its domains.",
+			},
+			"upstreamServerName": map[string]interface{}{
+				"type":        "string",
+				"format":      "hostname",
+				"maxLength":   maxDomainLength,
+				"description": "TLS server name (SNI) sent to the destinations; recommended when tls is true and all destinations are IP addresses.",
+			},
+			"maxRequestBodyBytes": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     maxRuleRequestBodyBytes,
+				"description": "Per-rule request body limit enforced by the proxy; omit for no limit.",
+			},
+			"healthCheck": map[string]interface{}{
+				"type":        "object",
+				"required":    []string{"path"},
+				"description": "How the proxy checks the health of the destinations; omit to leave it to the proxy.",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":    "string",
+						"pattern": "^/",
+					},
+					"intervalSeconds": map[string]interface{}{
+						"type":    "integer",
+						"minimum": 1,
+					},
+					"expectedStatus": map[string]interface{}{
+						"type":    "integer",
+						"minimum": minHealthCheckStatus,
+						"maximum": maxHealthCheckStatus,
+					},
+				},
+			},
+			"annotations": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+				"properties": map[string]interface{}{
+					ValidationProfileAnnotation: map[string]interface{}{
+						"type": "string",
+						"enum": []string{ValidationProfileDefault, ValidationProfilePublic, ValidationProfileInternal},
+					},
+				},
+			},
+		},
+	}
+}
+
+// domainSchema describes a domain, optionally with a leading wildcard label
+func domainSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"minLength":   1,
+		"maxLength":   maxDomainLength,
+		"description": "DNS name, optionally prefixed with '*.' for a wildcard; a single trailing dot is stripped.",
+	}
+}
+
+// destinationSchema describes a destination host with extra notes
+func destinationSchema(note string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"minLength":   1,
+		"description": "IP address or DNS name of the backend. " + note,
+	}
+}
+
+// stringMapSchema describes a map of string values
+func stringMapSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": map[string]interface{}{"type": "string"},
+	}
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ParseAndValidateSpec validates a raw ProxyRule spec and decodes it into a
+// ProxyRuleSpec. The checks are the same as for a full rule; fields with the
+// wrong type are reported as errors and left unset: the zero value, or true for Enabled.
+func ParseAndValidateSpec(raw map[string]interface{}, opts Options) (ProxyRuleSpec, ValidationErrors) {
+	var spec ProxyRuleSpec
+	if raw == nil {
+		return spec, ValidationErrors{{Field: "spec", Message: "spec is required"}}
+	}
+
+	errors := validateSpec(&unstructured.Unstructured{Object: map[string]interface{}{"spec": raw}}, opts)
+
+	spec.Domain, _ = raw["domain"].(string)
+	spec.Domains = stringSlice(raw["domains"])
+	spec.Destination, _ = raw["destination"].(string)
+	spec.Destinations = stringSlice(raw["destinations"])
+	spec.DestinationScheme, _ = raw["destinationScheme"].(string)
+	spec.DestinationStrategy, _ = raw["destinationStrategy"].(string)
+	spec.TLS, _ = raw["tls"].(bool)
+	spec.UpstreamServerName, _ = raw["upstreamServerName"].(string)
+	spec.Description, _ = raw["description"].(string)
+	spec.Enabled = true
+	if enabled, ok := raw["enabled"].(bool); ok {
+		spec.Enabled = enabled
+	}
+
+	if port, ok := integer(raw["port"]); ok {
+		spec.Port = int(port)
+	}
+	if limit, ok := integer(raw["maxRequestBodyBytes"]); ok {
+		spec.MaxRequestBodyBytes = limit
+	}
+
+	if healthCheck, ok := raw["healthCheck"].(map[string]interface{}); ok {
+		spec.HealthCheck = &HealthCheck{}
+		spec.HealthCheck.Path, _ = healthCheck["path"].(string)
+		if interval, ok := integer(healthCheck["intervalSeconds"]); ok {
+			spec.HealthCheck.IntervalSeconds = int(interval)
+		}
+		if status, ok := integer(healthCheck["expectedStatus"]); ok {
+			spec.HealthCheck.ExpectedStatus = int(status)
+		}
+	}
+
+	if annotations, ok := raw["annotations"].(map[string]interface{}); ok {
+		spec.Annotations = make(map[string]string, len(annotations))
+		for key, value := range annotations {
+			if s, ok := value.(string); ok {
+				spec.Annotations[key] = s
+			}
+		}
+	}
+
+	return spec, errors
+}
+
+// stringSlice returns the string elements of a JSON array, or nil if value is not one
+func stringSlice(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// integer returns a JSON number as int64, accepting whole float64 values as decoded by encoding/json
+func integer(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
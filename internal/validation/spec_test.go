@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseAndValidateSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		want       ProxyRuleSpec
+		wantFields []string
+	}{
+		{
+			name: "full spec",
+			raw: `{
+				"domain": "example.com",
+				"domains": ["www.example.com"],
+				"destinations": ["10.0.0.1", "10.0.0.2"],
+				"destinationScheme": "https",
+				"destinationStrategy": "failover",
+				"port": 8443,
+				"tls": true,
+				"enabled": false,
+				"description": "  Customer portal  ",
+				"maxRequestBodyBytes": 1048576,
+				"healthCheck": {"path": "/healthz", "intervalSeconds": 10, "expectedStatus": 204},
+				"annotations": {"team": "web"}
+			}`,
+			want: ProxyRuleSpec{
+				Domain:              "example.com",
+				Domains:             []string{"www.example.com"},
+				Destinations:        []string{"10.0.0.1", "10.0.0.2"},
+				DestinationScheme:   "https",
+				DestinationStrategy: "failover",
+				Port:                8443,
+				TLS:                 true,
+				MaxRequestBodyBytes: 1048576,
+				HealthCheck:         &HealthCheck{Path: "/healthz", IntervalSeconds: 10, ExpectedStatus: 204},
+				Annotations:         map[string]string{"team": "web"},
+				Description:         "  Customer portal  ",
+			},
+		},
+		{
+			name: "wrong types",
+			raw:  `{"domain": "example.com", "destination": 42, "port": "80", "tls": "yes", "enabled": "no"}`,
+			want: ProxyRuleSpec{Domain: "example.com", Enabled: true},
+			wantFields: []string{
+				"spec.destination/destinations",
+				"spec.destination",
+				"spec.port",
+				"spec.tls",
+				"spec.enabled",
+			},
+		},
+		{
+			name:       "invalid values are still decoded",
+			raw:        `{"domain": "example.com", "destination": "10.0.0.1", "port": 70000}`,
+			want:       ProxyRuleSpec{Domain: "example.com", Destination: "10.0.0.1", Port: 70000, Enabled: true},
+			wantFields: []string{"spec.port"},
+		},
+		{
+			name:       "missing spec",
+			raw:        `null`,
+			wantFields: []string{"spec"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var raw map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.raw), &raw); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+
+			got, errs := ParseAndValidateSpec(raw, Options{})
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAndValidateSpec() = %+v, want %+v", got, tt.want)
+			}
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("expected errors on %v, got %v", tt.wantFields, errs)
+			}
+		})
+	}
+}
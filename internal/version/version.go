@@ -0,0 +1,32 @@
+package version
+
+import "runtime"
+
+// Build information, injected at build time with -ldflags, e.g.
+// -X gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/version.Version=v1.2.3
+var (
+	// Version is the release version of the backend
+	Version = "dev"
+	// Commit is the git commit the backend was built from
+	Commit = "unknown"
+	// BuildDate is the time of the build, in RFC 3339
+	BuildDate = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
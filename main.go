@@ -1,20 +1,54 @@
 package main
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/audit"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/config"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/handlers"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/k8s"
 	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/server"
 )
 
 func main() {
+	// Load configuration from environment
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("Error loading configuration", err)
+	}
+
+	// Log through slog at the configured level; the standard logger logs at info
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.LogLevel})))
+
 	// Create Kubernetes dynamic client
-	dynamicClient, err := k8s.NewDynamicClient()
+	dynamicClient, k8sConfigSource, err := k8s.NewDynamicClient()
 	if err != nil {
-		log.Fatalf("Error creating Kubernetes client: %v", err)
+		fatal("Error creating Kubernetes client", err)
+	}
+
+	// Log the settings that are actually live, once, so a misbehaving deployment
+	// can be checked against what was intended
+	slog.Info("Effective configuration", "config", cfg, "namespace", handlers.DefaultNamespace, "kubernetesConfig", k8sConfigSource)
+
+	// Record metrics for every Kubernetes API call, and retry transient failures
+	// (each attempt is recorded separately)
+	instrumentedClient := k8s.NewInstrumentedDynamicClient(dynamicClient)
+	retryingClient := k8s.NewRetryingDynamicClient(instrumentedClient, cfg.K8sRetryMaxAttempts, cfg.K8sRetryBaseDelay)
+
+	// Open the audit log sink for rule mutations
+	auditLogger, err := audit.Open(cfg.AuditLog)
+	if err != nil {
+		fatal("Error opening audit log", err)
 	}
 
 	// Create and start server
-	srv := server.New("8080", dynamicClient)
+	srv := server.NewWithConfig(cfg, retryingClient, handlers.WithAuditLogger(auditLogger))
 	srv.Run()
 }
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,186 @@
+// Package client is a Go client for the mortar backend's proxy rule API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyRule is a proxy rule as returned by the API
+type ProxyRule struct {
+	APIVersion string        `json:"apiVersion,omitempty"`
+	Kind       string        `json:"kind,omitempty"`
+	Metadata   ObjectMeta    `json:"metadata"`
+	Spec       ProxyRuleSpec `json:"spec"`
+}
+
+// ObjectMeta is the subset of Kubernetes object metadata the API exposes
+type ObjectMeta struct {
+	Name            string            `json:"name"`
+	Namespace       string            `json:"namespace,omitempty"`
+	UID             string            `json:"uid,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+}
+
+// ProxyRuleSpec is the desired routing of a proxy rule. Fields left empty are
+// omitted, so the server applies its defaults; TLS and Enabled are pointers
+// because both default to true.
+type ProxyRuleSpec struct {
+	Domain              string            `json:"domain,omitempty"`
+	Domains             []string          `json:"domains,omitempty"`
+	Destination         string            `json:"destination,omitempty"`
+	Destinations        []string          `json:"destinations,omitempty"`
+	DestinationScheme   string            `json:"destinationScheme,omitempty"`
+	DestinationStrategy string            `json:"destinationStrategy,omitempty"`
+	Port                int               `json:"port,omitempty"`
+	TLS                 *bool             `json:"tls,omitempty"`
+	Enabled             *bool             `json:"enabled,omitempty"`
+	UpstreamServerName  string            `json:"upstreamServerName,omitempty"`
+	MaxRequestBodyBytes int64             `json:"maxRequestBodyBytes,omitempty"`
+	Description         string            `json:"description,omitempty"`
+	Annotations         map[string]string `json:"annotations,omitempty"`
+}
+
+// Client calls the proxy rule API of a mortar backend
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// Option configures optional behaviour of a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests through httpClient instead of http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBearerToken authenticates every request with token, for backends that
+// require JWT authentication
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// New returns a client for the backend at baseURL, e.g. "http://mortar-backend:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		httpClient: http.DefaultClient,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ListProxyRules returns all proxy rules, sorted by name
+func (c *Client) ListProxyRules(ctx context.Context) ([]ProxyRule, error) {
+	var list struct {
+		Items []ProxyRule `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// GetProxyRule returns the proxy rule called name
+func (c *Client) GetProxyRule(ctx context.Context, name string) (*ProxyRule, error) {
+	var rule ProxyRule
+	if err := c.do(ctx, http.MethodGet, name, nil, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// CreateProxyRule creates a proxy rule called name and returns it as stored
+func (c *Client) CreateProxyRule(ctx context.Context, name string, spec ProxyRuleSpec) (*ProxyRule, error) {
+	body := ProxyRule{
+		APIVersion: "bausteln.io/v1",
+		Kind:       "Proxyrule",
+		Metadata:   ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+	var rule ProxyRule
+	if err := c.do(ctx, http.MethodPost, "", body, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateProxyRule replaces the spec of the proxy rule called name and returns it as stored
+func (c *Client) UpdateProxyRule(ctx context.Context, name string, spec ProxyRuleSpec) (*ProxyRule, error) {
+	body := struct {
+		Spec ProxyRuleSpec `json:"spec"`
+	}{spec}
+	var rule ProxyRule
+	if err := c.do(ctx, http.MethodPut, name, body, &rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteProxyRule deletes the proxy rule called name
+func (c *Client) DeleteProxyRule(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, name, nil, nil)
+}
+
+// do sends a request for the rule called name (the collection if empty),
+// encoding body as JSON if set and decoding a successful response into out
+func (c *Client) do(ctx context.Context, method, name string, body, out interface{}) error {
+	target := c.baseURL + "/api/proxyrules"
+	if name != "" {
+		target += "/" + url.PathEscape(name)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("error encoding request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return newResponseError(resp, name)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
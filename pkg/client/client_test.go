@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/config"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/server"
+	"gitlab.bausteln.ch/net-core/reverse-proxy/mortar-backend/internal/testutil"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	fakeClient := testutil.NewFakeDynamicClient()
+	fakeClient.SeedProxyRule("existing-rule", "proxy-rules", "existing.example.com", "10.0.0.50", 3000)
+
+	backend := httptest.NewServer(server.NewWithConfig(config.Default(), fakeClient).Handler())
+	t.Cleanup(backend.Close)
+	return New(backend.URL, WithHTTPClient(backend.Client()))
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	disabled := false
+
+	tests := []struct {
+		name    string
+		call    func(c *Client) (interface{}, error)
+		want    interface{}
+		wantErr interface{}
+	}{
+		{
+			name: "list",
+			call: func(c *Client) (interface{}, error) {
+				rules, err := c.ListProxyRules(ctx)
+				var names []string
+				for _, rule := range rules {
+					names = append(names, rule.Metadata.Name)
+				}
+				return names, err
+			},
+			want: []string{"existing-rule"},
+		},
+		{
+			name: "get",
+			call: func(c *Client) (interface{}, error) {
+				rule, err := c.GetProxyRule(ctx, "existing-rule")
+				if err != nil {
+					return nil, err
+				}
+				return rule.Spec.Domain, nil
+			},
+			want: "existing.example.com",
+		},
+		{
+			name: "get missing",
+			call: func(c *Client) (interface{}, error) {
+				return c.GetProxyRule(ctx, "missing-rule")
+			},
+			wantErr: &NotFoundError{},
+		},
+		{
+			name: "create",
+			call: func(c *Client) (interface{}, error) {
+				rule, err := c.CreateProxyRule(ctx, "new-rule", ProxyRuleSpec{Domain: "new.example.com", Destination: "10.0.0.51", Port: 8080, TLS: &disabled})
+				if err != nil {
+					return nil, err
+				}
+				return []interface{}{rule.Metadata.Name, rule.Spec.Port, *rule.Spec.TLS}, nil
+			},
+			want: []interface{}{"new-rule", 8080, false},
+		},
+		{
+			name: "create duplicate name",
+			call: func(c *Client) (interface{}, error) {
+				return c.CreateProxyRule(ctx, "existing-rule", ProxyRuleSpec{Domain: "other.example.com", Destination: "10.0.0.51"})
+			},
+			wantErr: &ConflictError{},
+		},
+		{
+			name: "create duplicate domain",
+			call: func(c *Client) (interface{}, error) {
+				return c.CreateProxyRule(ctx, "new-rule", ProxyRuleSpec{Domain: "existing.example.com", Destination: "10.0.0.51"})
+			},
+			wantErr: &ConflictError{},
+		},
+		{
+			name: "create invalid",
+			call: func(c *Client) (interface{}, error) {
+				return c.CreateProxyRule(ctx, "new-rule", ProxyRuleSpec{Domain: "new.example.com", Destination: "10.0.0.51", Port: 70000})
+			},
+			wantErr: &ValidationError{},
+		},
+		{
+			name: "update",
+			call: func(c *Client) (interface{}, error) {
+				rule, err := c.UpdateProxyRule(ctx, "existing-rule", ProxyRuleSpec{Domain: "existing.example.com", Destination: "10.0.0.52"})
+				if err != nil {
+					return nil, err
+				}
+				return rule.Spec.Destination, nil
+			},
+			want: "10.0.0.52",
+		},
+		{
+			name: "update invalid",
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateProxyRule(ctx, "existing-rule", ProxyRuleSpec{Domain: "existing.example.com"})
+			},
+			wantErr: &ValidationError{},
+		},
+		{
+			name: "update missing",
+			call: func(c *Client) (interface{}, error) {
+				return c.UpdateProxyRule(ctx, "missing-rule", ProxyRuleSpec{Domain: "missing.example.com", Destination: "10.0.0.52"})
+			},
+			wantErr: &NotFoundError{},
+		},
+		{
+			name: "delete",
+			call: func(c *Client) (interface{}, error) {
+				if err := c.DeleteProxyRule(ctx, "existing-rule"); err != nil {
+					return nil, err
+				}
+				_, err := c.GetProxyRule(ctx, "existing-rule")
+				var notFound *NotFoundError
+				return errors.As(err, &notFound), nil
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call(newTestClient(t))
+
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatalf("expected a %T, got no error", tt.wantErr)
+				}
+				if reflect.TypeOf(err) != reflect.TypeOf(tt.wantErr) {
+					t.Fatalf("expected a %T, got %T: %v", tt.wantErr, err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClientErrorDetails(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.CreateProxyRule(context.Background(), "new-rule", ProxyRuleSpec{Domain: "existing.example.com", Destination: "10.0.0.51"})
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	if conflict.Reason != "DuplicateDomain" || conflict.ConflictingRule != "existing-rule" {
+		t.Errorf("expected the conflicting rule, got %+v", conflict)
+	}
+
+	_, err = c.CreateProxyRule(context.Background(), "new-rule", ProxyRuleSpec{Domain: "new.example.com", Destination: "10.0.0.51", Port: 70000})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Field != "spec.port" {
+		t.Errorf("expected an error on spec.port, got %+v", validationErr.Errors)
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyBytes bounds how much of an error response is read
+const maxErrorBodyBytes = 64 << 10
+
+// StatusError is returned for error responses without a more specific type
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// NotFoundError is returned when the requested rule does not exist
+type NotFoundError struct {
+	Name    string
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("proxy rule %q not found: %s", e.Name, e.Message)
+}
+
+// ConflictError is returned when a rule's name or domain is already taken, or
+// the rule changed since it was read
+type ConflictError struct {
+	// Reason is DuplicateName or DuplicateDomain, or empty for other conflicts
+	Reason           string
+	Message          string
+	ConflictingRule  string
+	ConflictingValue string
+}
+
+func (e *ConflictError) Error() string {
+	return "conflict: " + e.Message
+}
+
+// ValidationError is returned when the server rejects a rule as invalid
+type ValidationError struct {
+	StatusCode int          `json:"status"`
+	Message    string       `json:"message"`
+	Errors     []FieldError `json:"errors"`
+}
+
+// FieldError is a problem with one field of a rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 0 {
+		return "validation failed: " + e.Message
+	}
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// newResponseError maps an error response for the rule called name to the
+// matching error type
+func newResponseError(resp *http.Response, name string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	message := strings.TrimSpace(string(body))
+	isJSON := strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		// Not-found suggestions come as JSON; keep only the message
+		var suggestions struct {
+			Message string `json:"message"`
+		}
+		if isJSON && json.Unmarshal(body, &suggestions) == nil && suggestions.Message != "" {
+			message = suggestions.Message
+		}
+		return &NotFoundError{Name: name, Message: message}
+
+	case http.StatusConflict:
+		// Duplicate names and domains come as a JSON error; other conflicts are plain text
+		var envelope struct {
+			Error struct {
+				Code             string `json:"code"`
+				Message          string `json:"message"`
+				ConflictingRule  string `json:"conflictingRule"`
+				ConflictingValue string `json:"conflictingValue"`
+			} `json:"error"`
+		}
+		if isJSON && json.Unmarshal(body, &envelope) == nil && envelope.Error.Message != "" {
+			return &ConflictError{
+				Reason:           envelope.Error.Code,
+				Message:          envelope.Error.Message,
+				ConflictingRule:  envelope.Error.ConflictingRule,
+				ConflictingValue: envelope.Error.ConflictingValue,
+			}
+		}
+		return &ConflictError{Message: message}
+
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		// Only validation failures are structured; other 400s stay plain text
+		validationErr := &ValidationError{}
+		if isJSON && json.Unmarshal(body, validationErr) == nil {
+			validationErr.StatusCode = resp.StatusCode
+			return validationErr
+		}
+	}
+
+	return &StatusError{StatusCode: resp.StatusCode, Message: message}
+}